@@ -0,0 +1,5 @@
+// Package stickypb contains protobuf messages and grpc client and server of sticky,
+// generated from sticky.proto. Clients in other languages are generated from the same file.
+package stickypb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative sticky.proto
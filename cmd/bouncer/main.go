@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"fmt"
 	"io"
 	"log/slog"
@@ -9,6 +11,9 @@ import (
 	"time"
 
 	"github.com/aosderzhikov/sticky/internal/bouncer"
+	"github.com/aosderzhikov/sticky/internal/resp"
+	"github.com/aosderzhikov/sticky/internal/rpc"
+	"github.com/aosderzhikov/sticky/internal/typed"
 	"github.com/caarlos0/env/v9"
 	"gopkg.in/yaml.v3"
 )
@@ -18,14 +23,63 @@ type Config struct {
 }
 
 type BouncerConfig struct {
-	DebugMode bool            `yaml:"debugMode"`
-	Addr      string          `yaml:"addr"`
-	Storages  []StorageConfig `yaml:"storages"`
+	DebugMode bool   `yaml:"debugMode"`
+	Addr      string `yaml:"addr"`
+	// RESPAddr is address of RESP listener, it isnt started if address is empty.
+	RESPAddr string `yaml:"respAddr"`
+	// GRPCAddr is address of grpc listener, it isnt started if address is empty.
+	GRPCAddr string `yaml:"grpcAddr"`
+	// MaxValueSize limits value of set request in bytes, larger value gets 413.
+	MaxValueSize int64 `yaml:"maxValueSize"`
+	// StreamThreshold is size of value in bytes above which it is streamed to storage without buffering.
+	StreamThreshold int64 `yaml:"streamThreshold"`
+	// UploadIdleTimeout limits waiting for next part of value of set request.
+	UploadIdleTimeout time.Duration   `yaml:"uploadIdleTimeout"`
+	Cache             CacheConfig     `yaml:"cache"`
+	Storages          []StorageConfig `yaml:"storages"`
+}
+
+type CacheConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	MaxEntries   int           `yaml:"maxEntries"`
+	MaxValueSize int           `yaml:"maxValueSize"`
+	MaxStaleness time.Duration `yaml:"maxStaleness"`
 }
 
 type StorageConfig struct {
-	Addr                string        `yaml:"addr"`
-	HealthCheckInterval time.Duration `yaml:"healthCheckInterval" envDefault:"5s"`
+	Addr                string         `yaml:"addr"`
+	HealthCheckInterval time.Duration  `yaml:"healthCheckInterval" envDefault:"5s"`
+	Timeouts            TimeoutsConfig `yaml:"timeouts"`
+	Retry               RetryConfig    `yaml:"retry"`
+	// Transport is http by default.
+	Transport TransportConfig `yaml:"transport"`
+}
+
+type TimeoutsConfig struct {
+	Connect time.Duration `yaml:"connect"`
+	Read    time.Duration `yaml:"read"`
+	Total   time.Duration `yaml:"total"`
+}
+
+type RetryConfig struct {
+	MaxAttempts int           `yaml:"maxAttempts"`
+	BaseDelay   time.Duration `yaml:"baseDelay"`
+	MaxDelay    time.Duration `yaml:"maxDelay"`
+}
+
+type TransportConfig struct {
+	Kind        string `yaml:"kind"`
+	Addr        string `yaml:"addr"`
+	Conns       int    `yaml:"conns"`
+	MaxInFlight int    `yaml:"maxInFlight"`
+}
+
+func (s StorageConfig) shardOptions() bouncer.ShardOptions {
+	return bouncer.ShardOptions{
+		Timeouts:  bouncer.Timeouts(s.Timeouts),
+		Retry:     bouncer.RetryPolicy(s.Retry),
+		Transport: bouncer.Transport(s.Transport),
+	}
 }
 
 func load(r io.Reader) (Config, error) {
@@ -82,18 +136,74 @@ func main() {
 			return
 		}
 
-		shard := bouncer.NewShard(s.Addr, s.HealthCheckInterval, nil)
+		opts := s.shardOptions()
+		if err = opts.Transport.Validate(); err != nil {
+			slog.Error(fmt.Sprintf("storage %q: %v", s.Addr, err))
+			return
+		}
+
+		shard := bouncer.NewShard(s.Addr, s.HealthCheckInterval, nil, opts)
 		shard.Run()
 		storages = append(storages, shard)
 	}
 
-	service := bouncer.NewShardService(storages)
-	handler := bouncer.NewHandler(service)
+	service := bouncer.NewShardService(storages, bouncer.ServiceOptions{
+		Cache: bouncer.CacheConfig(cfg.Bouncer.Cache),
+	})
+	if cfg.Bouncer.Cache.Enabled {
+		service.RunInvalidation(context.Background())
+	}
+	handler := bouncer.NewHandler(service, bouncer.HandlerOptions{
+		MaxValueSize:      cfg.Bouncer.MaxValueSize,
+		StreamThreshold:   cfg.Bouncer.StreamThreshold,
+		UploadIdleTimeout: cfg.Bouncer.UploadIdleTimeout,
+	})
+
+	if cfg.Bouncer.RESPAddr != "" {
+		go func() {
+			slog.Info(fmt.Sprintf("start bouncer resp listener on %q", cfg.Bouncer.RESPAddr))
+			if err := resp.NewServer(bouncer.NewRESPBackend(service)).ListenAndServe(cfg.Bouncer.RESPAddr); err != nil {
+				slog.Error(err.Error())
+			}
+		}()
+	}
+
+	if cfg.Bouncer.GRPCAddr != "" {
+		go func() {
+			slog.Info(fmt.Sprintf("start bouncer grpc listener on %q", cfg.Bouncer.GRPCAddr))
+			if err := rpc.ListenAndServe(cfg.Bouncer.GRPCAddr, bouncer.NewRPCBackend(service)); err != nil {
+				slog.Error(err.Error())
+			}
+		}()
+	}
+
+	expvar.Publish("bouncer", expvar.Func(func() any { return service.Stats() }))
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /get", handler.GetHandle)
 	mux.HandleFunc("POST /set", handler.SetHandle)
 	mux.HandleFunc("DELETE /delete", handler.DeleteHandle)
+	mux.HandleFunc("POST /mget", handler.MGetHandle)
+	mux.HandleFunc("POST /mset", handler.MSetHandle)
+	mux.HandleFunc("POST /mdel", handler.MDeleteHandle)
+	mux.HandleFunc("POST /tx", handler.TxHandle)
+	mux.HandleFunc("POST /incr", handler.IncrHandle)
+	mux.HandleFunc("POST /decr", handler.DecrHandle)
+	mux.HandleFunc("POST /incrfloat", handler.IncrFloatHandle)
+	mux.HandleFunc("POST /ratelimit", handler.RateLimitHandle)
+	mux.HandleFunc("POST /lock", handler.LockHandle)
+	mux.HandleFunc("POST /unlock", handler.UnlockHandle)
+	mux.HandleFunc("POST /renew", handler.RenewHandle)
+	mux.HandleFunc("GET /watch", handler.WatchHandle)
+	mux.HandleFunc("GET /subscribe", handler.SubscribeHandle)
+	mux.HandleFunc("POST /publish", handler.PublishHandle)
+	mux.HandleFunc("GET /v2/keys/{key...}", handler.GetV2Handle)
+	mux.HandleFunc("PUT /v2/keys/{key...}", handler.PutV2Handle)
+	mux.HandleFunc("DELETE /v2/keys/{key...}", handler.DeleteV2Handle)
+	for _, op := range typed.Ops {
+		mux.HandleFunc(op.Method()+" /"+string(op), handler.TypedHandle(op))
+	}
+	mux.Handle("GET /debug/vars", expvar.Handler())
 
 	slog.Info(fmt.Sprintf("start bouncer on %q", cfg.Bouncer.Addr))
 	if err = http.ListenAndServe(cfg.Bouncer.Addr, mux); err != nil {
@@ -1,19 +1,49 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/aosderzhikov/sticky/internal/keeper"
+	"github.com/aosderzhikov/sticky/internal/keyring"
+	"github.com/aosderzhikov/sticky/internal/resp"
+	"github.com/aosderzhikov/sticky/internal/rpc"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/aosderzhikov/sticky/internal/webhook"
+	"github.com/aosderzhikov/sticky/internal/wire"
+	"gopkg.in/yaml.v3"
 )
 
 const (
 	httpAddrEnv = "HTTP_ADDRESS"
 	ttlEnv      = "TTL"
 	debugEnv    = "DEBUG"
+	// webhooksEnv is path to yaml file with webhooks registered on start.
+	webhooksEnv = "WEBHOOKS_CONFIG"
+	// respAddrEnv is address of RESP listener, it isnt started if env is empty.
+	respAddrEnv = "RESP_ADDRESS"
+	// binaryAddrEnv is address of binary listener for bouncer, it isnt started if env is empty.
+	binaryAddrEnv = "BINARY_ADDRESS"
+	// grpcAddrEnv is address of grpc listener, it isnt started if env is empty.
+	grpcAddrEnv = "GRPC_ADDRESS"
+	// maxValueSizeEnv limits value of set request in bytes, larger value gets 413.
+	maxValueSizeEnv = "MAX_VALUE_SIZE"
+	// uploadIdleTimeoutEnv limits waiting for next part of value of set request.
+	uploadIdleTimeoutEnv = "UPLOAD_IDLE_TIMEOUT"
+	// compressionEnv is codec of values compression, gzip or flate, values arent compressed if env is empty.
+	compressionEnv = "COMPRESSION"
+	// compressionThresholdEnv is min size of compressed value in bytes.
+	compressionThresholdEnv = "COMPRESSION_THRESHOLD"
+	// keyfileEnv is path to yaml keyfile, values are encrypted in memory if env isnt empty.
+	keyfileEnv = "ENCRYPTION_KEYFILE"
 
 	defaultAddr = "localhost:8181"
 	defaultTTL  = "10m"
@@ -35,6 +65,38 @@ func main() {
 		return
 	}
 
+	var maxValueSize int64
+	if s := os.Getenv(maxValueSizeEnv); s != "" {
+		maxValueSize, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			slog.Error(err.Error())
+			return
+		}
+	}
+
+	var uploadIdleTimeout time.Duration
+	if s := os.Getenv(uploadIdleTimeoutEnv); s != "" {
+		uploadIdleTimeout, err = time.ParseDuration(s)
+		if err != nil {
+			slog.Error(err.Error())
+			return
+		}
+	}
+
+	codec, err := keeper.ParseCodec(os.Getenv(compressionEnv))
+	if err != nil {
+		slog.Error(err.Error())
+		return
+	}
+	var compressionThreshold int
+	if s := os.Getenv(compressionThresholdEnv); s != "" {
+		compressionThreshold, err = strconv.Atoi(s)
+		if err != nil {
+			slog.Error(err.Error())
+			return
+		}
+	}
+
 	debugMode := os.Getenv(debugEnv)
 	if debugMode == "true" {
 		slog.SetLogLoggerLevel(slog.LevelDebug)
@@ -42,15 +104,89 @@ func main() {
 	}
 
 	k := keeper.NewService(ttl)
+	k.SetCompression(keeper.CompressionOptions{Codec: codec, Threshold: compressionThreshold})
 	k.Run()
 
-	handler := keeper.NewHandler(k)
+	if path := os.Getenv(keyfileEnv); path != "" {
+		if err = loadKeyring(k, path); err != nil {
+			slog.Error(err.Error())
+			return
+		}
+		go reloadKeyringOnHangup(k, path)
+	}
+
+	if path := os.Getenv(webhooksEnv); path != "" {
+		if err = registerWebhooks(k, path); err != nil {
+			slog.Error(err.Error())
+			return
+		}
+	}
+
+	if respAddr := os.Getenv(respAddrEnv); respAddr != "" {
+		go func() {
+			slog.Info(fmt.Sprintf("start keeper resp listener on %q", respAddr))
+			if err := resp.NewServer(keeper.NewRESPBackend(k)).ListenAndServe(respAddr); err != nil {
+				slog.Error(err.Error())
+			}
+		}()
+	}
+
+	if grpcAddr := os.Getenv(grpcAddrEnv); grpcAddr != "" {
+		go func() {
+			slog.Info(fmt.Sprintf("start keeper grpc listener on %q", grpcAddr))
+			if err := rpc.ListenAndServe(grpcAddr, keeper.NewRPCBackend(k)); err != nil {
+				slog.Error(err.Error())
+			}
+		}()
+	}
+
+	handler := keeper.NewHandler(k, keeper.HandlerOptions{
+		MaxValueSize:      maxValueSize,
+		UploadIdleTimeout: uploadIdleTimeout,
+	})
+
+	if binaryAddr := os.Getenv(binaryAddrEnv); binaryAddr != "" {
+		go func() {
+			slog.Info(fmt.Sprintf("start keeper binary listener on %q", binaryAddr))
+			if err := wire.NewServer(handler, 0).ListenAndServe(binaryAddr); err != nil {
+				slog.Error(err.Error())
+			}
+		}()
+	}
+
+	expvar.Publish("keeper", expvar.Func(func() any { return k.Stats() }))
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("GET /get", handler.GetHandle)
 	mux.HandleFunc("POST /set", handler.SetHandle)
 	mux.HandleFunc("DELETE /delete", handler.DeleteHandle)
+	mux.HandleFunc("POST /expire", handler.ExpireHandle)
+	mux.HandleFunc("POST /persist", handler.PersistHandle)
+	mux.HandleFunc("POST /mget", handler.MGetHandle)
+	mux.HandleFunc("POST /mset", handler.MSetHandle)
+	mux.HandleFunc("POST /mdel", handler.MDeleteHandle)
+	mux.HandleFunc("POST /tx", handler.TxHandle)
+	mux.HandleFunc("POST /incr", handler.IncrHandle)
+	mux.HandleFunc("POST /decr", handler.DecrHandle)
+	mux.HandleFunc("POST /incrfloat", handler.IncrFloatHandle)
+	mux.HandleFunc("POST /ratelimit", handler.RateLimitHandle)
+	mux.HandleFunc("POST /lock", handler.LockHandle)
+	mux.HandleFunc("POST /unlock", handler.UnlockHandle)
+	mux.HandleFunc("POST /renew", handler.RenewHandle)
+	for _, op := range typed.Ops {
+		mux.HandleFunc(op.Method()+" /"+string(op), handler.TypedHandle(op))
+	}
+	mux.HandleFunc("GET /watch", handler.WatchHandle)
+	mux.HandleFunc("GET /subscribe", handler.SubscribeHandle)
+	mux.HandleFunc("POST /publish", handler.PublishHandle)
+	mux.HandleFunc("GET /webhooks", handler.WebhooksHandle)
+	mux.HandleFunc("POST /webhooks", handler.RegisterWebhookHandle)
+	mux.HandleFunc("DELETE /webhooks", handler.UnregisterWebhookHandle)
+	mux.HandleFunc("GET /v2/keys/{key...}", handler.GetV2Handle)
+	mux.HandleFunc("PUT /v2/keys/{key...}", handler.PutV2Handle)
+	mux.HandleFunc("DELETE /v2/keys/{key...}", handler.DeleteV2Handle)
 	mux.HandleFunc("GET /health-check", handler.HealthCheckHandle)
+	mux.Handle("GET /debug/vars", expvar.Handler())
 
 	srv := http.Server{
 		Addr:              addr,
@@ -64,3 +200,61 @@ func main() {
 		slog.Error(err.Error())
 	}
 }
+
+type WebhooksConfig struct {
+	Webhooks []webhook.Webhook `yaml:"webhooks"`
+}
+
+func registerWebhooks(k *keeper.Keeper, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var cfg WebhooksConfig
+	if err = yaml.NewDecoder(f).Decode(&cfg); err != nil {
+		return fmt.Errorf("decode webhooks config: %w", err)
+	}
+
+	for _, w := range cfg.Webhooks {
+		if _, err = k.RegisterWebhook(w); err != nil {
+			return fmt.Errorf("register webhook %q: %w", w.URL, err)
+		}
+	}
+	return nil
+}
+
+func loadKeyring(k *keeper.Keeper, path string) error {
+	kr, err := keyring.Load(path)
+	if err != nil {
+		return fmt.Errorf("load keyfile: %w", err)
+	}
+	if err = k.SetEncryption(kr); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("values are encrypted with key %q", kr.Primary()))
+	return nil
+}
+
+// reloadKeyringOnHangup reloads keyfile on SIGHUP, so primary key can be rotated without restart.
+// Values encrypted with previous key are reencrypted in background, previous key has to stay
+// in keyfile until it is done.
+func reloadKeyringOnHangup(k *keeper.Keeper, path string) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	for range hangup {
+		if err := loadKeyring(k, path); err != nil {
+			slog.Error(err.Error())
+			continue
+		}
+
+		n, err := k.Reencrypt(context.Background())
+		if err != nil {
+			slog.Error(fmt.Sprintf("reencrypt values: %v", err))
+			continue
+		}
+		slog.Info(fmt.Sprintf("%d values are reencrypted", n))
+	}
+}
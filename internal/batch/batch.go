@@ -0,0 +1,96 @@
+package batch
+
+import (
+	"errors"
+	"time"
+)
+
+// Item is a single key of batch request. Value and TTL are used only by mset.
+type Item struct {
+	Key   string   `json:"key"`
+	Value []byte   `json:"value,omitempty"`
+	TTL   Duration `json:"ttl,omitempty"`
+}
+
+type Request struct {
+	Items []Item `json:"items"`
+}
+
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusNotFound Status = "not_found"
+	StatusError    Status = "error"
+)
+
+// Result is a status of single key of batch request. Value is filled only by mget.
+type Result struct {
+	Key    string `json:"key"`
+	Status Status `json:"status"`
+	Value  []byte `json:"value,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Response struct {
+	Results []Result `json:"results"`
+}
+
+const MaxItems = 1000
+
+var (
+	ErrEmptyBatch   error = errors.New("batch cannot be empty")
+	ErrTooManyItems error = errors.New("batch is too large")
+	ErrEmptyKey     error = errors.New("key in batch cannot be empty")
+)
+
+func (r Request) Validate() error {
+	if len(r.Items) == 0 {
+		return ErrEmptyBatch
+	}
+	if len(r.Items) > MaxItems {
+		return ErrTooManyItems
+	}
+	for _, item := range r.Items {
+		if item.Key == "" {
+			return ErrEmptyKey
+		}
+	}
+	return nil
+}
+
+func (r Request) Keys() []string {
+	keys := make([]string, 0, len(r.Items))
+	for _, item := range r.Items {
+		keys = append(keys, item.Key)
+	}
+	return keys
+}
+
+func KeysRequest(keys []string) Request {
+	items := make([]Item, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, Item{Key: key})
+	}
+	return Request{items}
+}
+
+func ErrorResult(key string, err error) Result {
+	return Result{Key: key, Status: StatusError, Error: err.Error()}
+}
+
+// Duration is time.Duration encoded in json as string, e.g. "1m30s".
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
@@ -0,0 +1,200 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+)
+
+const (
+	ContentTypeJSON   = "application/json"
+	ContentTypeBinary = "application/octet-stream"
+
+	// maxFieldLen bounds length of single field of binary encoding.
+	maxFieldLen = 64 << 20
+)
+
+var ErrUnsupportedContentType error = errors.New("unsupported content type of batch")
+
+// ContentType returns content type of batch request, json is used by default.
+func ContentType(h http.Header) (string, error) {
+	ct := h.Get("Content-Type")
+	if ct == "" {
+		return ContentTypeJSON, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return "", errors.Join(ErrUnsupportedContentType, err)
+	}
+	switch mediaType {
+	case ContentTypeJSON, ContentTypeBinary:
+		return mediaType, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedContentType, mediaType)
+	}
+}
+
+func DecodeRequest(r io.Reader, contentType string) (Request, error) {
+	var req Request
+	if contentType == ContentTypeJSON {
+		err := json.NewDecoder(r).Decode(&req)
+		return req, err
+	}
+
+	br := bufio.NewReader(r)
+	count, err := readUint32(br)
+	if err != nil {
+		return req, err
+	}
+	if count > MaxItems {
+		return req, ErrTooManyItems
+	}
+
+	req.Items = make([]Item, 0, count)
+	for range count {
+		var item Item
+		key, err := readField(br)
+		if err != nil {
+			return req, err
+		}
+		item.Key = string(key)
+
+		if item.Value, err = readField(br); err != nil {
+			return req, err
+		}
+
+		var ttl int64
+		if err = binary.Read(br, binary.BigEndian, &ttl); err != nil {
+			return req, err
+		}
+		item.TTL = Duration(ttl)
+
+		req.Items = append(req.Items, item)
+	}
+	return req, nil
+}
+
+func EncodeRequest(w io.Writer, contentType string, req Request) error {
+	if contentType == ContentTypeJSON {
+		return json.NewEncoder(w).Encode(req)
+	}
+
+	bw := bufio.NewWriter(w)
+	writeUint32(bw, uint32(len(req.Items)))
+	for _, item := range req.Items {
+		writeField(bw, []byte(item.Key))
+		writeField(bw, item.Value)
+		_ = binary.Write(bw, binary.BigEndian, int64(item.TTL))
+	}
+	return bw.Flush()
+}
+
+// statuses are encoded in binary by index.
+var statuses = []Status{StatusOK, StatusNotFound, StatusError}
+
+func DecodeResponse(r io.Reader, contentType string) (Response, error) {
+	var resp Response
+	if contentType == ContentTypeJSON {
+		err := json.NewDecoder(r).Decode(&resp)
+		return resp, err
+	}
+
+	br := bufio.NewReader(r)
+	count, err := readUint32(br)
+	if err != nil {
+		return resp, err
+	}
+	if count > MaxItems {
+		return resp, ErrTooManyItems
+	}
+
+	resp.Results = make([]Result, 0, count)
+	for range count {
+		var res Result
+		key, err := readField(br)
+		if err != nil {
+			return resp, err
+		}
+		res.Key = string(key)
+
+		code, err := br.ReadByte()
+		if err != nil {
+			return resp, err
+		}
+		res.Status = StatusError
+		if int(code) < len(statuses) {
+			res.Status = statuses[code]
+		}
+
+		if res.Value, err = readField(br); err != nil {
+			return resp, err
+		}
+
+		errMsg, err := readField(br)
+		if err != nil {
+			return resp, err
+		}
+		res.Error = string(errMsg)
+
+		resp.Results = append(resp.Results, res)
+	}
+	return resp, nil
+}
+
+func EncodeResponse(w io.Writer, contentType string, resp Response) error {
+	if contentType == ContentTypeJSON {
+		return json.NewEncoder(w).Encode(resp)
+	}
+
+	bw := bufio.NewWriter(w)
+	writeUint32(bw, uint32(len(resp.Results)))
+	for _, res := range resp.Results {
+		writeField(bw, []byte(res.Key))
+		_ = bw.WriteByte(byte(slices.Index(statuses, res.Status)))
+		writeField(bw, res.Value)
+		writeField(bw, []byte(res.Error))
+	}
+	return bw.Flush()
+}
+
+// WriteResponse writes batch response with the same content type as request had.
+func WriteResponse(w http.ResponseWriter, contentType string, results []Result) error {
+	w.Header().Set("Content-Type", contentType)
+	return EncodeResponse(w, contentType, Response{results})
+}
+
+func readUint32(r io.Reader) (uint32, error) {
+	var v uint32
+	err := binary.Read(r, binary.BigEndian, &v)
+	return v, err
+}
+
+func writeUint32(w io.Writer, v uint32) {
+	_ = binary.Write(w, binary.BigEndian, v)
+}
+
+func readField(r io.Reader) ([]byte, error) {
+	n, err := readUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > maxFieldLen {
+		return nil, fmt.Errorf("field length %d exceeds limit", n)
+	}
+
+	b := make([]byte, n)
+	_, err = io.ReadFull(r, b)
+	return b, err
+}
+
+func writeField(w io.Writer, b []byte) {
+	writeUint32(w, uint32(len(b)))
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,78 @@
+package batch
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestRoundTrip(t *testing.T) {
+	req := Request{Items: []Item{
+		{Key: "key1", Value: []byte("data"), TTL: Duration(time.Minute)},
+		{Key: "key2", Value: []byte{}},
+	}}
+
+	for _, contentType := range []string{ContentTypeJSON, ContentTypeBinary} {
+		t.Run(contentType, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, EncodeRequest(&buf, contentType, req))
+
+			got, err := DecodeRequest(&buf, contentType)
+			require.NoError(t, err)
+			require.Equal(t, req.Keys(), got.Keys())
+			require.Equal(t, "data", string(got.Items[0].Value))
+			require.Equal(t, Duration(time.Minute), got.Items[0].TTL)
+			require.Empty(t, got.Items[1].Value)
+		})
+	}
+}
+
+func TestResponseRoundTrip(t *testing.T) {
+	resp := Response{Results: []Result{
+		{Key: "key1", Status: StatusOK, Value: []byte("data")},
+		{Key: "key2", Status: StatusNotFound},
+		{Key: "key3", Status: StatusError, Error: "failed"},
+	}}
+
+	for _, contentType := range []string{ContentTypeJSON, ContentTypeBinary} {
+		t.Run(contentType, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, EncodeResponse(&buf, contentType, resp))
+
+			got, err := DecodeResponse(&buf, contentType)
+			require.NoError(t, err)
+			require.Len(t, got.Results, 3)
+			for i, res := range resp.Results {
+				require.Equal(t, res.Key, got.Results[i].Key)
+				require.Equal(t, res.Status, got.Results[i].Status)
+				require.Equal(t, string(res.Value), string(got.Results[i].Value))
+				require.Equal(t, res.Error, got.Results[i].Error)
+			}
+		})
+	}
+}
+
+func TestContentType(t *testing.T) {
+	h := http.Header{}
+	ct, err := ContentType(h)
+	require.NoError(t, err)
+	require.Equal(t, ContentTypeJSON, ct)
+
+	h.Set("Content-Type", "application/octet-stream")
+	ct, err = ContentType(h)
+	require.NoError(t, err)
+	require.Equal(t, ContentTypeBinary, ct)
+
+	h.Set("Content-Type", "text/plain")
+	_, err = ContentType(h)
+	require.ErrorIs(t, err, ErrUnsupportedContentType)
+}
+
+func TestValidate(t *testing.T) {
+	require.ErrorIs(t, Request{}.Validate(), ErrEmptyBatch)
+	require.ErrorIs(t, KeysRequest([]string{"key1", ""}).Validate(), ErrEmptyKey)
+	require.ErrorIs(t, KeysRequest(make([]string, MaxItems+1)).Validate(), ErrTooManyItems)
+}
@@ -0,0 +1,192 @@
+package bouncer
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheConfig describes near cache of recently read values.
+type CacheConfig struct {
+	Enabled bool
+	// MaxEntries bounds number of cached values, the least recently used is evicted first.
+	MaxEntries int
+	// MaxValueSize in bytes, larger values arent cached.
+	MaxValueSize int
+	// MaxStaleness bounds how long value is cached. Value is never cached longer than its ttl.
+	MaxStaleness time.Duration
+}
+
+type CacheStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Entries int   `json:"entries"`
+}
+
+// recentInvalidations bounds number of keys whose generation is tracked.
+const recentInvalidations = 4096
+
+type nearCache struct {
+	cfg CacheConfig
+
+	hits   atomic.Int64
+	misses atomic.Int64
+
+	mu  sync.Mutex
+	gen uint64
+	// invalidated has generation of the last invalidation of recently invalidated keys,
+	// invalidations are kept in order in queue. floor is generation of the last clear or
+	// forgotten invalidation, so it is generation of keys missing in invalidated.
+	invalidated map[string]uint64
+	queue       []invalidation
+	floor       uint64
+	lru         *list.List
+	items       map[string]*list.Element
+}
+
+type invalidation struct {
+	key string
+	gen uint64
+}
+
+type cacheItem struct {
+	key   string
+	entry Entry
+	// expiresAt is when item is dropped, it isnt later than expiration of entry.
+	expiresAt time.Time
+}
+
+func newNearCache(cfg CacheConfig) *nearCache {
+	return &nearCache{
+		cfg:         cfg,
+		invalidated: make(map[string]uint64),
+		lru:         list.New(),
+		items:       make(map[string]*list.Element),
+	}
+}
+
+func (c *nearCache) get(key string) (Entry, bool) {
+	if !c.cfg.Enabled {
+		return Entry{}, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if !ok {
+		c.misses.Add(1)
+		return Entry{}, false
+	}
+
+	item := e.Value.(*cacheItem)
+	if !time.Now().Before(item.expiresAt) {
+		c.removeLocked(e)
+		c.misses.Add(1)
+		return Entry{}, false
+	}
+
+	c.lru.MoveToFront(e)
+	c.hits.Add(1)
+	return item.entry, true
+}
+
+// generation of key must be taken before reading of value from storage and passed to put,
+// so value read before concurrent invalidation of key isnt cached. Writes of other keys
+// dont change it.
+func (c *nearCache) generation(key string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generationLocked(key)
+}
+
+func (c *nearCache) generationLocked(key string) uint64 {
+	return max(c.invalidated[key], c.floor)
+}
+
+func (c *nearCache) put(key string, entry Entry, gen uint64) {
+	if !c.cfg.Enabled || len(entry.Value) > c.cfg.MaxValueSize {
+		return
+	}
+
+	expiresAt := time.Now().Add(c.cfg.MaxStaleness)
+	if !entry.ExpiresAt.IsZero() && entry.ExpiresAt.Before(expiresAt) {
+		expiresAt = entry.ExpiresAt
+	}
+	if !time.Now().Before(expiresAt) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if gen != c.generationLocked(key) {
+		return
+	}
+
+	if e, ok := c.items[key]; ok {
+		c.removeLocked(e)
+	}
+	c.items[key] = c.lru.PushFront(&cacheItem{key, entry, expiresAt})
+
+	for c.lru.Len() > c.cfg.MaxEntries {
+		c.removeLocked(c.lru.Back())
+	}
+}
+
+// invalidate changes generation of key even if cache is disabled, as reads are coalesced by it.
+func (c *nearCache) invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.invalidated[key] = c.gen
+	c.queue = append(c.queue, invalidation{key, c.gen})
+	for len(c.queue) > recentInvalidations {
+		oldest := c.queue[0]
+		c.queue = c.queue[1:]
+		// key could be invalidated again later, then its generation is kept
+		if c.invalidated[oldest.key] == oldest.gen {
+			delete(c.invalidated, oldest.key)
+			c.floor = oldest.gen
+		}
+	}
+
+	if e, ok := c.items[key]; ok {
+		c.removeLocked(e)
+	}
+}
+
+func (c *nearCache) clear() {
+	if !c.cfg.Enabled {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	c.floor = c.gen
+	clear(c.invalidated)
+	c.queue = nil
+	c.lru.Init()
+	clear(c.items)
+}
+
+func (c *nearCache) removeLocked(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.items, e.Value.(*cacheItem).key)
+}
+
+func (c *nearCache) stats() CacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+
+	return CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: entries,
+	}
+}
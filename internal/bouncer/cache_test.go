@@ -0,0 +1,165 @@
+package bouncer
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCacheHit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := NewMockStorage(ctrl)
+	storage.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage.EXPECT().Get(gomock.Any(), "key1").Return(Entry{Value: []byte("data")}, nil).Times(1)
+
+	s := NewShardService([]Storage{storage}, ServiceOptions{
+		Cache: CacheConfig{Enabled: true, MaxEntries: 10, MaxValueSize: 10, MaxStaleness: time.Minute},
+	})
+	s.setStorageIndex("key1", 0)
+
+	for range 3 {
+		value, err := s.Get(context.Background(), "key1")
+		require.NoError(t, err)
+		require.Equal(t, "data", string(value))
+	}
+	require.Equal(t, CacheStats{Hits: 2, Misses: 1, Entries: 1}, s.Stats().Cache)
+}
+
+func TestCacheInvalidatedOnSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := NewMockStorage(ctrl)
+	storage.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage.EXPECT().Set(gomock.Any(), "key1", []byte("data2"), time.Duration(0), meta.Meta{}).Return(nil)
+	gomock.InOrder(
+		storage.EXPECT().Get(gomock.Any(), "key1").Return(Entry{Value: []byte("data1")}, nil),
+		storage.EXPECT().Get(gomock.Any(), "key1").Return(Entry{Value: []byte("data2")}, nil),
+	)
+
+	s := NewShardService([]Storage{storage}, ServiceOptions{
+		Cache: CacheConfig{Enabled: true, MaxEntries: 10, MaxValueSize: 10, MaxStaleness: time.Minute},
+	})
+	s.setStorageIndex("key1", 0)
+
+	value, err := s.Get(context.Background(), "key1")
+	require.NoError(t, err)
+	require.Equal(t, "data1", string(value))
+
+	require.NoError(t, s.Set(context.Background(), "key1", []byte("data2"), 0))
+
+	value, err = s.Get(context.Background(), "key1")
+	require.NoError(t, err)
+	require.Equal(t, "data2", string(value))
+}
+
+func TestCacheBounds(t *testing.T) {
+	c := newNearCache(CacheConfig{Enabled: true, MaxEntries: 2, MaxValueSize: 4, MaxStaleness: time.Minute})
+
+	c.put("big", Entry{Value: []byte("12345")}, c.generation("big"))
+	_, ok := c.get("big")
+	require.False(t, ok, "value bigger than max size is cached")
+
+	c.put("expiring", Entry{Value: []byte("1"), ExpiresAt: time.Now().Add(10 * time.Millisecond)}, c.generation("expiring"))
+	_, ok = c.get("expiring")
+	require.True(t, ok)
+	time.Sleep(10 * time.Millisecond)
+	_, ok = c.get("expiring")
+	require.False(t, ok, "value outlived its ttl")
+
+	c.put("key1", Entry{Value: []byte("1")}, c.generation("key1"))
+	c.put("key2", Entry{Value: []byte("2")}, c.generation("key2"))
+	c.put("key3", Entry{Value: []byte("3")}, c.generation("key3"))
+	_, ok = c.get("key1")
+	require.False(t, ok, "least recently used value isnt evicted")
+
+	gen := c.generation("key2")
+	c.invalidate("key2")
+	c.put("key2", Entry{Value: []byte("2")}, gen)
+	_, ok = c.get("key2")
+	require.False(t, ok, "value read before invalidation is cached")
+}
+
+func TestCacheGenerationPerKey(t *testing.T) {
+	c := newNearCache(CacheConfig{Enabled: true, MaxEntries: 10, MaxValueSize: 4, MaxStaleness: time.Minute})
+
+	// write of another key doesnt prevent caching
+	gen := c.generation("key1")
+	c.invalidate("key2")
+	c.put("key1", Entry{Value: []byte("1")}, gen)
+	_, ok := c.get("key1")
+	require.True(t, ok)
+
+	// generation of forgotten invalidation is kept by floor
+	gen = c.generation("key1")
+	c.invalidate("key1")
+	for i := range recentInvalidations {
+		c.invalidate(strconv.Itoa(i))
+	}
+	require.Len(t, c.invalidated, recentInvalidations)
+	c.put("key1", Entry{Value: []byte("1")}, gen)
+	_, ok = c.get("key1")
+	require.False(t, ok, "value read before forgotten invalidation is cached")
+}
+
+// blockingStorage returns old value from the first read after release is closed.
+type blockingStorage struct {
+	Storage
+	started chan struct{}
+	release chan struct{}
+	calls   atomic.Int64
+}
+
+func (s *blockingStorage) Get(ctx context.Context, key string) (Entry, error) {
+	if s.calls.Add(1) == 1 {
+		close(s.started)
+		<-s.release
+		return Entry{Value: []byte("old")}, nil
+	}
+	return Entry{Value: []byte("new")}, nil
+}
+
+func (s *blockingStorage) Set(ctx context.Context, key string, value []byte, ttl time.Duration, m meta.Meta) error {
+	return nil
+}
+
+func (s *blockingStorage) IsAlive() bool {
+	return true
+}
+
+func TestCacheSetDuringRead(t *testing.T) {
+	storage := &blockingStorage{started: make(chan struct{}), release: make(chan struct{})}
+	s := NewShardService([]Storage{storage}, ServiceOptions{
+		Cache: CacheConfig{Enabled: true, MaxEntries: 10, MaxValueSize: 10, MaxStaleness: time.Minute},
+	})
+	s.setStorageIndex("key1", 0)
+
+	old := make(chan []byte)
+	go func() {
+		value, _ := s.Get(context.Background(), "key1")
+		old <- value
+	}()
+	<-storage.started
+
+	require.NoError(t, s.Set(context.Background(), "key1", []byte("new"), 0))
+
+	// read started after set doesnt join read started before it, which is still blocked
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	value, err := s.Get(ctx, "key1")
+	require.NoError(t, err)
+	require.Equal(t, "new", string(value))
+
+	close(storage.release)
+	require.Equal(t, "old", string(<-old))
+
+	// value of read started before set isnt cached
+	value, err = s.Get(context.Background(), "key1")
+	require.NoError(t, err)
+	require.Equal(t, "new", string(value))
+	require.Equal(t, int64(2), storage.calls.Load())
+}
@@ -0,0 +1,58 @@
+package bouncer
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/aosderzhikov/sticky/internal/tx"
+)
+
+// setIfMatch stores value if request has no If-Match or it holds, otherwise it returns handler.ErrPreconditionFailed.
+func (h *Handler) setIfMatch(w http.ResponseWriter, r *http.Request, key string, value []byte, ttl time.Duration, m meta.Meta) error {
+	cond, ok := handler.ExtractIfMatch(r)
+	if !ok {
+		return h.s.SetWithMeta(r.Context(), key, value, ttl, m)
+	}
+	return h.writeIfMatch(w, r, key, cond, tx.Op{Op: tx.OpSet, Key: key, Value: value, TTL: batch.Duration(ttl), Meta: m})
+}
+
+// deleteIfMatch deletes key if request has no If-Match or it holds, otherwise it returns handler.ErrPreconditionFailed.
+func (h *Handler) deleteIfMatch(w http.ResponseWriter, r *http.Request, key string) error {
+	cond, ok := handler.ExtractIfMatch(r)
+	if !ok {
+		return h.s.Delete(r.Context(), key)
+	}
+	return h.writeIfMatch(w, r, key, cond, tx.Op{Op: tx.OpDelete, Key: key})
+}
+
+// writeIfMatch applies op to key if ETag of key matches cond, ETag of written value is put to response.
+// Version is checked again by storage in transaction, so key changed after it was read isnt written.
+func (h *Handler) writeIfMatch(w http.ResponseWriter, r *http.Request, key string, cond handler.IfMatch, op tx.Op) error {
+	ctx := r.Context()
+
+	entry, err := h.s.GetEntry(ctx, key)
+	if errors.Is(err, ErrKeyNotExist) {
+		return handler.ErrPreconditionFailed
+	}
+	if err != nil {
+		return err
+	}
+	if !cond.Matches(entry.Version) {
+		return handler.ErrPreconditionFailed
+	}
+
+	resp, err := h.s.Exec(ctx, tx.Tx{Ops: []tx.Op{{Op: tx.OpCheck, Key: key, Version: entry.Version}, op}})
+	if err != nil {
+		return err
+	}
+	if !resp.Committed {
+		return handler.ErrPreconditionFailed
+	}
+
+	handler.PutValidators(w, resp.Results[1].Version, time.Time{})
+	return nil
+}
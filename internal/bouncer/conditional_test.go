@@ -0,0 +1,78 @@
+package bouncer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/tx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestConditionalGet(t *testing.T) {
+	updated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	service := NewMockService(ctrl)
+	service.EXPECT().GetCached(gomock.Any(), "key").Return(Entry{Value: []byte("value"), Version: 3, UpdatedAt: updated}, nil).AnyTimes()
+
+	h := NewHandler(service, HandlerOptions{})
+	get := func(headers ...string) *http.Response {
+		req, err := http.NewRequest(http.MethodGet, "http://test?key=key", http.NoBody)
+		require.NoError(t, err)
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+		rec := httptest.NewRecorder()
+		h.GetHandle(rec, req)
+		return rec.Result()
+	}
+
+	resp := get()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, `"3"`, resp.Header.Get("ETag"))
+	require.Equal(t, updated.Format(http.TimeFormat), resp.Header.Get("Last-Modified"))
+
+	require.Equal(t, http.StatusNotModified, get("If-None-Match", `"3"`).StatusCode)
+	require.Equal(t, http.StatusOK, get("If-None-Match", `"2"`).StatusCode)
+	require.Equal(t, http.StatusNotModified, get("If-Modified-Since", updated.Format(http.TimeFormat)).StatusCode)
+	require.Equal(t, http.StatusOK, get("If-Modified-Since", updated.Add(-time.Second).Format(http.TimeFormat)).StatusCode)
+}
+
+func TestConditionalSet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := NewMockService(ctrl)
+	service.EXPECT().GetEntry(gomock.Any(), "key").Return(Entry{Value: []byte("v1"), Version: 3}, nil).Times(3)
+	service.EXPECT().GetEntry(gomock.Any(), "absent").Return(Entry{}, ErrKeyNotExist)
+	service.EXPECT().Exec(gomock.Any(), tx.Tx{Ops: []tx.Op{
+		{Op: tx.OpCheck, Key: "key", Version: 3},
+		{Op: tx.OpSet, Key: "key", Value: []byte("v2")},
+	}}).Return(tx.Response{Committed: true, Results: []tx.Result{
+		{Op: tx.OpCheck, Key: "key", Status: tx.StatusOK, Version: 3},
+		{Op: tx.OpSet, Key: "key", Status: tx.StatusOK, Version: 4},
+	}}, nil)
+	service.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(tx.Response{Committed: false}, nil)
+
+	h := NewHandler(service, HandlerOptions{})
+	set := func(key, ifMatch string) *http.Response {
+		req, err := http.NewRequest(http.MethodPost, "http://test?key="+key, strings.NewReader("v2"))
+		require.NoError(t, err)
+		req.Header.Set("If-Match", ifMatch)
+		rec := httptest.NewRecorder()
+		h.SetHandle(rec, req)
+		return rec.Result()
+	}
+
+	resp := set("key", `"3"`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, handler.ETag(4), resp.Header.Get("ETag"))
+
+	// key was changed after it was read
+	require.Equal(t, http.StatusPreconditionFailed, set("key", `"3"`).StatusCode)
+	require.Equal(t, http.StatusPreconditionFailed, set("key", `"2"`).StatusCode)
+	require.Equal(t, http.StatusPreconditionFailed, set("absent", "*").StatusCode)
+}
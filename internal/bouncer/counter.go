@@ -0,0 +1,79 @@
+package bouncer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/ratelimit"
+)
+
+// Incr routes counter to storage which has the key or which is selected for new key.
+// It doesnt fallback to other storages, so counter never splits between them.
+func (b *ShardService) Incr(ctx context.Context, key string, by int64, ttl time.Duration) (int64, error) {
+	s, i, err := b.ownerStorage(key)
+	if err != nil {
+		return 0, err
+	}
+	defer b.cache.invalidate(key)
+
+	value, err := s.Incr(ctx, key, by, ttl)
+	if err != nil {
+		return 0, err
+	}
+
+	b.setStorageIndex(key, i)
+	return value, nil
+}
+
+func (b *ShardService) IncrFloat(ctx context.Context, key string, by float64, ttl time.Duration) (float64, error) {
+	s, i, err := b.ownerStorage(key)
+	if err != nil {
+		return 0, err
+	}
+	defer b.cache.invalidate(key)
+
+	value, err := s.IncrFloat(ctx, key, by, ttl)
+	if err != nil {
+		return 0, err
+	}
+
+	b.setStorageIndex(key, i)
+	return value, nil
+}
+
+// RateLimit routes rate limit by hash of key. Rate limits arent indexed, so
+// rate limit is started over on another storage if set of alive storages is changed.
+func (b *ShardService) RateLimit(ctx context.Context, key string, p ratelimit.Params) (ratelimit.Decision, error) {
+	if b.countAliveShards() == 0 {
+		return ratelimit.Decision{}, ErrAllStorage
+	}
+
+	i, tagged := b.tagStorage(key)
+	if !tagged || !b.storages[i].IsAlive() {
+		i = b.getShardIndByHash(key)
+	}
+
+	s := b.storages[i]
+	if !s.IsAlive() {
+		return ratelimit.Decision{}, fmt.Errorf("storage %q isnt alive", s.Addr())
+	}
+	return s.RateLimit(ctx, key, p)
+}
+
+// ownerStorage returns storage which has the key or which is selected for new key.
+func (b *ShardService) ownerStorage(key string) (Storage, int, error) {
+	if i, exist := b.isExist(key); exist {
+		s := b.storages[i]
+		if !s.IsAlive() {
+			return nil, 0, fmt.Errorf("storage %q isnt alive", s.Addr())
+		}
+		return s, i, nil
+	}
+
+	i, ok := b.placement(key)
+	if !ok {
+		return nil, 0, ErrAllStorage
+	}
+	return b.storages[i], i, nil
+}
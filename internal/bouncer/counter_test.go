@@ -0,0 +1,33 @@
+package bouncer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestIncrStaysOnOwningStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage1 := NewMockStorage(ctrl)
+	storage1.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage2 := NewMockStorage(ctrl)
+	storage2.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage2.EXPECT().Incr(gomock.Any(), "counter", int64(2), time.Duration(0)).Return(int64(0), errors.New("failed"))
+
+	s := NewShardService([]Storage{storage1, storage2}, ServiceOptions{})
+	s.setStorageIndex("counter", 1)
+
+	_, err := s.Incr(context.Background(), "counter", 2, 0)
+	require.Error(t, err)
+}
+
+func TestIncrConflictCode(t *testing.T) {
+	err := &StatusError{Code: http.StatusConflict, Msg: "value isnt a number"}
+	require.Equal(t, http.StatusConflict, errorCode(err))
+	require.Equal(t, http.StatusInternalServerError, errorCode(&StatusError{Code: http.StatusBadGateway}))
+}
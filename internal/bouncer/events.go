@@ -0,0 +1,189 @@
+package bouncer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+)
+
+// Subscription is a stream of events, Err returns reason of its end after events is closed.
+type Subscription struct {
+	events chan events.Event
+	err    error
+}
+
+func (s *Subscription) Events() <-chan events.Event {
+	return s.events
+}
+
+func (s *Subscription) Err() error {
+	return s.err
+}
+
+// Subscribe aggregates events of all storages into one stream. Storage which is down or whose
+// stream is broken is subscribed again when it is alive, gap event is sent when its stream is lost
+// and when it is back, so client doesnt miss events silently. Stream ends only with ctx.
+func (b *ShardService) Subscribe(ctx context.Context, f events.Filter) (*Subscription, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	subs := make([]*Subscription, len(b.storages))
+	attached := 0
+	for i, s := range b.storages {
+		if !s.IsAlive() {
+			continue
+		}
+		sub, err := s.Subscribe(ctx, f)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		subs[i] = sub
+		attached++
+	}
+	if attached == 0 {
+		cancel()
+		return nil, ErrAllStorage
+	}
+
+	out := &Subscription{events: make(chan events.Event, events.DefaultBufferSize)}
+	var wg sync.WaitGroup
+	for i, s := range b.storages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			forwardEvents(ctx, s, subs[i], f, out.events)
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(out.events)
+	}()
+	return out, nil
+}
+
+// forwardEvents sends events of storage to out until ctx is done, sub is nil if storage
+// isnt subscribed yet.
+func forwardEvents(ctx context.Context, s Storage, sub *Subscription, f events.Filter, out chan<- events.Event) {
+	for {
+		if sub == nil {
+			if !sendEvent(ctx, out, gapEvent(fmt.Sprintf("storage %q is down, its events are lost until it is back", s.Addr()))) {
+				return
+			}
+			if sub = resubscribe(ctx, s, f); sub == nil {
+				return
+			}
+			if !sendEvent(ctx, out, gapEvent(fmt.Sprintf("storage %q is back, its events could be lost before", s.Addr()))) {
+				return
+			}
+		}
+
+		if !forwardSubscription(ctx, sub, out) {
+			return
+		}
+		slog.Warn(fmt.Sprintf("events stream of storage %q is broken: %v", s.Addr(), sub.Err()))
+		sub = nil
+	}
+}
+
+// forwardSubscription sends events of sub to out, it returns false if ctx is done
+// and true if stream of storage is broken.
+func forwardSubscription(ctx context.Context, sub *Subscription, out chan<- events.Event) bool {
+	for {
+		select {
+		case e, ok := <-sub.Events():
+			if !ok {
+				return ctx.Err() == nil
+			}
+			if !sendEvent(ctx, out, e) {
+				return false
+			}
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// resubscribe waits until storage is alive and subscribes to it, it returns nil if ctx is done.
+func resubscribe(ctx context.Context, s Storage, f events.Filter) *Subscription {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(resubscribeInterval):
+		}
+
+		if !s.IsAlive() {
+			continue
+		}
+		sub, err := s.Subscribe(ctx, f)
+		if err == nil {
+			return sub
+		}
+		slog.Warn(fmt.Sprintf("subscribe to storage %q: %v", s.Addr(), err))
+	}
+}
+
+func gapEvent(reason string) events.Event {
+	return events.Event{Op: events.OpGap, Data: []byte(reason), Time: time.Now()}
+}
+
+func sendEvent(ctx context.Context, out chan<- events.Event, e events.Event) bool {
+	select {
+	case out <- e:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Publish sends message to subscribers of channel on storage selected by channel,
+// so bouncer subscribers get message once.
+func (b *ShardService) Publish(ctx context.Context, channel string, data []byte) (int, error) {
+	s, err := b.homeStorage(channel)
+	if err != nil {
+		return 0, err
+	}
+	return s.Publish(ctx, channel, data)
+}
+
+const resubscribeInterval = time.Second
+
+// RunInvalidation removes keys from near cache when they are changed or expired on storages,
+// including changes bypassing bouncer.
+func (b *ShardService) RunInvalidation(ctx context.Context) {
+	for _, s := range b.storages {
+		go func() {
+			for ctx.Err() == nil {
+				if s.IsAlive() {
+					b.invalidateBy(ctx, s)
+				}
+
+				select {
+				case <-ctx.Done():
+				case <-time.After(resubscribeInterval):
+				}
+			}
+		}()
+	}
+}
+
+func (b *ShardService) invalidateBy(ctx context.Context, s Storage) {
+	sub, err := s.Subscribe(ctx, events.Filter{Pattern: "*"})
+	if err != nil {
+		return
+	}
+	for e := range sub.Events() {
+		b.cache.invalidate(e.Key)
+	}
+	if ctx.Err() == nil {
+		// events could be lost until subscription is established again,
+		// so cached values cannot be trusted
+		b.cache.clear()
+	}
+}
@@ -0,0 +1,141 @@
+package bouncer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func newEventsShard(t *testing.T, keys ...string) *Shard {
+	return newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "user:*", r.URL.Query().Get("pattern"))
+		for _, key := range keys {
+			require.NoError(t, events.WriteEvent(w, events.Event{Op: events.OpSet, Key: key}))
+		}
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}, ShardOptions{})
+}
+
+func TestSubscribeAggregatesStorages(t *testing.T) {
+	shard1 := newEventsShard(t, "user:1")
+	shard2 := newEventsShard(t, "user:2", "user:3")
+	shard1.alive, shard2.alive = true, true
+
+	s := NewShardService([]Storage{shard1, shard2}, ServiceOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := s.Subscribe(ctx, events.Filter{Pattern: "user:*"})
+	require.NoError(t, err)
+
+	keys := make([]string, 0, 3)
+	for range 3 {
+		keys = append(keys, (<-sub.Events()).Key)
+	}
+	require.ElementsMatch(t, []string{"user:1", "user:2", "user:3"}, keys)
+
+	cancel()
+	for range sub.Events() {
+	}
+	require.NoError(t, sub.Err())
+}
+
+func receiveEvent(t *testing.T, sub *Subscription) events.Event {
+	select {
+	case e, ok := <-sub.Events():
+		require.True(t, ok, "subscription is closed")
+		return e
+	case <-time.After(3 * resubscribeInterval):
+		t.Fatal("event isnt received")
+		return events.Event{}
+	}
+}
+
+func TestSubscribeReattachesBrokenStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage1 := NewMockStorage(ctrl)
+	storage1.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage2 := NewMockStorage(ctrl)
+	storage2.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage2.EXPECT().Addr().Return("storage2").AnyTimes()
+
+	alive := &Subscription{events: make(chan events.Event)}
+	storage1.EXPECT().Subscribe(gomock.Any(), gomock.Any()).Return(alive, nil)
+	broken := &Subscription{events: make(chan events.Event), err: events.ErrSlowSubscriber}
+	close(broken.events)
+	back := &Subscription{events: make(chan events.Event, 1)}
+	back.events <- events.Event{Op: events.OpSet, Key: "key1"}
+	gomock.InOrder(
+		storage2.EXPECT().Subscribe(gomock.Any(), gomock.Any()).Return(broken, nil),
+		storage2.EXPECT().Subscribe(gomock.Any(), gomock.Any()).Return(back, nil),
+	)
+
+	s := NewShardService([]Storage{storage1, storage2}, ServiceOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := s.Subscribe(ctx, events.Filter{Pattern: "*"})
+	require.NoError(t, err)
+
+	lost := receiveEvent(t, sub)
+	require.Equal(t, events.OpGap, lost.Op)
+	require.Contains(t, string(lost.Data), "is down")
+	require.Equal(t, events.OpGap, receiveEvent(t, sub).Op)
+	require.Equal(t, "key1", receiveEvent(t, sub).Key)
+
+	cancel()
+	for range sub.Events() {
+	}
+	require.NoError(t, sub.Err())
+}
+
+func TestSubscribeAttachesRecoveredStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage1 := NewMockStorage(ctrl)
+	storage1.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage1.EXPECT().Subscribe(gomock.Any(), gomock.Any()).Return(&Subscription{events: make(chan events.Event)}, nil)
+
+	storage2 := NewMockStorage(ctrl)
+	storage2.EXPECT().Addr().Return("storage2").AnyTimes()
+	gomock.InOrder(
+		storage2.EXPECT().IsAlive().Return(false),
+		storage2.EXPECT().IsAlive().Return(true).AnyTimes(),
+	)
+	back := &Subscription{events: make(chan events.Event, 1)}
+	back.events <- events.Event{Op: events.OpSet, Key: "key1"}
+	storage2.EXPECT().Subscribe(gomock.Any(), gomock.Any()).Return(back, nil)
+
+	s := NewShardService([]Storage{storage1, storage2}, ServiceOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub, err := s.Subscribe(ctx, events.Filter{Pattern: "*"})
+	require.NoError(t, err)
+
+	// storage down at start is reported at once
+	require.Equal(t, events.OpGap, receiveEvent(t, sub).Op)
+	require.Equal(t, events.OpGap, receiveEvent(t, sub).Op)
+	require.Equal(t, "key1", receiveEvent(t, sub).Key)
+}
+
+func TestInvalidationByEvents(t *testing.T) {
+	shard := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, events.WriteEvent(w, events.Event{Op: events.OpSet, Key: "key1"}))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}, ShardOptions{})
+	shard.alive = true
+
+	s := NewShardService([]Storage{shard}, ServiceOptions{Cache: CacheConfig{Enabled: true}})
+	s.cache.put("key1", Entry{Value: []byte("stale")}, s.cache.generation("key1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.RunInvalidation(ctx)
+
+	require.Eventually(t, func() bool {
+		_, ok := s.cache.get("key1")
+		return !ok
+	}, time.Second, 10*time.Millisecond)
+}
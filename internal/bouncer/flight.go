@@ -0,0 +1,81 @@
+package bouncer
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup coalesces concurrent reads of the same key into one call.
+// The call is detached from callers contexts and is cancelled only when all callers gave up.
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[flightKey]*flight
+}
+
+// flightKey has cache generation of key, so read started after key was written
+// doesnt join read started before it and doesnt get older value than it wrote.
+type flightKey struct {
+	key string
+	gen uint64
+}
+
+type flight struct {
+	done    chan struct{}
+	entry   Entry
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{
+		calls: make(map[flightKey]*flight),
+	}
+}
+
+// do calls fn or joins call of the same key and generation, gen is taken from cache before.
+func (g *flightGroup) do(ctx context.Context, key string, gen uint64, fn func(ctx context.Context) (Entry, error)) (Entry, error) {
+	k := flightKey{key, gen}
+	g.mu.Lock()
+	f, ok := g.calls[k]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.calls[k] = f
+
+		go func() {
+			defer cancel()
+			f.entry, f.err = fn(callCtx)
+			g.forget(k, f)
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.entry, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			f.cancel()
+			g.forgetLocked(k, f)
+		}
+		g.mu.Unlock()
+		return Entry{}, ctx.Err()
+	}
+}
+
+func (g *flightGroup) forget(key flightKey, f *flight) {
+	g.mu.Lock()
+	g.forgetLocked(key, f)
+	g.mu.Unlock()
+}
+
+func (g *flightGroup) forgetLocked(key flightKey, f *flight) {
+	if g.calls[key] == f {
+		delete(g.calls, key)
+	}
+}
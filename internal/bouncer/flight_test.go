@@ -0,0 +1,125 @@
+package bouncer
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type slowStorage struct {
+	Storage
+	delay time.Duration
+	calls atomic.Int64
+}
+
+func (s *slowStorage) Get(ctx context.Context, key string) (Entry, error) {
+	s.calls.Add(1)
+	select {
+	case <-ctx.Done():
+		return Entry{}, ctx.Err()
+	case <-time.After(s.delay):
+		return Entry{Value: []byte("data")}, nil
+	}
+}
+
+func (s *slowStorage) get(ctx context.Context) (Entry, error) {
+	return s.Get(ctx, "key1")
+}
+
+func (s *slowStorage) IsAlive() bool {
+	return true
+}
+
+func TestFlightShared(t *testing.T) {
+	storage := &slowStorage{delay: 50 * time.Millisecond}
+	g := newFlightGroup()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry, err := g.do(context.Background(), "key1", 0, storage.get)
+			require.NoError(t, err)
+			require.Equal(t, "data", string(entry.Value))
+		}()
+	}
+	wg.Wait()
+	require.Equal(t, int64(1), storage.calls.Load())
+}
+
+func TestFlightCallerCancel(t *testing.T) {
+	storage := &slowStorage{delay: 50 * time.Millisecond}
+	g := newFlightGroup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error)
+	go func() {
+		_, err := g.do(ctx, "key1", 0, storage.get)
+		canceled <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	result := make(chan error)
+	go func() {
+		_, err := g.do(context.Background(), "key1", 0, storage.get)
+		result <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	require.ErrorIs(t, <-canceled, context.Canceled)
+	require.NoError(t, <-result)
+	require.Equal(t, int64(1), storage.calls.Load())
+}
+
+func TestFlightAllCallersCancel(t *testing.T) {
+	g := newFlightGroup()
+	callCanceled := make(chan struct{})
+	fn := func(ctx context.Context) (Entry, error) {
+		<-ctx.Done()
+		close(callCanceled)
+		return Entry{}, ctx.Err()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := g.do(ctx, "key1", 0, fn)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	select {
+	case <-callCanceled:
+	case <-time.After(time.Second):
+		t.Fatal("call wasnt cancelled")
+	}
+}
+
+func BenchmarkGetHotKey(b *testing.B) {
+	b.Run("direct", func(b *testing.B) {
+		storage := &slowStorage{delay: time.Millisecond}
+		b.SetParallelism(100)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = storage.Get(context.Background(), "hot")
+			}
+		})
+		b.ReportMetric(float64(storage.calls.Load())/float64(b.N), "storage-calls/op")
+	})
+
+	b.Run("coalesced", func(b *testing.B) {
+		storage := &slowStorage{delay: time.Millisecond}
+		s := NewShardService([]Storage{storage}, ServiceOptions{})
+		s.setStorageIndex("hot", 0)
+		b.SetParallelism(100)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				_, _ = s.Get(context.Background(), "hot")
+			}
+		})
+		b.ReportMetric(float64(storage.calls.Load())/float64(b.N), "storage-calls/op")
+	})
+}
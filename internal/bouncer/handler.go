@@ -1,28 +1,83 @@
-//go:generate mockgen -source=$GOFILE -destination=mock_test.go -package=$GOPACKAGE
+//go:generate mockgen -source=$GOFILE -destination=mock_handler_test.go -package=$GOPACKAGE
 package bouncer
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/events"
 	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/lease"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/aosderzhikov/sticky/internal/ratelimit"
+	"github.com/aosderzhikov/sticky/internal/tx"
+	"github.com/aosderzhikov/sticky/internal/typed"
 )
 
-func NewHandler(s Service) *Handler {
-	return &Handler{s}
+// DefaultStreamThreshold is size of value above which set request is streamed to storage if threshold isnt configured.
+const DefaultStreamThreshold int64 = 1 << 20
+
+type HandlerOptions struct {
+	// MaxValueSize limits value of set request, zero is handler.DefaultMaxValueSize.
+	MaxValueSize int64
+	// StreamThreshold is size of value above which set request is streamed to storage
+	// instead of being buffered, zero is DefaultStreamThreshold.
+	StreamThreshold int64
+	// UploadIdleTimeout limits waiting for next part of value, zero is handler.DefaultUploadIdleTimeout.
+	UploadIdleTimeout time.Duration
+}
+
+func NewHandler(s Service, opts HandlerOptions) *Handler {
+	if opts.MaxValueSize <= 0 {
+		opts.MaxValueSize = handler.DefaultMaxValueSize
+	}
+	if opts.StreamThreshold <= 0 {
+		opts.StreamThreshold = DefaultStreamThreshold
+	}
+	return &Handler{s: s, opts: opts}
 }
 
 type Handler struct {
-	s Service
+	s    Service
+	opts HandlerOptions
 }
 
 type Service interface {
 	Get(ctx context.Context, key string) (value []byte, err error)
+	GetCached(ctx context.Context, key string) (entry Entry, err error)
+	GetEntry(ctx context.Context, key string) (entry Entry, err error)
 	Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error)
+	SetWithMeta(ctx context.Context, key string, value []byte, ttl time.Duration, m meta.Meta) (err error)
+	SetStream(ctx context.Context, key string, body io.Reader, size int64, ttl time.Duration, m meta.Meta) (err error)
 	Delete(ctx context.Context, key string) (err error)
+
+	MGet(ctx context.Context, keys []string) (results []batch.Result)
+	MSet(ctx context.Context, items []batch.Item) (results []batch.Result)
+	MDelete(ctx context.Context, keys []string) (results []batch.Result)
+
+	Exec(ctx context.Context, t tx.Tx) (resp tx.Response, err error)
+
+	Incr(ctx context.Context, key string, by int64, ttl time.Duration) (value int64, err error)
+	IncrFloat(ctx context.Context, key string, by float64, ttl time.Duration) (value float64, err error)
+
+	RateLimit(ctx context.Context, key string, p ratelimit.Params) (d ratelimit.Decision, err error)
+
+	Lock(ctx context.Context, key, owner string, ttl, wait time.Duration) (l lease.Lease, err error)
+	Unlock(ctx context.Context, key, owner string) (err error)
+	Renew(ctx context.Context, key, owner string, ttl time.Duration) (l lease.Lease, err error)
+
+	Do(ctx context.Context, cmd typed.Command) (reply typed.Reply, err error)
+
+	Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (entry Entry, err error)
+
+	Subscribe(ctx context.Context, f events.Filter) (sub *Subscription, err error)
+	Publish(ctx context.Context, channel string, data []byte) (receivers int, err error)
 }
 
 func (h *Handler) GetHandle(w http.ResponseWriter, r *http.Request) {
@@ -34,12 +89,20 @@ func (h *Handler) GetHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	value, err := h.s.Get(ctx, key)
+	entry, err := h.s.GetCached(ctx, key)
 	if err != nil {
-		handler.ErrorHandle(ctx, w, err, http.StatusInternalServerError)
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
 		return
 	}
-	_, _ = w.Write(value)
+	if handler.NotModified(r, entry.Version, entry.UpdatedAt) {
+		handler.WriteNotModified(w, entry.Version, entry.UpdatedAt)
+		return
+	}
+
+	handler.PutValidators(w, entry.Version, entry.UpdatedAt)
+	entry.Meta.Put(w.Header())
+	// validators are already put, so zero modtime keeps Last-Modified as is
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(entry.Value))
 }
 
 func (h *Handler) SetHandle(w http.ResponseWriter, r *http.Request) {
@@ -51,20 +114,41 @@ func (h *Handler) SetHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	value, err := io.ReadAll(r.Body)
+	m, err := meta.FromHeader(r.Header)
 	if err != nil {
-		err = errors.Join(handler.ErrBodyRead, err)
-		handler.ErrorHandle(ctx, w, err, http.StatusInternalServerError)
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
 		return
 	}
 
-	err = h.s.Set(ctx, key, value, ttl)
+	err = h.setValue(w, r, key, ttl, m)
 	if err != nil {
-		handler.ErrorHandle(ctx, w, err, http.StatusInternalServerError)
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
 		return
 	}
 }
 
+// setValue streams body to storage if its length is unknown or above StreamThreshold.
+// Smaller value is buffered, so it can go over binary transport and to another storage on failure,
+// conditional set is always buffered as value is checked and stored in one transaction.
+func (h *Handler) setValue(w http.ResponseWriter, r *http.Request, key string, ttl time.Duration, m meta.Meta) error {
+	// upload isnt limited by total timeout, but client mustnt stall in the middle of it
+	handler.WatchUpload(w, r, h.opts.UploadIdleTimeout)
+
+	_, conditional := handler.ExtractIfMatch(r)
+	if !conditional && (r.ContentLength < 0 || r.ContentLength > h.opts.StreamThreshold) {
+		if err := handler.LimitBody(w, r, h.opts.MaxValueSize); err != nil {
+			return err
+		}
+		return h.s.SetStream(r.Context(), key, r.Body, r.ContentLength, ttl, m)
+	}
+
+	value, err := handler.ReadValue(w, r, h.opts.MaxValueSize)
+	if err != nil {
+		return err
+	}
+	return h.setIfMatch(w, r, key, value, ttl, m)
+}
+
 func (h *Handler) DeleteHandle(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -74,9 +158,295 @@ func (h *Handler) DeleteHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.s.Delete(ctx, key)
+	err = h.deleteIfMatch(w, r, key)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+}
+
+func (h *Handler) MGetHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, contentType, err := handler.ExtractBatch(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, handler.BatchErrorCode(err))
+		return
+	}
+
+	_ = batch.WriteResponse(w, contentType, h.s.MGet(ctx, req.Keys()))
+}
+
+func (h *Handler) MSetHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, contentType, err := handler.ExtractBatch(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, handler.BatchErrorCode(err))
+		return
+	}
+
+	_ = batch.WriteResponse(w, contentType, h.s.MSet(ctx, req.Items))
+}
+
+func (h *Handler) MDeleteHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, contentType, err := handler.ExtractBatch(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, handler.BatchErrorCode(err))
+		return
+	}
+
+	_ = batch.WriteResponse(w, contentType, h.s.MDelete(ctx, req.Keys()))
+}
+
+func (h *Handler) TxHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	t, err := handler.ExtractTx(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.s.Exec(ctx, t)
 	if err != nil {
-		handler.ErrorHandle(ctx, w, err, http.StatusInternalServerError)
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
 		return
 	}
+	handler.WriteTxResponse(w, resp)
+}
+
+func (h *Handler) IncrHandle(w http.ResponseWriter, r *http.Request) {
+	h.incr(w, r, 1)
+}
+
+func (h *Handler) DecrHandle(w http.ResponseWriter, r *http.Request) {
+	h.incr(w, r, -1)
+}
+
+func (h *Handler) incr(w http.ResponseWriter, r *http.Request, sign int64) {
+	ctx := r.Context()
+
+	key, ttl, err := handler.ExtractKeyAndTTL(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	by, err := handler.ExtractBy(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	value, err := h.s.Incr(ctx, key, sign*by, ttl)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+	_, _ = w.Write([]byte(strconv.FormatInt(value, 10)))
+}
+
+func (h *Handler) IncrFloatHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, ttl, err := handler.ExtractKeyAndTTL(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	by, err := handler.ExtractFloatBy(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	value, err := h.s.IncrFloat(ctx, key, by, ttl)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+	_, _ = w.Write([]byte(strconv.FormatFloat(value, 'f', -1, 64)))
+}
+
+func (h *Handler) RateLimitHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, p, err := handler.ExtractRateLimit(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.s.RateLimit(ctx, key, p)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+	handler.WriteRateLimitResponse(w, p, d)
+}
+
+func (h *Handler) LockHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, owner, ttl, wait, err := handler.ExtractLock(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	handler.ExtendWriteDeadline(w, wait)
+	l, err := h.s.Lock(ctx, key, owner, ttl, wait)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+	handler.WriteLease(w, l)
+}
+
+func (h *Handler) UnlockHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, owner, _, _, err := handler.ExtractLock(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err = h.s.Unlock(ctx, key, owner); err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+}
+
+func (h *Handler) RenewHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, owner, ttl, _, err := handler.ExtractLock(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	l, err := h.s.Renew(ctx, key, owner, ttl)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+	handler.WriteLease(w, l)
+}
+
+// TypedHandle returns handler of operation on typed value.
+func (h *Handler) TypedHandle(op typed.Op) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		cmd, err := handler.ExtractCommand(r, op)
+		if err != nil {
+			handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+			return
+		}
+
+		if cmd.Blocking() {
+			handler.ExtendWriteDeadline(w, cmd.Timeout)
+		}
+		reply, err := h.s.Do(ctx, cmd)
+		if err != nil {
+			handler.ErrorHandle(ctx, w, err, errorCode(err))
+			return
+		}
+		handler.WriteReply(w, reply)
+	}
+}
+
+// WatchHandle responds with value and version of changed key, 404 if key was deleted
+// or 304 if key isnt changed until timeout.
+func (h *Handler) WatchHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, sinceVersion, timeout, err := handler.ExtractWatch(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	handler.ExtendWriteDeadline(w, timeout)
+	entry, err := h.s.Watch(ctx, key, sinceVersion, timeout)
+	if errors.Is(err, ErrNotModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+
+	if !entry.ExpiresAt.IsZero() {
+		handler.PutTTLHeader(w, time.Until(entry.ExpiresAt))
+	}
+	handler.PutVersionHeader(w, entry.Version)
+	_, _ = w.Write(entry.Value)
+}
+
+func (h *Handler) SubscribeHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	f, err := handler.ExtractFilter(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	sub, err := h.s.Subscribe(ctx, f)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+	handler.ServeEvents(w, r, sub.Events(), sub.Err)
+}
+
+func (h *Handler) PublishHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	channel, data, err := handler.ExtractPublish(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	receivers, err := h.s.Publish(ctx, channel, data)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+	_, _ = w.Write([]byte(strconv.Itoa(receivers)))
+}
+
+func errorCode(err error) int {
+	var (
+		statusErr *StatusError
+		maxErr    *http.MaxBytesError
+	)
+	switch {
+	case errors.Is(err, ErrKeyNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, ErrCrossShard):
+		return http.StatusBadRequest
+	case errors.Is(err, handler.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, handler.ErrValueTooLarge), errors.As(err, &maxErr):
+		// streamed body can exceed limit while it is sent to storage
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, handler.ErrUploadIdle):
+		// streamed body can stall while it is sent to storage
+		return http.StatusRequestTimeout
+	case errors.As(err, &statusErr) && statusErr.Code < http.StatusInternalServerError:
+		// client errors of storage, e.g. conflicts, are passed as is
+		return statusErr.Code
+	default:
+		return http.StatusInternalServerError
+	}
 }
@@ -0,0 +1,189 @@
+package bouncer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetHandle(t *testing.T) {
+	cases := []struct {
+		name        string
+		serviceFunc func(t *testing.T) Service
+		reqFunc     func(t *testing.T) *http.Request
+		wantFunc    func(t *testing.T, rec *httptest.ResponseRecorder)
+	}{
+		{
+			name: "get not found",
+			reqFunc: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodGet, "http://test?key=key1", http.NoBody)
+				require.NoError(t, err)
+				return req
+			},
+			serviceFunc: func(t *testing.T) Service {
+				ctrl := gomock.NewController(t)
+				service := NewMockService(ctrl)
+				service.EXPECT().GetCached(gomock.Any(), "key1").Return(Entry{}, ErrKeyNotExist)
+				return service
+			},
+			wantFunc: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+			},
+		},
+		{
+			name: "get empty value",
+			reqFunc: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodGet, "http://test?key=key1", http.NoBody)
+				require.NoError(t, err)
+				return req
+			},
+			serviceFunc: func(t *testing.T) Service {
+				ctrl := gomock.NewController(t)
+				service := NewMockService(ctrl)
+				service.EXPECT().GetCached(gomock.Any(), "key1").Return(Entry{Value: []byte{}}, nil)
+				return service
+			},
+			wantFunc: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+				require.Empty(t, rec.Body.String())
+			},
+		},
+		{
+			name: "get with metadata",
+			reqFunc: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodGet, "http://test?key=key1", http.NoBody)
+				require.NoError(t, err)
+				return req
+			},
+			serviceFunc: func(t *testing.T) Service {
+				ctrl := gomock.NewController(t)
+				service := NewMockService(ctrl)
+				m := meta.Meta{ContentType: "application/json", User: map[string]string{"Owner": "team-a"}}
+				service.EXPECT().GetCached(gomock.Any(), "key1").Return(Entry{Value: []byte("{}"), Meta: m}, nil)
+				return service
+			},
+			wantFunc: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+				require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+				require.Equal(t, "team-a", rec.Header().Get("X-Sticky-Meta-Owner"))
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := NewHandler(c.serviceFunc(t), HandlerOptions{})
+			rec := httptest.NewRecorder()
+			h.GetHandle(rec, c.reqFunc(t))
+			c.wantFunc(t, rec)
+		})
+	}
+}
+
+func TestSetHandleStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := NewMockService(ctrl)
+	service.EXPECT().SetWithMeta(gomock.Any(), "small", []byte("abc"), time.Duration(0), meta.Meta{}).Return(nil)
+	service.EXPECT().SetStream(gomock.Any(), "large", gomock.Any(), int64(6), time.Duration(0), meta.Meta{}).DoAndReturn(
+		func(_ context.Context, _ string, body io.Reader, _ int64, _ time.Duration, _ meta.Meta) error {
+			value, err := io.ReadAll(body)
+			require.NoError(t, err)
+			require.Equal(t, "abcdef", string(value))
+			return nil
+		})
+	service.EXPECT().SetStream(gomock.Any(), "chunked", gomock.Any(), int64(-1), time.Duration(0), meta.Meta{}).DoAndReturn(
+		func(_ context.Context, _ string, body io.Reader, _ int64, _ time.Duration, _ meta.Meta) error {
+			// limit is exceeded while value is sent to storage
+			_, err := io.ReadAll(body)
+			return err
+		})
+
+	h := NewHandler(service, HandlerOptions{MaxValueSize: 8, StreamThreshold: 4})
+
+	tests := []struct {
+		name   string
+		key    string
+		body   string
+		length int64
+		code   int
+	}{
+		{"buffered below threshold", "small", "abc", 3, http.StatusOK},
+		{"streamed above threshold", "large", "abcdef", 6, http.StatusOK},
+		{"too large length", "absent", "abcdefghi", 9, http.StatusRequestEntityTooLarge},
+		{"too large chunked", "chunked", "abcdefghi", -1, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/set?key="+tt.key, strings.NewReader(tt.body))
+			req.ContentLength = tt.length
+			rec := httptest.NewRecorder()
+			h.SetHandle(rec, req)
+			require.Equal(t, tt.code, rec.Code)
+		})
+	}
+}
+
+func TestSetHandleStreamStalled(t *testing.T) {
+	storageErr := make(chan error, 1)
+	shard := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		storageErr <- err
+	}, ShardOptions{})
+	ctrl := gomock.NewController(t)
+	service := NewMockService(ctrl)
+	service.EXPECT().SetStream(gomock.Any(), "key", gomock.Any(), int64(15), time.Duration(0), meta.Meta{}).DoAndReturn(shard.SetStream)
+
+	h := NewHandler(service, HandlerOptions{
+		StreamThreshold:   4,
+		UploadIdleTimeout: 300 * time.Millisecond,
+	})
+	srv := httptest.NewServer(http.HandlerFunc(h.SetHandle))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// client sends part of value above threshold and stalls
+	_, err = conn.Write([]byte("POST /set?key=key HTTP/1.1\r\nHost: bouncer\r\nContent-Length: 15\r\n\r\nslow"))
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(3*time.Second)))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusRequestTimeout, resp.StatusCode)
+
+	// request to storage is aborted too
+	select {
+	case err = <-storageErr:
+		require.Error(t, err)
+	case <-time.After(3 * time.Second):
+		t.Fatal("storage is still reading value")
+	}
+}
+
+func TestGetHandleRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := NewMockService(ctrl)
+	service.EXPECT().GetCached(gomock.Any(), "key").Return(Entry{Value: []byte("0123456789"), Version: 1}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/get?key=key", http.NoBody)
+	req.Header.Set("Range", "bytes=2-4")
+	rec := httptest.NewRecorder()
+	NewHandler(service, HandlerOptions{}).GetHandle(rec, req)
+
+	require.Equal(t, http.StatusPartialContent, rec.Code)
+	require.Equal(t, "bytes 2-4/10", rec.Header().Get("Content-Range"))
+	require.Equal(t, "234", rec.Body.String())
+}
@@ -0,0 +1,86 @@
+package bouncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/scan"
+)
+
+func (b *ShardService) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	err := b.keyOp(ctx, key, func(s Storage) error {
+		return s.Expire(ctx, key, ttl)
+	})
+	if errors.Is(err, ErrKeyNotExist) {
+		b.deletStorageIndex(key)
+	}
+	return err
+}
+
+// Persist returns ErrKeyNotExist if key doesnt exist or already doesnt expire.
+func (b *ShardService) Persist(ctx context.Context, key string) error {
+	return b.keyOp(ctx, key, func(s Storage) error {
+		return s.Persist(ctx, key)
+	})
+}
+
+// keyOp applies op to storage which has the key.
+func (b *ShardService) keyOp(ctx context.Context, key string, op func(s Storage) error) error {
+	defer b.cache.invalidate(key)
+
+	s, err := b.keyStorage(key)
+	if err != nil {
+		return err
+	}
+	return op(s)
+}
+
+// GetEntry reads key from its storage bypassing near cache, so ttl and version are fresh.
+func (b *ShardService) GetEntry(ctx context.Context, key string) (Entry, error) {
+	s, err := b.keyStorage(key)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	entry, err := s.Get(ctx, key)
+	if errors.Is(err, ErrKeyNotExist) {
+		b.deletStorageIndex(key)
+	}
+	return entry, err
+}
+
+func (b *ShardService) keyStorage(key string) (Storage, error) {
+	i, ok := b.isExist(key)
+	if !ok {
+		return nil, ErrKeyNotExist
+	}
+
+	s := b.storages[i]
+	if !s.IsAlive() {
+		return nil, fmt.Errorf("storage %q isnt alive", s.Addr())
+	}
+	return s, nil
+}
+
+// Scan iterates keys known by index of bouncer, see scan.Page for guarantees of iteration.
+// Index keeps expired keys until they are read, so they can be returned too.
+func (b *ShardService) Scan(cursor uint64, pattern string, count int) (uint64, []string) {
+	page := scan.New(cursor, pattern, count)
+
+	b.mu.Lock()
+	for key := range b.index {
+		page.Add(key)
+	}
+	b.mu.Unlock()
+
+	return page.Result()
+}
+
+// Len returns number of keys known by index of bouncer.
+func (b *ShardService) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.index)
+}
@@ -0,0 +1,48 @@
+package bouncer
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/lease"
+)
+
+// Lock routes lock to its authoritative storage. Unlike values, locks never
+// fallback to other storages, otherwise two owners could hold the same lock.
+func (b *ShardService) Lock(ctx context.Context, key, owner string, ttl, wait time.Duration) (lease.Lease, error) {
+	s, err := b.homeStorage(key)
+	if err != nil {
+		return lease.Lease{}, err
+	}
+	return s.Lock(ctx, key, owner, ttl, wait)
+}
+
+func (b *ShardService) Unlock(ctx context.Context, key, owner string) error {
+	s, err := b.homeStorage(key)
+	if err != nil {
+		return err
+	}
+	return s.Unlock(ctx, key, owner)
+}
+
+func (b *ShardService) Renew(ctx context.Context, key, owner string, ttl time.Duration) (lease.Lease, error) {
+	s, err := b.homeStorage(key)
+	if err != nil {
+		return lease.Lease{}, err
+	}
+	return s.Renew(ctx, key, owner, ttl)
+}
+
+// homeStorage selects storage by hash of routing key over all storages,
+// so the choice doesnt depend on which storages are alive.
+func (b *ShardService) homeStorage(key string) (Storage, error) {
+	h := fnv.New64a()
+	h.Write([]byte(routingKey(key)))
+	s := b.storages[h.Sum64()%uint64(len(b.storages))]
+	if !s.IsAlive() {
+		return nil, fmt.Errorf("storage %q isnt alive", s.Addr())
+	}
+	return s, nil
+}
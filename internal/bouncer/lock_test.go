@@ -0,0 +1,53 @@
+package bouncer
+
+import (
+	"context"
+	"hash/fnv"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/lease"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func lockStorageInd(key string, n int) int {
+	h := fnv.New64a()
+	h.Write([]byte(routingKey(key)))
+	return int(h.Sum64() % uint64(n))
+}
+
+func TestLockAuthoritativeStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage1 := NewMockStorage(ctrl)
+	storage2 := NewMockStorage(ctrl)
+	storages := []*MockStorage{storage1, storage2}
+
+	i := lockStorageInd("job", len(storages))
+	want := lease.Lease{Key: "job", Owner: "a", Token: 7}
+	storages[i].EXPECT().IsAlive().Return(true)
+	storages[i].EXPECT().Lock(gomock.Any(), "job", "a", time.Minute, time.Second).Return(want, nil)
+
+	s := NewShardService([]Storage{storage1, storage2}, ServiceOptions{})
+	l, err := s.Lock(context.Background(), "job", "a", time.Minute, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, want, l)
+}
+
+func TestLockAuthoritativeStorageDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage1 := NewMockStorage(ctrl)
+	storage2 := NewMockStorage(ctrl)
+	storages := []*MockStorage{storage1, storage2}
+
+	i := lockStorageInd("job", len(storages))
+	storages[i].EXPECT().IsAlive().Return(false).Times(2)
+	storages[i].EXPECT().Addr().Return("keeper1").Times(2)
+	// the other storage is alive, but lock must not be taken there
+	storages[1-i].EXPECT().IsAlive().Return(true).AnyTimes()
+
+	s := NewShardService([]Storage{storage1, storage2}, ServiceOptions{})
+	_, err := s.Lock(context.Background(), "job", "a", time.Minute, 0)
+	require.Error(t, err)
+	require.Error(t, s.Unlock(context.Background(), "job", "a"))
+}
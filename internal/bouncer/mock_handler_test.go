@@ -0,0 +1,356 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: handler.go
+//
+// Generated by this command:
+//
+//	mockgen -source=handler.go -destination=mock_handler_test.go -package=bouncer
+//
+
+// Package bouncer is a generated GoMock package.
+package bouncer
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+	time "time"
+
+	batch "github.com/aosderzhikov/sticky/internal/batch"
+	events "github.com/aosderzhikov/sticky/internal/events"
+	lease "github.com/aosderzhikov/sticky/internal/lease"
+	meta "github.com/aosderzhikov/sticky/internal/meta"
+	ratelimit "github.com/aosderzhikov/sticky/internal/ratelimit"
+	tx "github.com/aosderzhikov/sticky/internal/tx"
+	typed "github.com/aosderzhikov/sticky/internal/typed"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockService is a mock of Service interface.
+type MockService struct {
+	ctrl     *gomock.Controller
+	recorder *MockServiceMockRecorder
+}
+
+// MockServiceMockRecorder is the mock recorder for MockService.
+type MockServiceMockRecorder struct {
+	mock *MockService
+}
+
+// NewMockService creates a new mock instance.
+func NewMockService(ctrl *gomock.Controller) *MockService {
+	mock := &MockService{ctrl: ctrl}
+	mock.recorder = &MockServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockService) EXPECT() *MockServiceMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockService) Delete(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockServiceMockRecorder) Delete(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockService)(nil).Delete), ctx, key)
+}
+
+// Do mocks base method.
+func (m *MockService) Do(ctx context.Context, cmd typed.Command) (typed.Reply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", ctx, cmd)
+	ret0, _ := ret[0].(typed.Reply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockServiceMockRecorder) Do(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockService)(nil).Do), ctx, cmd)
+}
+
+// Exec mocks base method.
+func (m *MockService) Exec(ctx context.Context, t tx.Tx) (tx.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exec", ctx, t)
+	ret0, _ := ret[0].(tx.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exec indicates an expected call of Exec.
+func (mr *MockServiceMockRecorder) Exec(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockService)(nil).Exec), ctx, t)
+}
+
+// Get mocks base method.
+func (m *MockService) Get(ctx context.Context, key string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockServiceMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockService)(nil).Get), ctx, key)
+}
+
+// GetCached mocks base method.
+func (m *MockService) GetCached(ctx context.Context, key string) (Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCached", ctx, key)
+	ret0, _ := ret[0].(Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCached indicates an expected call of GetCached.
+func (mr *MockServiceMockRecorder) GetCached(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCached", reflect.TypeOf((*MockService)(nil).GetCached), ctx, key)
+}
+
+// GetEntry mocks base method.
+func (m *MockService) GetEntry(ctx context.Context, key string) (Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEntry", ctx, key)
+	ret0, _ := ret[0].(Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEntry indicates an expected call of GetEntry.
+func (mr *MockServiceMockRecorder) GetEntry(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEntry", reflect.TypeOf((*MockService)(nil).GetEntry), ctx, key)
+}
+
+// Incr mocks base method.
+func (m *MockService) Incr(ctx context.Context, key string, by int64, ttl time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", ctx, key, by, ttl)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr.
+func (mr *MockServiceMockRecorder) Incr(ctx, key, by, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockService)(nil).Incr), ctx, key, by, ttl)
+}
+
+// IncrFloat mocks base method.
+func (m *MockService) IncrFloat(ctx context.Context, key string, by float64, ttl time.Duration) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrFloat", ctx, key, by, ttl)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrFloat indicates an expected call of IncrFloat.
+func (mr *MockServiceMockRecorder) IncrFloat(ctx, key, by, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrFloat", reflect.TypeOf((*MockService)(nil).IncrFloat), ctx, key, by, ttl)
+}
+
+// Lock mocks base method.
+func (m *MockService) Lock(ctx context.Context, key, owner string, ttl, wait time.Duration) (lease.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, owner, ttl, wait)
+	ret0, _ := ret[0].(lease.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockServiceMockRecorder) Lock(ctx, key, owner, ttl, wait any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockService)(nil).Lock), ctx, key, owner, ttl, wait)
+}
+
+// MDelete mocks base method.
+func (m *MockService) MDelete(ctx context.Context, keys []string) []batch.Result {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MDelete", ctx, keys)
+	ret0, _ := ret[0].([]batch.Result)
+	return ret0
+}
+
+// MDelete indicates an expected call of MDelete.
+func (mr *MockServiceMockRecorder) MDelete(ctx, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MDelete", reflect.TypeOf((*MockService)(nil).MDelete), ctx, keys)
+}
+
+// MGet mocks base method.
+func (m *MockService) MGet(ctx context.Context, keys []string) []batch.Result {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MGet", ctx, keys)
+	ret0, _ := ret[0].([]batch.Result)
+	return ret0
+}
+
+// MGet indicates an expected call of MGet.
+func (mr *MockServiceMockRecorder) MGet(ctx, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MGet", reflect.TypeOf((*MockService)(nil).MGet), ctx, keys)
+}
+
+// MSet mocks base method.
+func (m *MockService) MSet(ctx context.Context, items []batch.Item) []batch.Result {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MSet", ctx, items)
+	ret0, _ := ret[0].([]batch.Result)
+	return ret0
+}
+
+// MSet indicates an expected call of MSet.
+func (mr *MockServiceMockRecorder) MSet(ctx, items any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MSet", reflect.TypeOf((*MockService)(nil).MSet), ctx, items)
+}
+
+// Publish mocks base method.
+func (m *MockService) Publish(ctx context.Context, channel string, data []byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, channel, data)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockServiceMockRecorder) Publish(ctx, channel, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockService)(nil).Publish), ctx, channel, data)
+}
+
+// RateLimit mocks base method.
+func (m *MockService) RateLimit(ctx context.Context, key string, p ratelimit.Params) (ratelimit.Decision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RateLimit", ctx, key, p)
+	ret0, _ := ret[0].(ratelimit.Decision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RateLimit indicates an expected call of RateLimit.
+func (mr *MockServiceMockRecorder) RateLimit(ctx, key, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RateLimit", reflect.TypeOf((*MockService)(nil).RateLimit), ctx, key, p)
+}
+
+// Renew mocks base method.
+func (m *MockService) Renew(ctx context.Context, key, owner string, ttl time.Duration) (lease.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Renew", ctx, key, owner, ttl)
+	ret0, _ := ret[0].(lease.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Renew indicates an expected call of Renew.
+func (mr *MockServiceMockRecorder) Renew(ctx, key, owner, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Renew", reflect.TypeOf((*MockService)(nil).Renew), ctx, key, owner, ttl)
+}
+
+// Set mocks base method.
+func (m *MockService) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, key, value, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockServiceMockRecorder) Set(ctx, key, value, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockService)(nil).Set), ctx, key, value, ttl)
+}
+
+// SetStream mocks base method.
+func (m_2 *MockService) SetStream(ctx context.Context, key string, body io.Reader, size int64, ttl time.Duration, m meta.Meta) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SetStream", ctx, key, body, size, ttl, m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStream indicates an expected call of SetStream.
+func (mr *MockServiceMockRecorder) SetStream(ctx, key, body, size, ttl, m any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStream", reflect.TypeOf((*MockService)(nil).SetStream), ctx, key, body, size, ttl, m)
+}
+
+// SetWithMeta mocks base method.
+func (m_2 *MockService) SetWithMeta(ctx context.Context, key string, value []byte, ttl time.Duration, m meta.Meta) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SetWithMeta", ctx, key, value, ttl, m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetWithMeta indicates an expected call of SetWithMeta.
+func (mr *MockServiceMockRecorder) SetWithMeta(ctx, key, value, ttl, m any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWithMeta", reflect.TypeOf((*MockService)(nil).SetWithMeta), ctx, key, value, ttl, m)
+}
+
+// Subscribe mocks base method.
+func (m *MockService) Subscribe(ctx context.Context, f events.Filter) (*Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, f)
+	ret0, _ := ret[0].(*Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockServiceMockRecorder) Subscribe(ctx, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockService)(nil).Subscribe), ctx, f)
+}
+
+// Unlock mocks base method.
+func (m *MockService) Unlock(ctx context.Context, key, owner string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, key, owner)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockServiceMockRecorder) Unlock(ctx, key, owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockService)(nil).Unlock), ctx, key, owner)
+}
+
+// Watch mocks base method.
+func (m *MockService) Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, key, sinceVersion, timeout)
+	ret0, _ := ret[0].(Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockServiceMockRecorder) Watch(ctx, key, sinceVersion, timeout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockService)(nil).Watch), ctx, key, sinceVersion, timeout)
+}
@@ -0,0 +1,371 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service.go
+//
+// Generated by this command:
+//
+//	mockgen -source=service.go -destination=mock_test.go -package=bouncer
+//
+
+// Package bouncer is a generated GoMock package.
+package bouncer
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+	time "time"
+
+	batch "github.com/aosderzhikov/sticky/internal/batch"
+	events "github.com/aosderzhikov/sticky/internal/events"
+	lease "github.com/aosderzhikov/sticky/internal/lease"
+	meta "github.com/aosderzhikov/sticky/internal/meta"
+	ratelimit "github.com/aosderzhikov/sticky/internal/ratelimit"
+	tx "github.com/aosderzhikov/sticky/internal/tx"
+	typed "github.com/aosderzhikov/sticky/internal/typed"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStorage is a mock of Storage interface.
+type MockStorage struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageMockRecorder
+}
+
+// MockStorageMockRecorder is the mock recorder for MockStorage.
+type MockStorageMockRecorder struct {
+	mock *MockStorage
+}
+
+// NewMockStorage creates a new mock instance.
+func NewMockStorage(ctrl *gomock.Controller) *MockStorage {
+	mock := &MockStorage{ctrl: ctrl}
+	mock.recorder = &MockStorageMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorage) EXPECT() *MockStorageMockRecorder {
+	return m.recorder
+}
+
+// Addr mocks base method.
+func (m *MockStorage) Addr() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Addr")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Addr indicates an expected call of Addr.
+func (mr *MockStorageMockRecorder) Addr() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Addr", reflect.TypeOf((*MockStorage)(nil).Addr))
+}
+
+// Delete mocks base method.
+func (m *MockStorage) Delete(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockStorageMockRecorder) Delete(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStorage)(nil).Delete), ctx, key)
+}
+
+// Do mocks base method.
+func (m *MockStorage) Do(ctx context.Context, cmd typed.Command) (typed.Reply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", ctx, cmd)
+	ret0, _ := ret[0].(typed.Reply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockStorageMockRecorder) Do(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockStorage)(nil).Do), ctx, cmd)
+}
+
+// Exec mocks base method.
+func (m *MockStorage) Exec(ctx context.Context, t tx.Tx) (tx.Response, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exec", ctx, t)
+	ret0, _ := ret[0].(tx.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exec indicates an expected call of Exec.
+func (mr *MockStorageMockRecorder) Exec(ctx, t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockStorage)(nil).Exec), ctx, t)
+}
+
+// Expire mocks base method.
+func (m *MockStorage) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Expire", ctx, key, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Expire indicates an expected call of Expire.
+func (mr *MockStorageMockRecorder) Expire(ctx, key, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Expire", reflect.TypeOf((*MockStorage)(nil).Expire), ctx, key, ttl)
+}
+
+// Get mocks base method.
+func (m *MockStorage) Get(ctx context.Context, key string) (Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockStorageMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStorage)(nil).Get), ctx, key)
+}
+
+// Incr mocks base method.
+func (m *MockStorage) Incr(ctx context.Context, key string, by int64, ttl time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", ctx, key, by, ttl)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr.
+func (mr *MockStorageMockRecorder) Incr(ctx, key, by, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockStorage)(nil).Incr), ctx, key, by, ttl)
+}
+
+// IncrFloat mocks base method.
+func (m *MockStorage) IncrFloat(ctx context.Context, key string, by float64, ttl time.Duration) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrFloat", ctx, key, by, ttl)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrFloat indicates an expected call of IncrFloat.
+func (mr *MockStorageMockRecorder) IncrFloat(ctx, key, by, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrFloat", reflect.TypeOf((*MockStorage)(nil).IncrFloat), ctx, key, by, ttl)
+}
+
+// IsAlive mocks base method.
+func (m *MockStorage) IsAlive() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAlive")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsAlive indicates an expected call of IsAlive.
+func (mr *MockStorageMockRecorder) IsAlive() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAlive", reflect.TypeOf((*MockStorage)(nil).IsAlive))
+}
+
+// Lock mocks base method.
+func (m *MockStorage) Lock(ctx context.Context, key, owner string, ttl, wait time.Duration) (lease.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, owner, ttl, wait)
+	ret0, _ := ret[0].(lease.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockStorageMockRecorder) Lock(ctx, key, owner, ttl, wait any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockStorage)(nil).Lock), ctx, key, owner, ttl, wait)
+}
+
+// MDelete mocks base method.
+func (m *MockStorage) MDelete(ctx context.Context, keys []string) ([]batch.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MDelete", ctx, keys)
+	ret0, _ := ret[0].([]batch.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MDelete indicates an expected call of MDelete.
+func (mr *MockStorageMockRecorder) MDelete(ctx, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MDelete", reflect.TypeOf((*MockStorage)(nil).MDelete), ctx, keys)
+}
+
+// MGet mocks base method.
+func (m *MockStorage) MGet(ctx context.Context, keys []string) ([]batch.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MGet", ctx, keys)
+	ret0, _ := ret[0].([]batch.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MGet indicates an expected call of MGet.
+func (mr *MockStorageMockRecorder) MGet(ctx, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MGet", reflect.TypeOf((*MockStorage)(nil).MGet), ctx, keys)
+}
+
+// MSet mocks base method.
+func (m *MockStorage) MSet(ctx context.Context, items []batch.Item) ([]batch.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MSet", ctx, items)
+	ret0, _ := ret[0].([]batch.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MSet indicates an expected call of MSet.
+func (mr *MockStorageMockRecorder) MSet(ctx, items any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MSet", reflect.TypeOf((*MockStorage)(nil).MSet), ctx, items)
+}
+
+// Persist mocks base method.
+func (m *MockStorage) Persist(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Persist", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Persist indicates an expected call of Persist.
+func (mr *MockStorageMockRecorder) Persist(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Persist", reflect.TypeOf((*MockStorage)(nil).Persist), ctx, key)
+}
+
+// Publish mocks base method.
+func (m *MockStorage) Publish(ctx context.Context, channel string, data []byte) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, channel, data)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockStorageMockRecorder) Publish(ctx, channel, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockStorage)(nil).Publish), ctx, channel, data)
+}
+
+// RateLimit mocks base method.
+func (m *MockStorage) RateLimit(ctx context.Context, key string, p ratelimit.Params) (ratelimit.Decision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RateLimit", ctx, key, p)
+	ret0, _ := ret[0].(ratelimit.Decision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RateLimit indicates an expected call of RateLimit.
+func (mr *MockStorageMockRecorder) RateLimit(ctx, key, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RateLimit", reflect.TypeOf((*MockStorage)(nil).RateLimit), ctx, key, p)
+}
+
+// Renew mocks base method.
+func (m *MockStorage) Renew(ctx context.Context, key, owner string, ttl time.Duration) (lease.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Renew", ctx, key, owner, ttl)
+	ret0, _ := ret[0].(lease.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Renew indicates an expected call of Renew.
+func (mr *MockStorageMockRecorder) Renew(ctx, key, owner, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Renew", reflect.TypeOf((*MockStorage)(nil).Renew), ctx, key, owner, ttl)
+}
+
+// Set mocks base method.
+func (m_2 *MockStorage) Set(ctx context.Context, key string, value []byte, ttl time.Duration, m meta.Meta) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "Set", ctx, key, value, ttl, m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockStorageMockRecorder) Set(ctx, key, value, ttl, m any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockStorage)(nil).Set), ctx, key, value, ttl, m)
+}
+
+// SetStream mocks base method.
+func (m_2 *MockStorage) SetStream(ctx context.Context, key string, body io.Reader, size int64, ttl time.Duration, m meta.Meta) error {
+	m_2.ctrl.T.Helper()
+	ret := m_2.ctrl.Call(m_2, "SetStream", ctx, key, body, size, ttl, m)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStream indicates an expected call of SetStream.
+func (mr *MockStorageMockRecorder) SetStream(ctx, key, body, size, ttl, m any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStream", reflect.TypeOf((*MockStorage)(nil).SetStream), ctx, key, body, size, ttl, m)
+}
+
+// Subscribe mocks base method.
+func (m *MockStorage) Subscribe(ctx context.Context, f events.Filter) (*Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", ctx, f)
+	ret0, _ := ret[0].(*Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockStorageMockRecorder) Subscribe(ctx, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockStorage)(nil).Subscribe), ctx, f)
+}
+
+// Unlock mocks base method.
+func (m *MockStorage) Unlock(ctx context.Context, key, owner string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", ctx, key, owner)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockStorageMockRecorder) Unlock(ctx, key, owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockStorage)(nil).Unlock), ctx, key, owner)
+}
+
+// Watch mocks base method.
+func (m *MockStorage) Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, key, sinceVersion, timeout)
+	ret0, _ := ret[0].(Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockStorageMockRecorder) Watch(ctx, key, sinceVersion, timeout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockStorage)(nil).Watch), ctx, key, sinceVersion, timeout)
+}
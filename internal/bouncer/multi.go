@@ -0,0 +1,182 @@
+package bouncer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+)
+
+// MGet splits keys by owning storages and reads them in parallel.
+func (b *ShardService) MGet(ctx context.Context, keys []string) []batch.Result {
+	results := make(map[string]batch.Result, len(keys))
+	groups := make(map[int][]string)
+	for _, key := range keys {
+		i, ok := b.isExist(key)
+		if !ok {
+			results[key] = batch.Result{Key: key, Status: batch.StatusNotFound}
+			continue
+		}
+		groups[i] = append(groups[i], key)
+	}
+
+	fanOut(ctx, b.storages, groups, keyOf, results, Storage.MGet)
+
+	for _, res := range results {
+		if res.Status == batch.StatusNotFound {
+			b.deletStorageIndex(res.Key)
+		}
+	}
+	return ordered(keys, results)
+}
+
+// MSet places each key the same way as Set does, but doesnt fallback to other storages
+// on failure, failed keys are reported in results.
+func (b *ShardService) MSet(ctx context.Context, items []batch.Item) []batch.Result {
+	results := make(map[string]batch.Result, len(items))
+	groups := make(map[int][]batch.Item)
+	for _, item := range items {
+		i, ok := b.placement(item.Key)
+		if !ok {
+			results[item.Key] = batch.ErrorResult(item.Key, ErrAllStorage)
+			continue
+		}
+		groups[i] = append(groups[i], item)
+	}
+
+	fanOut(ctx, b.storages, groups, itemKey, results, Storage.MSet)
+
+	for i, group := range groups {
+		for _, item := range group {
+			b.cache.invalidate(item.Key)
+			if results[item.Key].Status == batch.StatusOK {
+				b.setStorageIndex(item.Key, i)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(items))
+	for _, item := range items {
+		keys = append(keys, item.Key)
+	}
+	return ordered(keys, results)
+}
+
+func (b *ShardService) MDelete(ctx context.Context, keys []string) []batch.Result {
+	results := make(map[string]batch.Result, len(keys))
+	groups := make(map[int][]string)
+	for _, key := range keys {
+		i, ok := b.isExist(key)
+		if !ok {
+			results[key] = batch.Result{Key: key, Status: batch.StatusNotFound}
+			continue
+		}
+		groups[i] = append(groups[i], key)
+	}
+
+	fanOut(ctx, b.storages, groups, keyOf, results, Storage.MDelete)
+
+	for _, key := range keys {
+		b.cache.invalidate(key)
+		if results[key].Status == batch.StatusOK {
+			b.deletStorageIndex(key)
+		}
+	}
+	return ordered(keys, results)
+}
+
+// placement returns index of storage for key: storage which already has the key,
+// storage of keys with the same hash tag, storage selected by hash or first alive storage.
+func (b *ShardService) placement(key string) (int, bool) {
+	if b.countAliveShards() == 0 {
+		return 0, false
+	}
+
+	if i, exist := b.isExist(key); exist && b.storages[i].IsAlive() {
+		return i, true
+	}
+
+	if i, tagged := b.tagStorage(key); tagged && b.storages[i].IsAlive() {
+		return i, true
+	}
+
+	if i := b.getShardIndByHash(key); b.storages[i].IsAlive() {
+		return i, true
+	}
+
+	for i, s := range b.storages {
+		if s.IsAlive() {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// fanOut sends groups to their storages in parallel and merges results.
+// All keys of group get error status if request to storage failed.
+func fanOut[T any](
+	ctx context.Context,
+	storages []Storage,
+	groups map[int][]T,
+	key func(T) string,
+	results map[string]batch.Result,
+	call func(s Storage, ctx context.Context, group []T) ([]batch.Result, error),
+) {
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	for i, group := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s := storages[i]
+			var (
+				groupResults []batch.Result
+				err          error
+			)
+			if s.IsAlive() {
+				groupResults, err = call(s, ctx, group)
+			} else {
+				err = fmt.Errorf("storage %q isnt alive", s.Addr())
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				for _, item := range group {
+					results[key(item)] = batch.ErrorResult(key(item), err)
+				}
+				return
+			}
+			for _, res := range groupResults {
+				results[res.Key] = res
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// ordered returns results in order of keys, keys missing in results get error status.
+func ordered(keys []string, results map[string]batch.Result) []batch.Result {
+	ordered := make([]batch.Result, 0, len(keys))
+	for _, key := range keys {
+		res, ok := results[key]
+		if !ok {
+			res = batch.ErrorResult(key, fmt.Errorf("storage didnt respond for key %q", key))
+		}
+		ordered = append(ordered, res)
+	}
+	return ordered
+}
+
+func keyOf(key string) string {
+	return key
+}
+
+func itemKey(item batch.Item) string {
+	return item.Key
+}
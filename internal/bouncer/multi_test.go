@@ -0,0 +1,55 @@
+package bouncer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMGetSplitByShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage1 := NewMockStorage(ctrl)
+	storage1.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage1.EXPECT().MGet(gomock.Any(), []string{"key1"}).Return([]batch.Result{
+		{Key: "key1", Status: batch.StatusOK, Value: []byte("data1")},
+	}, nil)
+
+	storage2 := NewMockStorage(ctrl)
+	storage2.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage2.EXPECT().Addr().Return("http://storage2/").AnyTimes()
+	storage2.EXPECT().MGet(gomock.Any(), []string{"key2"}).Return(nil, errors.New("failed"))
+
+	s := NewShardService([]Storage{storage1, storage2}, ServiceOptions{})
+	s.setStorageIndex("key1", 0)
+	s.setStorageIndex("key2", 1)
+
+	results := s.MGet(context.Background(), []string{"key3", "key2", "key1"})
+	require.Len(t, results, 3)
+	require.Equal(t, batch.Result{Key: "key3", Status: batch.StatusNotFound}, results[0])
+	require.Equal(t, batch.StatusError, results[1].Status)
+	require.Equal(t, batch.Result{Key: "key1", Status: batch.StatusOK, Value: []byte("data1")}, results[2])
+}
+
+func TestMSetIndexesStoredKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := NewMockStorage(ctrl)
+	storage.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage.EXPECT().MSet(gomock.Any(), gomock.Len(2)).Return([]batch.Result{
+		{Key: "key1", Status: batch.StatusOK},
+		{Key: "key2", Status: batch.StatusError, Error: "failed"},
+	}, nil)
+
+	s := NewShardService([]Storage{storage}, ServiceOptions{})
+	results := s.MSet(context.Background(), []batch.Item{{Key: "key1"}, {Key: "key2"}})
+	require.Equal(t, batch.StatusOK, results[0].Status)
+	require.Equal(t, batch.StatusError, results[1].Status)
+
+	_, exist := s.isExist("key1")
+	require.True(t, exist)
+	_, exist = s.isExist("key2")
+	require.False(t, exist)
+}
@@ -0,0 +1,182 @@
+package bouncer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/resp"
+	"github.com/aosderzhikov/sticky/internal/tx"
+	"github.com/aosderzhikov/sticky/internal/typed"
+)
+
+func NewRESPBackend(s *ShardService) *RESPBackend {
+	return &RESPBackend{s}
+}
+
+// RESPBackend executes commands of RESP clients by shard service.
+type RESPBackend struct {
+	s *ShardService
+}
+
+func (b *RESPBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := b.s.Get(ctx, key)
+	if errors.Is(err, ErrKeyNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, respError(err)
+	}
+	return value, true, nil
+}
+
+// Set with condition is applied as transaction, so condition is checked by storage atomically.
+func (b *RESPBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration, cond resp.Condition) (bool, error) {
+	set := tx.Op{Op: tx.OpSet, Key: key, Value: value, TTL: batch.Duration(ttl)}
+
+	switch cond {
+	case resp.IfAbsent:
+		return b.exec(ctx, tx.Op{Op: tx.OpCheck, Key: key}, set)
+	case resp.IfPresent:
+		for {
+			entry, err := b.s.GetEntry(ctx, key)
+			if errors.Is(err, ErrKeyNotExist) {
+				return false, nil
+			}
+			if err != nil {
+				return false, respError(err)
+			}
+
+			stored, err := b.exec(ctx, tx.Op{Op: tx.OpCheck, Key: key, Version: entry.Version}, set)
+			if stored || err != nil {
+				return stored, err
+			}
+			// key was changed after read, it is checked again
+		}
+	default:
+		return true, b.s.Set(ctx, key, value, ttl)
+	}
+}
+
+func (b *RESPBackend) exec(ctx context.Context, check, set tx.Op) (bool, error) {
+	txResp, err := b.s.Exec(ctx, tx.Tx{Ops: []tx.Op{check, set}})
+	if err != nil {
+		return false, respError(err)
+	}
+	return txResp.Committed, nil
+}
+
+// Delete returns number of deleted keys known by index of bouncer.
+func (b *RESPBackend) Delete(ctx context.Context, keys []string) (int, error) {
+	deleted := 0
+	for _, res := range b.s.MDelete(ctx, keys) {
+		switch res.Status {
+		case batch.StatusOK:
+			deleted++
+		case batch.StatusError:
+			return deleted, errors.New(res.Error)
+		}
+	}
+	return deleted, nil
+}
+
+func (b *RESPBackend) Expire(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return found(b.s.Expire(ctx, key, ttl))
+}
+
+func (b *RESPBackend) Persist(ctx context.Context, key string) (bool, error) {
+	return found(b.s.Persist(ctx, key))
+}
+
+// TTL treats value without reported ttl as value which doesnt expire, keeper reports ttl of others.
+func (b *RESPBackend) TTL(ctx context.Context, key string) (time.Duration, bool, error) {
+	entry, err := b.s.GetEntry(ctx, key)
+	if ok, err := found(err); !ok {
+		return 0, false, err
+	}
+	if entry.ExpiresAt.IsZero() {
+		return -1, true, nil
+	}
+	return max(time.Until(entry.ExpiresAt), 0), true, nil
+}
+
+func (b *RESPBackend) Incr(ctx context.Context, key string) (int64, error) {
+	value, err := b.s.Incr(ctx, key, 1, 0)
+	if err != nil {
+		return 0, respError(err)
+	}
+	return value, nil
+}
+
+// MGet returns nil for keys which werent read, e.g. typed values or keys of dead storages.
+func (b *RESPBackend) MGet(ctx context.Context, keys []string) ([][]byte, error) {
+	results := b.s.MGet(ctx, keys)
+	values := make([][]byte, 0, len(results))
+	for _, res := range results {
+		switch {
+		case res.Status != batch.StatusOK:
+			values = append(values, nil)
+		case res.Value == nil:
+			values = append(values, []byte{})
+		default:
+			values = append(values, res.Value)
+		}
+	}
+	return values, nil
+}
+
+// MSet isnt atomic, keys can be stored on different storages.
+func (b *RESPBackend) MSet(ctx context.Context, items []batch.Item) error {
+	for _, res := range b.s.MSet(ctx, items) {
+		if res.Status != batch.StatusOK {
+			return errors.New(res.Error)
+		}
+	}
+	return nil
+}
+
+func (b *RESPBackend) Scan(_ context.Context, cursor uint64, pattern string, count int) (uint64, []string, error) {
+	next, keys := b.s.Scan(cursor, pattern, count)
+	return next, keys, nil
+}
+
+func (b *RESPBackend) Info(_ context.Context) map[string]string {
+	alive := b.s.countAliveShards()
+	stats := b.s.Stats()
+	return map[string]string{
+		"role":           "bouncer",
+		"keys":           strconv.Itoa(b.s.Len()),
+		"storages":       strconv.Itoa(len(b.s.storages)),
+		"alive_storages": strconv.Itoa(alive),
+		"cache_hits":     strconv.FormatInt(stats.Cache.Hits, 10),
+		"cache_misses":   strconv.FormatInt(stats.Cache.Misses, 10),
+		"cache_entries":  strconv.Itoa(stats.Cache.Entries),
+	}
+}
+
+// found converts ErrKeyNotExist to false.
+func found(err error) (bool, error) {
+	if errors.Is(err, ErrKeyNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, respError(err)
+	}
+	return true, nil
+}
+
+// respError converts conflicts of storage to errors of RESP commands: storage responds
+// with 409 to reads and writes of typed values and to increments of non integers.
+func respError(err error) error {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.Code == http.StatusConflict {
+		if statusErr.Msg == typed.ErrWrongType.Error() {
+			return typed.ErrWrongType
+		}
+		return resp.ErrNotInteger
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package bouncer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/resp"
+	"github.com/aosderzhikov/sticky/internal/tx"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRESPBackendSetIfPresentRetriesConflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := NewMockStorage(ctrl)
+	storage.EXPECT().IsAlive().Return(true).AnyTimes()
+
+	committed := tx.Response{Committed: true}
+	gomock.InOrder(
+		storage.EXPECT().Get(gomock.Any(), "key").Return(Entry{Value: []byte("1"), Version: 1}, nil),
+		storage.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(tx.Response{}, nil),
+		storage.EXPECT().Get(gomock.Any(), "key").Return(Entry{Value: []byte("2"), Version: 2}, nil),
+		storage.EXPECT().Exec(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, t tx.Tx) (tx.Response, error) {
+			if t.Ops[0].Op != tx.OpCheck || t.Ops[0].Version != 2 {
+				return tx.Response{}, nil
+			}
+			return committed, nil
+		}),
+	)
+
+	s := NewShardService([]Storage{storage}, ServiceOptions{})
+	s.setStorageIndex("key", 0)
+
+	stored, err := NewRESPBackend(s).Set(context.Background(), "key", []byte("3"), 0, resp.IfPresent)
+	require.NoError(t, err)
+	require.True(t, stored)
+}
+
+func TestRESPBackendSetIfPresentAbsentKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := NewMockStorage(ctrl)
+
+	s := NewShardService([]Storage{storage}, ServiceOptions{})
+	stored, err := NewRESPBackend(s).Set(context.Background(), "key", []byte("v"), 0, resp.IfPresent)
+	require.NoError(t, err)
+	require.False(t, stored)
+}
+
+func TestRESPBackendTTL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := NewMockStorage(ctrl)
+	storage.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage.EXPECT().Get(gomock.Any(), "expiring").Return(Entry{ExpiresAt: time.Now().Add(time.Minute)}, nil)
+	storage.EXPECT().Get(gomock.Any(), "persisted").Return(Entry{}, nil)
+	storage.EXPECT().Get(gomock.Any(), "gone").Return(Entry{}, ErrKeyNotExist)
+
+	s := NewShardService([]Storage{storage}, ServiceOptions{})
+	for _, key := range []string{"expiring", "persisted", "gone"} {
+		s.setStorageIndex(key, 0)
+	}
+	b := NewRESPBackend(s)
+
+	ttl, found, err := b.TTL(context.Background(), "expiring")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	ttl, found, err = b.TTL(context.Background(), "persisted")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Negative(t, ttl)
+
+	_, found, err = b.TTL(context.Background(), "gone")
+	require.NoError(t, err)
+	require.False(t, found)
+	_, indexed := s.isExist("gone")
+	require.False(t, indexed)
+}
+
+func TestRESPBackendErrors(t *testing.T) {
+	wrongType := &StatusError{Code: http.StatusConflict, Msg: typed.ErrWrongType.Error()}
+	require.ErrorIs(t, respError(wrongType), typed.ErrWrongType)
+
+	notNumber := &StatusError{Code: http.StatusConflict, Msg: "value isnt a number"}
+	require.ErrorIs(t, respError(notNumber), resp.ErrNotInteger)
+
+	unavailable := &StatusError{Code: http.StatusServiceUnavailable}
+	require.Equal(t, unavailable, respError(unavailable))
+}
+
+func TestScanIndex(t *testing.T) {
+	s := NewShardService(nil, ServiceOptions{})
+	s.setStorageIndex("user:1", 0)
+	s.setStorageIndex("user:2", 0)
+	s.setStorageIndex("session:1", 0)
+
+	next, keys := s.Scan(0, "user:*", 10)
+	require.Zero(t, next)
+	require.ElementsMatch(t, []string{"user:1", "user:2"}, keys)
+}
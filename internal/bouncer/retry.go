@@ -0,0 +1,143 @@
+package bouncer
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+type Timeouts struct {
+	// Connect limits establishing of tcp connection with storage.
+	Connect time.Duration
+	// Read limits waiting of response headers from storage.
+	Read time.Duration
+	// Total limits the whole operation including retries.
+	Total time.Duration
+}
+
+var DefaultTimeouts = Timeouts{
+	Connect: 1 * time.Second,
+	Read:    2 * time.Second,
+	Total:   5 * time.Second,
+}
+
+func (t Timeouts) withDefaults() Timeouts {
+	if t.Connect == 0 {
+		t.Connect = DefaultTimeouts.Connect
+	}
+	if t.Read == 0 {
+		t.Read = DefaultTimeouts.Read
+	}
+	if t.Total == 0 {
+		t.Total = DefaultTimeouts.Total
+	}
+	return t
+}
+
+func newHTTPClient(t Timeouts) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: t.Connect}).DialContext
+	transport.ResponseHeaderTimeout = t.Read
+	// values are read as they are stored, even if their content encoding is gzip
+	transport.DisableCompression = true
+	return &http.Client{Transport: transport}
+}
+
+// newLongPollClient returns client for requests which wait on storage, e.g. for lock,
+// so response headers arent limited by read timeout, request deadline limits them instead.
+func newLongPollClient(t Timeouts) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: t.Connect}).DialContext
+	transport.DisableCompression = true
+	return &http.Client{Transport: transport}
+}
+
+// RetryPolicy describes retries of idempotent operations.
+// Delay before each next attempt grows exponentially from BaseDelay up to MaxDelay,
+// the actual delay is randomly picked from [0, delay) to spread retries of different clients.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   50 * time.Millisecond,
+	MaxDelay:    1 * time.Second,
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = DefaultRetryPolicy.MaxAttempts
+	}
+	if p.BaseDelay == 0 {
+		p.BaseDelay = DefaultRetryPolicy.BaseDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = DefaultRetryPolicy.MaxDelay
+	}
+	return p
+}
+
+func (p RetryPolicy) do(ctx context.Context, op func(ctx context.Context) error) error {
+	var err error
+	for attempt := 0; attempt < p.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			if !p.sleep(ctx, attempt) {
+				return err
+			}
+		}
+
+		err = op(ctx)
+		if !isRetryable(ctx, err) {
+			return err
+		}
+	}
+	return err
+}
+
+// sleep waits backoff delay before attempt, returns false if context
+// would be done earlier than the delay is passed.
+func (p RetryPolicy) sleep(ctx context.Context, attempt int) bool {
+	delay := p.backoff(attempt)
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return false
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, p.MaxDelay)
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay)
+}
+
+func isRetryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || errors.Is(err, ErrKeyNotExist) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -0,0 +1,92 @@
+package bouncer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/rpc"
+)
+
+func NewRPCBackend(s *ShardService) *RPCBackend {
+	return &RPCBackend{s}
+}
+
+// RPCBackend executes grpc requests by shard service.
+type RPCBackend struct {
+	s *ShardService
+}
+
+// Get reads key bypassing near cache, so version and ttl are fresh.
+func (b *RPCBackend) Get(ctx context.Context, key string) (rpc.Entry, error) {
+	entry, err := b.s.GetEntry(ctx, key)
+	if err != nil {
+		return rpc.Entry{}, rpcError(err)
+	}
+	return rpcEntry(entry), nil
+}
+
+func (b *RPCBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return rpcError(b.s.Set(ctx, key, value, ttl))
+}
+
+func (b *RPCBackend) Delete(ctx context.Context, key string) error {
+	return rpcError(b.s.Delete(ctx, key))
+}
+
+func (b *RPCBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	entry, err := b.Get(ctx, key)
+	return entry.TTL, err
+}
+
+func (b *RPCBackend) MGet(ctx context.Context, keys []string) ([]batch.Result, error) {
+	return b.s.MGet(ctx, keys), nil
+}
+
+func (b *RPCBackend) MSet(ctx context.Context, items []batch.Item) ([]batch.Result, error) {
+	return b.s.MSet(ctx, items), nil
+}
+
+func (b *RPCBackend) MDelete(ctx context.Context, keys []string) ([]batch.Result, error) {
+	return b.s.MDelete(ctx, keys), nil
+}
+
+func (b *RPCBackend) Scan(_ context.Context, cursor uint64, pattern string, count int) (uint64, []string, error) {
+	next, keys := b.s.Scan(cursor, pattern, count)
+	return next, keys, nil
+}
+
+func (b *RPCBackend) Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (rpc.Entry, error) {
+	entry, err := b.s.Watch(ctx, key, sinceVersion, timeout)
+	if errors.Is(err, ErrNotModified) {
+		return rpc.Entry{}, rpc.ErrNotModified
+	}
+	if err != nil {
+		return rpc.Entry{}, rpcError(err)
+	}
+	return rpcEntry(entry), nil
+}
+
+// rpcEntry treats value without reported ttl as value which doesnt expire.
+func rpcEntry(entry Entry) rpc.Entry {
+	ttl := time.Duration(-1)
+	if !entry.ExpiresAt.IsZero() {
+		ttl = max(time.Until(entry.ExpiresAt), 0)
+	}
+	return rpc.Entry{Value: entry.Value, Version: entry.Version, TTL: ttl}
+}
+
+// rpcError gives errors the same codes as http handler responds with.
+func rpcError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrKeyNotExist):
+		return rpc.ErrNotFound
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	default:
+		return rpc.Error(errorCode(err), err)
+	}
+}
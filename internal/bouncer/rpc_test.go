@@ -0,0 +1,50 @@
+package bouncer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/rpc"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRPCBackendGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := NewMockStorage(ctrl)
+	storage.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage.EXPECT().Get(gomock.Any(), "key").Return(Entry{Value: []byte("v"), Version: 4, ExpiresAt: time.Now().Add(time.Minute)}, nil)
+	storage.EXPECT().Get(gomock.Any(), "gone").Return(Entry{}, ErrKeyNotExist)
+
+	s := NewShardService([]Storage{storage}, ServiceOptions{})
+	s.setStorageIndex("key", 0)
+	s.setStorageIndex("gone", 0)
+	b := NewRPCBackend(s)
+
+	entry, err := b.Get(context.Background(), "key")
+	require.NoError(t, err)
+	require.Equal(t, uint64(4), entry.Version)
+	require.InDelta(t, time.Minute, entry.TTL, float64(time.Second))
+
+	_, err = b.Get(context.Background(), "gone")
+	require.ErrorIs(t, err, rpc.ErrNotFound)
+	_, indexed := s.isExist("gone")
+	require.False(t, indexed)
+
+	_, err = b.Get(context.Background(), "unknown")
+	require.ErrorIs(t, err, rpc.ErrNotFound)
+}
+
+func TestRPCError(t *testing.T) {
+	require.NoError(t, rpcError(nil))
+	require.ErrorIs(t, rpcError(context.Canceled), context.Canceled)
+
+	conflict := &StatusError{Code: http.StatusConflict, Msg: "wrong type"}
+	require.Equal(t, rpc.Error(http.StatusConflict, conflict), rpcError(conflict))
+
+	down := errors.New("storage isnt alive")
+	require.Equal(t, rpc.Error(http.StatusInternalServerError, down), rpcError(down))
+}
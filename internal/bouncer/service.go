@@ -6,47 +6,134 @@ import (
 	"errors"
 	"fmt"
 	"hash/fnv"
+	"io"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/aosderzhikov/sticky/internal/lease"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/aosderzhikov/sticky/internal/ratelimit"
+	"github.com/aosderzhikov/sticky/internal/tx"
+	"github.com/aosderzhikov/sticky/internal/typed"
 )
 
-func NewShardService(storages []Storage) *ShardService {
+type ServiceOptions struct {
+	Cache CacheConfig
+}
+
+func NewShardService(storages []Storage, opts ServiceOptions) *ShardService {
 	return &ShardService{
 		storages: storages,
 		index:    make(map[string]int),
+		tags:     make(map[string]tagPlacement),
+		flights:  newFlightGroup(),
+		cache:    newNearCache(opts.Cache),
 	}
 }
 
 type ShardService struct {
 	storages []Storage
+	flights  *flightGroup
+	cache    *nearCache
 
 	mu    sync.Mutex
 	index map[string]int
+	// tags keeps storage of keys with hash tag, so new keys with the same tag
+	// land on the same storage even if set of alive storages was changed.
+	tags map[string]tagPlacement
+}
+
+type tagPlacement struct {
+	storage int
+	keys    int
 }
 
 type Storage interface {
-	Get(ctx context.Context, key string) (value []byte, err error)
-	Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error)
+	Get(ctx context.Context, key string) (entry Entry, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, m meta.Meta) (err error)
+	SetStream(ctx context.Context, key string, body io.Reader, size int64, ttl time.Duration, m meta.Meta) (err error)
 	Delete(ctx context.Context, key string) (err error)
 
+	Expire(ctx context.Context, key string, ttl time.Duration) (err error)
+	Persist(ctx context.Context, key string) (err error)
+
+	MGet(ctx context.Context, keys []string) (results []batch.Result, err error)
+	MSet(ctx context.Context, items []batch.Item) (results []batch.Result, err error)
+	MDelete(ctx context.Context, keys []string) (results []batch.Result, err error)
+
+	Exec(ctx context.Context, t tx.Tx) (resp tx.Response, err error)
+
+	Incr(ctx context.Context, key string, by int64, ttl time.Duration) (value int64, err error)
+	IncrFloat(ctx context.Context, key string, by float64, ttl time.Duration) (value float64, err error)
+
+	RateLimit(ctx context.Context, key string, p ratelimit.Params) (d ratelimit.Decision, err error)
+
+	Lock(ctx context.Context, key, owner string, ttl, wait time.Duration) (l lease.Lease, err error)
+	Unlock(ctx context.Context, key, owner string) (err error)
+	Renew(ctx context.Context, key, owner string, ttl time.Duration) (l lease.Lease, err error)
+
+	Do(ctx context.Context, cmd typed.Command) (reply typed.Reply, err error)
+
+	Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (entry Entry, err error)
+
+	Subscribe(ctx context.Context, f events.Filter) (sub *Subscription, err error)
+	Publish(ctx context.Context, channel string, data []byte) (receivers int, err error)
+
 	Addr() (addr string)
 	IsAlive() (alive bool)
 }
 
+type Entry struct {
+	Value []byte
+	// ExpiresAt is zero if storage didnt report ttl of value.
+	ExpiresAt time.Time
+	// Version is zero if storage didnt report version of value.
+	Version uint64
+	Meta    meta.Meta
+	// CreatedAt and UpdatedAt are zero if storage didnt report them.
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
 var (
 	ErrAllStorage  error = errors.New("not found storage to store value")
 	ErrKeyNotExist error = errors.New("key not exist")
 )
 
+type Stats struct {
+	Cache CacheStats `json:"cache"`
+}
+
+func (b *ShardService) Stats() Stats {
+	return Stats{
+		Cache: b.cache.stats(),
+	}
+}
+
+// Invalidate removes key from near cache, e.g. when key was changed bypassing bouncer.
+func (b *ShardService) Invalidate(key string) {
+	b.cache.invalidate(key)
+}
+
 func (b *ShardService) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return b.SetWithMeta(ctx, key, value, ttl, meta.Meta{})
+}
+
+// SetWithMeta stores value with its metadata, previous metadata of key is dropped.
+func (b *ShardService) SetWithMeta(ctx context.Context, key string, value []byte, ttl time.Duration, m meta.Meta) error {
+	defer b.cache.invalidate(key)
+
 	var s Storage
 
 	i, exist := b.isExist(key)
 	if exist && b.storages[i].IsAlive() {
 		slog.Debug(fmt.Sprintf("key %q is exist, value will be updated", key))
 		s = b.storages[i]
-		err := s.Set(ctx, key, value, ttl)
+		err := s.Set(ctx, key, value, ttl, m)
 		if err == nil {
 			b.setStorageIndex(key, i)
 			return nil
@@ -54,11 +141,23 @@ func (b *ShardService) Set(ctx context.Context, key string, value []byte, ttl ti
 		slog.ErrorContext(ctx, fmt.Sprintf("update value by key %q failed: %v", key, err))
 	}
 
+	i, tagged := b.tagStorage(key)
+	if tagged && b.storages[i].IsAlive() {
+		s = b.storages[i]
+		slog.Debug(fmt.Sprintf("selected by hash tag storage with index %d and addr %q is alive", i, s.Addr()))
+		err := s.Set(ctx, key, value, ttl, m)
+		if err == nil {
+			b.setStorageIndex(key, i)
+			return nil
+		}
+		slog.ErrorContext(ctx, fmt.Sprintf("store value by key %q failed: %v", key, err))
+	}
+
 	i = b.getShardIndByHash(key)
 	if b.storages[i].IsAlive() {
 		s = b.storages[i]
 		slog.Debug(fmt.Sprintf("selected by hash storage with index %d and addr %q is alive", i, s.Addr()))
-		err := s.Set(ctx, key, value, ttl)
+		err := s.Set(ctx, key, value, ttl, m)
 		if err == nil {
 			b.setStorageIndex(key, i)
 			return nil
@@ -72,7 +171,7 @@ func (b *ShardService) Set(ctx context.Context, key string, value []byte, ttl ti
 			continue
 		}
 
-		err := s.Set(ctx, key, value, ttl)
+		err := s.Set(ctx, key, value, ttl, m)
 		if err != nil {
 			slog.ErrorContext(ctx, fmt.Sprintf("store key %q in storage with addr %q failed: %v", key, s.Addr(), err))
 			continue
@@ -85,7 +184,46 @@ func (b *ShardService) Set(ctx context.Context, key string, value []byte, ttl ti
 	return ErrAllStorage
 }
 
+// SetStream stores value read from body, body can be read only once, so unlike SetWithMeta
+// it doesnt try other storages if the selected one fails.
+func (b *ShardService) SetStream(ctx context.Context, key string, body io.Reader, size int64, ttl time.Duration, m meta.Meta) error {
+	defer b.cache.invalidate(key)
+
+	i, ok := b.streamStorage(key)
+	if !ok {
+		return ErrAllStorage
+	}
+
+	if err := b.storages[i].SetStream(ctx, key, body, size, ttl, m); err != nil {
+		return err
+	}
+	b.setStorageIndex(key, i)
+	return nil
+}
+
+// streamStorage selects storage in the same order as SetWithMeta does: storage of existing key,
+// storage of hash tag, storage by hash and the first alive one.
+func (b *ShardService) streamStorage(key string) (int, bool) {
+	if i, ok := b.isExist(key); ok && b.storages[i].IsAlive() {
+		return i, true
+	}
+	if i, ok := b.tagStorage(key); ok && b.storages[i].IsAlive() {
+		return i, true
+	}
+	if i := b.getShardIndByHash(key); b.storages[i].IsAlive() {
+		return i, true
+	}
+	for i, s := range b.storages {
+		if s.IsAlive() {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
 func (b *ShardService) Delete(ctx context.Context, key string) error {
+	defer b.cache.invalidate(key)
+
 	i, ok := b.isExist(key)
 	if !ok {
 		return ErrKeyNotExist
@@ -106,31 +244,70 @@ func (b *ShardService) Delete(ctx context.Context, key string) error {
 }
 
 func (b *ShardService) Get(ctx context.Context, key string) ([]byte, error) {
+	entry, err := b.GetCached(ctx, key)
+	return entry.Value, err
+}
+
+// GetCached returns entry which can be served by near cache, so unlike GetEntry its ttl can be stale.
+func (b *ShardService) GetCached(ctx context.Context, key string) (Entry, error) {
+	if entry, ok := b.cache.get(key); ok {
+		return entry, nil
+	}
+
 	i, ok := b.isExist(key)
 	if !ok {
-		return nil, ErrKeyNotExist
+		return Entry{}, ErrKeyNotExist
 	}
 
 	s := b.storages[i]
 	if !s.IsAlive() {
-		return nil, fmt.Errorf("storage %q isnt alive", s.Addr())
+		return Entry{}, fmt.Errorf("storage %q isnt alive", s.Addr())
 	}
 
-	value, err := s.Get(ctx, key)
-	if len(value) == 0 {
+	gen := b.cache.generation(key)
+	entry, err := b.read(ctx, s, key, gen)
+	if errors.Is(err, ErrKeyNotExist) {
 		b.deletStorageIndex(key)
-		return nil, ErrKeyNotExist
+		return Entry{}, ErrKeyNotExist
+	}
+	if err != nil {
+		return Entry{}, err
 	}
-	return value, err
+
+	b.cache.put(key, entry, gen)
+	return entry, nil
 }
 
+// read coalesces concurrent reads of the same key and cache generation, so all callers share the same value.
+func (b *ShardService) read(ctx context.Context, s Storage, key string, gen uint64) (Entry, error) {
+	return b.flights.do(ctx, key, gen, func(ctx context.Context) (Entry, error) {
+		return s.Get(ctx, key)
+	})
+}
+
+// getShardIndByHash hashes routing key, so keys with the same hash tag land on the same storage.
 func (b *ShardService) getShardIndByHash(key string) int {
 	aliveShards := b.countAliveShards()
 	h := fnv.New64a()
-	h.Write([]byte(key))
+	h.Write([]byte(routingKey(key)))
 	return int(h.Sum64() % uint64(aliveShards))
 }
 
+// routingKey returns substring inside the first {...} of key if it is not empty,
+// otherwise the whole key.
+func routingKey(key string) string {
+	start := strings.IndexByte(key, '{')
+	if start == -1 {
+		return key
+	}
+
+	end := strings.IndexByte(key[start+1:], '}')
+	if end <= 0 {
+		return key
+	}
+	return key[start+1 : start+1+end]
+}
+
 func (b *ShardService) countAliveShards() int {
 	count := 0
 	for _, s := range b.storages {
@@ -148,14 +325,53 @@ func (b *ShardService) isExist(key string) (int, bool) {
 	return i, ok
 }
 
+// tagStorage returns storage of keys with the same hash tag as key has.
+func (b *ShardService) tagStorage(key string) (int, bool) {
+	tag := routingKey(key)
+	if tag == key {
+		return 0, false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	p, ok := b.tags[tag]
+	return p.storage, ok
+}
+
 func (b *ShardService) setStorageIndex(key string, i int) {
 	b.mu.Lock()
+	b.deleteLocked(key)
 	b.index[key] = i
+	if tag := routingKey(key); tag != key {
+		p, ok := b.tags[tag]
+		if !ok {
+			p.storage = i
+		}
+		p.keys++
+		b.tags[tag] = p
+	}
 	b.mu.Unlock()
 }
 
 func (b *ShardService) deletStorageIndex(key string) {
 	b.mu.Lock()
-	delete(b.index, key)
+	b.deleteLocked(key)
 	b.mu.Unlock()
 }
+
+func (b *ShardService) deleteLocked(key string) {
+	if _, ok := b.index[key]; !ok {
+		return
+	}
+	delete(b.index, key)
+
+	if tag := routingKey(key); tag != key {
+		p := b.tags[tag]
+		p.keys--
+		if p.keys == 0 {
+			delete(b.tags, tag)
+			return
+		}
+		b.tags[tag] = p
+	}
+}
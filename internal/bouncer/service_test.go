@@ -0,0 +1,98 @@
+package bouncer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestGetEmptyValue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := NewMockStorage(ctrl)
+	storage.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage.EXPECT().Get(gomock.Any(), "key1").Return(Entry{Value: []byte{}}, nil)
+
+	s := NewShardService([]Storage{storage}, ServiceOptions{})
+	s.setStorageIndex("key1", 0)
+
+	value, err := s.Get(context.Background(), "key1")
+	require.NoError(t, err)
+	require.Empty(t, value)
+
+	_, exist := s.isExist("key1")
+	require.True(t, exist)
+}
+
+func TestGetKeyNotExist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := NewMockStorage(ctrl)
+	storage.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage.EXPECT().Get(gomock.Any(), "key1").Return(Entry{}, ErrKeyNotExist)
+
+	s := NewShardService([]Storage{storage}, ServiceOptions{})
+	s.setStorageIndex("key1", 0)
+
+	_, err := s.Get(context.Background(), "key1")
+	require.ErrorIs(t, err, ErrKeyNotExist)
+
+	_, exist := s.isExist("key1")
+	require.False(t, exist)
+
+	_, err = s.Get(context.Background(), "key2")
+	require.ErrorIs(t, err, ErrKeyNotExist)
+}
+
+func TestHashTagColocation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storages := make([]Storage, 0, 5)
+	for range 5 {
+		storage := NewMockStorage(ctrl)
+		storage.EXPECT().IsAlive().Return(true).AnyTimes()
+		storages = append(storages, storage)
+	}
+	s := NewShardService(storages, ServiceOptions{})
+
+	want := s.getShardIndByHash("{user42}:profile")
+	for _, key := range []string{"{user42}:email", "{user42}", "session:{user42}", "{user42}:{user43}"} {
+		require.Equal(t, want, s.getShardIndByHash(key), key)
+	}
+}
+
+func TestHashTagColocationAfterStorageDown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	alive := []bool{true, true, true}
+	stored := make(map[string]int)
+	storages := make([]Storage, 0, len(alive))
+	for i := range alive {
+		storage := NewMockStorage(ctrl)
+		storage.EXPECT().IsAlive().DoAndReturn(func() bool { return alive[i] }).AnyTimes()
+		storage.EXPECT().Addr().Return("").AnyTimes()
+		storage.EXPECT().Set(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, key string, _ []byte, _ time.Duration, _ meta.Meta) error {
+				stored[key] = i
+				return nil
+			}).AnyTimes()
+		storages = append(storages, storage)
+	}
+	s := NewShardService(storages, ServiceOptions{})
+
+	require.NoError(t, s.Set(context.Background(), "{user42}:profile", []byte("data"), 0))
+	for i := range alive {
+		if i != stored["{user42}:profile"] {
+			alive[i] = false
+			break
+		}
+	}
+
+	require.NoError(t, s.Set(context.Background(), "{user42}:email", []byte("data"), 0))
+	require.Equal(t, stored["{user42}:profile"], stored["{user42}:email"])
+
+	s.deletStorageIndex("{user42}:profile")
+	s.deletStorageIndex("{user42}:email")
+	_, tagged := s.tagStorage("{user42}:profile")
+	require.False(t, tagged)
+}
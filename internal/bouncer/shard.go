@@ -3,17 +3,40 @@ package bouncer
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/lease"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/aosderzhikov/sticky/internal/ratelimit"
+	"github.com/aosderzhikov/sticky/internal/tx"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/aosderzhikov/sticky/internal/wire"
 )
 
-func NewShard(addr string, interval time.Duration, client *http.Client) *Shard {
+type ShardOptions struct {
+	Timeouts  Timeouts
+	Retry     RetryPolicy
+	Transport Transport
+}
+
+func NewShard(addr string, interval time.Duration, client *http.Client, opts ShardOptions) *Shard {
+	opts.Timeouts = opts.Timeouts.withDefaults()
+	opts.Retry = opts.Retry.withDefaults()
+
+	pollClient := client
 	if client == nil {
-		client = &http.Client{}
+		client = newHTTPClient(opts.Timeouts)
+		pollClient = newLongPollClient(opts.Timeouts)
 	}
 
 	if !strings.HasSuffix(addr, "/") {
@@ -23,7 +46,11 @@ func NewShard(addr string, interval time.Duration, client *http.Client) *Shard {
 	return &Shard{
 		addr:                addr,
 		client:              *client,
+		pollClient:          *pollClient,
 		healthCheckInterval: interval,
+		timeouts:            opts.Timeouts,
+		retry:               opts.Retry,
+		bin:                 newBinaryClient(opts.Transport, opts.Timeouts),
 	}
 }
 
@@ -32,23 +59,274 @@ type Shard struct {
 	alive               bool
 	healthCheckInterval time.Duration
 	client              http.Client
+	pollClient          http.Client
+	timeouts            Timeouts
+	retry               RetryPolicy
+	// bin is nil if storage is reached only over http.
+	bin *wire.Client
 }
 
 const (
 	setEndpoint         = "set"
 	getEndpoint         = "get"
 	deleteEndpoint      = "delete"
+	expireEndpoint      = "expire"
+	persistEndpoint     = "persist"
+	mgetEndpoint        = "mget"
+	msetEndpoint        = "mset"
+	mdelEndpoint        = "mdel"
+	txEndpoint          = "tx"
+	incrEndpoint        = "incr"
+	incrFloatEndpoint   = "incrfloat"
+	rateLimitEndpoint   = "ratelimit"
+	lockEndpoint        = "lock"
+	unlockEndpoint      = "unlock"
+	renewEndpoint       = "renew"
+	watchEndpoint       = "watch"
+	subscribeEndpoint   = "subscribe"
+	publishEndpoint     = "publish"
 	healthCheckEndpoint = "health-check"
 )
 
-func (s *Shard) Get(ctx context.Context, key string) (value []byte, err error) {
-	url := s.addr + getEndpoint
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+var batchOps = map[string]wire.Op{
+	mgetEndpoint: wire.OpMGet,
+	msetEndpoint: wire.OpMSet,
+	mdelEndpoint: wire.OpMDelete,
+}
+
+// StatusError is returned when storage responds with unexpected status code.
+type StatusError struct {
+	Code int
+	Msg  string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("storage responded with status %d: %s", e.Code, e.Msg)
+}
+
+func (s *Shard) Get(ctx context.Context, key string) (entry Entry, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	err = s.retry.do(ctx, func(ctx context.Context) error {
+		entry, err = s.get(ctx, key)
+		return err
+	})
+	return entry, err
+}
+
+// Set isnt retried because it isnt idempotent without versioning of values.
+func (s *Shard) Set(ctx context.Context, key string, value []byte, ttl time.Duration, m meta.Meta) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	return s.set(ctx, key, value, ttl, m)
+}
+
+// SetStream sends value to storage while it is read from body, so bouncer doesnt buffer it.
+// Size is -1 if it is unknown. Binary transport needs the whole value, so it isnt used.
+func (s *Shard) SetStream(ctx context.Context, key string, body io.Reader, size int64, ttl time.Duration, m meta.Meta) (err error) {
+	// total timeout isnt applied, as body is sent as long as client uploads it,
+	// transport limits waiting of response headers after body is sent by read timeout
+	url := s.addr + setEndpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, io.NopCloser(body))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+
+	putKey(req, key)
+	putTTL(req, ttl)
+	m.Put(req.Header)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkStatus(resp)
+}
+
+func (s *Shard) Delete(ctx context.Context, key string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	return s.retry.do(ctx, func(ctx context.Context) error {
+		return s.delete(ctx, key)
+	})
+}
+
+func (s *Shard) Expire(ctx context.Context, key string, ttl time.Duration) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	return s.retry.do(ctx, func(ctx context.Context) error {
+		return s.post(ctx, expireEndpoint, key, ttl)
+	})
+}
+
+// Persist returns ErrKeyNotExist if key doesnt exist or already doesnt expire.
+// Persist isnt retried, if response of applied persist is lost, next attempt gets 404
+// as key already doesnt expire.
+func (s *Shard) Persist(ctx context.Context, key string) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	return s.post(ctx, persistEndpoint, key, 0)
+}
+
+// post sends request to endpoint which responds only with status.
+func (s *Shard) post(ctx context.Context, endpoint, key string, ttl time.Duration) error {
+	url := s.addr + endpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, http.NoBody)
+	if err != nil {
+		return err
+	}
+
+	putKey(req, key)
+	if ttl != 0 {
+		putTTL(req, ttl)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkStatus(resp)
+}
+
+func (s *Shard) MGet(ctx context.Context, keys []string) (results []batch.Result, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	err = s.retry.do(ctx, func(ctx context.Context) error {
+		results, err = s.batch(ctx, mgetEndpoint, batch.KeysRequest(keys))
+		return err
+	})
+	return results, err
+}
+
+// MSet isnt retried the same as Set.
+func (s *Shard) MSet(ctx context.Context, items []batch.Item) (results []batch.Result, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	return s.batch(ctx, msetEndpoint, batch.Request{Items: items})
+}
+
+func (s *Shard) MDelete(ctx context.Context, keys []string) (results []batch.Result, err error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	err = s.retry.do(ctx, func(ctx context.Context) error {
+		results, err = s.batch(ctx, mdelEndpoint, batch.KeysRequest(keys))
+		return err
+	})
+	return results, err
+}
+
+func (s *Shard) batch(ctx context.Context, endpoint string, batchReq batch.Request) ([]batch.Result, error) {
+	op := batchOps[endpoint]
+	binResp, sent, err := s.sendBinary(ctx, wire.Request{Op: op, Batch: batchReq}, op != wire.OpMSet)
+	if sent {
+		return binResp.Results, err
+	}
+
+	url := s.addr + endpoint
+
+	var body bytes.Buffer
+	if err := batch.EncodeRequest(&body, batch.ContentTypeBinary, batchReq); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", batch.ContentTypeBinary)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err = checkStatus(resp); err != nil {
+		return nil, err
+	}
+
+	batchResp, err := batch.DecodeResponse(resp.Body, batch.ContentTypeBinary)
+	return batchResp.Results, err
+}
+
+// Exec isnt retried, transaction could be applied even if response is lost.
+func (s *Shard) Exec(ctx context.Context, t tx.Tx) (tx.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	url := s.addr + txEndpoint
+
+	body, err := json.Marshal(t)
+	if err != nil {
+		return tx.Response{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return tx.Response{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return tx.Response{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusConflict {
+		if err = checkStatus(resp); err != nil {
+			return tx.Response{}, err
+		}
+	}
+
+	var txResp tx.Response
+	err = json.NewDecoder(resp.Body).Decode(&txResp)
+	return txResp, err
+}
+
+// Incr isnt retried, the same as Set.
+func (s *Shard) Incr(ctx context.Context, key string, by int64, ttl time.Duration) (int64, error) {
+	body, err := s.incr(ctx, incrEndpoint, key, strconv.FormatInt(by, 10), ttl)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(body), 10, 64)
+}
+
+func (s *Shard) IncrFloat(ctx context.Context, key string, by float64, ttl time.Duration) (float64, error) {
+	body, err := s.incr(ctx, incrFloatEndpoint, key, strconv.FormatFloat(by, 'f', -1, 64), ttl)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(body), 64)
+}
+
+func (s *Shard) incr(ctx context.Context, endpoint, key, by string, ttl time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	url := s.addr + endpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, http.NoBody)
 	if err != nil {
 		return nil, err
 	}
 
 	putKey(req, key)
+	putTTL(req, ttl)
+	putBy(req, by)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -56,10 +334,327 @@ func (s *Shard) Get(ctx context.Context, key string) (value []byte, err error) {
 	}
 	defer resp.Body.Close()
 
+	if err = checkStatus(resp); err != nil {
+		return nil, err
+	}
 	return io.ReadAll(resp.Body)
 }
 
-func (s *Shard) Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error) {
+// RateLimit isnt retried, the same as Set.
+func (s *Shard) RateLimit(ctx context.Context, key string, p ratelimit.Params) (ratelimit.Decision, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	url := s.addr + rateLimitEndpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, http.NoBody)
+	if err != nil {
+		return ratelimit.Decision{}, err
+	}
+
+	query := req.URL.Query()
+	query.Set("key", key)
+	query.Set("algorithm", string(p.Algorithm))
+	query.Set("limit", strconv.FormatInt(p.Limit, 10))
+	query.Set("window", p.Window.String())
+	query.Set("cost", strconv.FormatInt(p.Cost, 10))
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return ratelimit.Decision{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		if err = checkStatus(resp); err != nil {
+			return ratelimit.Decision{}, err
+		}
+	}
+	return handler.ExtractRateLimitDecision(resp)
+}
+
+// Do executes command on typed value, only read commands are retried.
+// Blocking pop waits on storage not longer than deadline of ctx.
+func (s *Shard) Do(ctx context.Context, cmd typed.Command) (reply typed.Reply, err error) {
+	if cmd.Blocking() {
+		if deadline, ok := ctx.Deadline(); ok {
+			cmd.Timeout = max(min(cmd.Timeout, time.Until(deadline)), 0)
+		}
+		ctx, cancel := context.WithTimeout(ctx, cmd.Timeout+s.timeouts.Total)
+		defer cancel()
+		return s.do(ctx, &s.pollClient, cmd)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	if !cmd.Op.ReadOnly() {
+		return s.do(ctx, &s.client, cmd)
+	}
+	err = s.retry.do(ctx, func(ctx context.Context) error {
+		reply, err = s.do(ctx, &s.client, cmd)
+		return err
+	})
+	return reply, err
+}
+
+func (s *Shard) do(ctx context.Context, client *http.Client, cmd typed.Command) (typed.Reply, error) {
+	var body io.Reader = http.NoBody
+	if cmd.HasBody() {
+		body = bytes.NewReader(cmd.Value)
+	}
+
+	url := s.addr + string(cmd.Op)
+	req, err := http.NewRequestWithContext(ctx, cmd.Op.Method(), url, body)
+	if err != nil {
+		return typed.Reply{}, err
+	}
+	req.URL.RawQuery = cmd.Query().Encode()
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return typed.Reply{}, err
+	}
+	defer resp.Body.Close()
+
+	if err = checkStatus(resp); err != nil {
+		return typed.Reply{}, err
+	}
+
+	var reply typed.Reply
+	if err = json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+		return typed.Reply{}, err
+	}
+	return reply, nil
+}
+
+// Watch waits on storage for change of key, it returns ErrNotModified if key isnt changed until timeout.
+func (s *Shard) Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (Entry, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout+s.timeouts.Total)
+	defer cancel()
+
+	url := s.addr + watchEndpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	query := req.URL.Query()
+	query.Set("key", key)
+	query.Set("sinceVersion", strconv.FormatUint(sinceVersion, 10))
+	query.Set("timeout", timeout.String())
+	req.URL.RawQuery = query.Encode()
+
+	start := time.Now()
+	resp, err := s.pollClient.Do(req)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return Entry{}, ErrNotModified
+	}
+	if err = checkStatus(resp); err != nil {
+		return Entry{}, err
+	}
+	return readEntry(resp, start)
+}
+
+// Subscribe streams events of storage until ctx is cancelled or stream is broken.
+func (s *Shard) Subscribe(ctx context.Context, f events.Filter) (*Subscription, error) {
+	url := s.addr + subscribeEndpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	query := req.URL.Query()
+	if f.Channels {
+		query.Set("channel", f.Pattern)
+	} else {
+		query.Set("pattern", f.Pattern)
+	}
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := s.pollClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkStatus(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	sub := &Subscription{events: make(chan events.Event, events.DefaultBufferSize)}
+	go func() {
+		defer resp.Body.Close()
+		err := events.ReadStream(resp.Body, func(e events.Event) error {
+			select {
+			case sub.events <- e:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if ctx.Err() == nil {
+			sub.err = fmt.Errorf("events stream of storage %q: %w", s.addr, err)
+		}
+		close(sub.events)
+	}()
+	return sub, nil
+}
+
+func (s *Shard) Publish(ctx context.Context, channel string, data []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	url := s.addr + publishEndpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+
+	query := req.URL.Query()
+	query.Set("channel", channel)
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if err = checkStatus(resp); err != nil {
+		return 0, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(string(body))
+}
+
+// Lock isnt retried, deadline of request includes time of waiting for the lock.
+func (s *Shard) Lock(ctx context.Context, key, owner string, ttl, wait time.Duration) (lease.Lease, error) {
+	ctx, cancel := context.WithTimeout(ctx, wait+s.timeouts.Total)
+	defer cancel()
+	return s.lease(ctx, lockEndpoint, key, owner, ttl, wait)
+}
+
+func (s *Shard) Unlock(ctx context.Context, key, owner string) error {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+
+	resp, err := s.lockRequest(ctx, unlockEndpoint, key, owner, 0, 0)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkStatus(resp)
+}
+
+func (s *Shard) Renew(ctx context.Context, key, owner string, ttl time.Duration) (lease.Lease, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeouts.Total)
+	defer cancel()
+	return s.lease(ctx, renewEndpoint, key, owner, ttl, 0)
+}
+
+func (s *Shard) lease(ctx context.Context, endpoint, key, owner string, ttl, wait time.Duration) (lease.Lease, error) {
+	resp, err := s.lockRequest(ctx, endpoint, key, owner, ttl, wait)
+	if err != nil {
+		return lease.Lease{}, err
+	}
+	defer resp.Body.Close()
+
+	if err = checkStatus(resp); err != nil {
+		return lease.Lease{}, err
+	}
+
+	var l lease.Lease
+	if err = json.NewDecoder(resp.Body).Decode(&l); err != nil {
+		return lease.Lease{}, err
+	}
+	return l, nil
+}
+
+func (s *Shard) lockRequest(ctx context.Context, endpoint, key, owner string, ttl, wait time.Duration) (*http.Response, error) {
+	url := s.addr + endpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+
+	query := req.URL.Query()
+	query.Set("key", key)
+	query.Set("owner", owner)
+	if ttl != 0 {
+		query.Set("ttl", ttl.String())
+	}
+	if wait != 0 {
+		query.Set("wait", wait.String())
+	}
+	req.URL.RawQuery = query.Encode()
+
+	if wait != 0 {
+		return s.pollClient.Do(req)
+	}
+	return s.client.Do(req)
+}
+
+func (s *Shard) get(ctx context.Context, key string) (entry Entry, err error) {
+	start := time.Now()
+	binResp, sent, err := s.sendBinary(ctx, wire.Request{Op: wire.OpGet, Key: key}, true)
+	if sent {
+		if err != nil {
+			return Entry{}, err
+		}
+		return entryFromWire(binResp, start), nil
+	}
+
+	url := s.addr + getEndpoint
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	putKey(req, key)
+
+	start = time.Now()
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return Entry{}, err
+	}
+	defer resp.Body.Close()
+
+	if err = checkStatus(resp); err != nil {
+		return Entry{}, err
+	}
+
+	return readEntry(resp, start)
+}
+
+func readEntry(resp *http.Response, start time.Time) (entry Entry, err error) {
+	if ttl, ok := handler.ExtractTTLHeader(resp.Header); ok {
+		entry.ExpiresAt = start.Add(ttl)
+	}
+	entry.Version, _ = handler.ExtractVersionHeader(resp.Header)
+	entry.CreatedAt = handler.ExtractTimeHeader(resp.Header, handler.CreatedAtHeader)
+	entry.UpdatedAt = handler.ExtractTimeHeader(resp.Header, handler.UpdatedAtHeader)
+	// metadata was validated by storage when value was set
+	entry.Meta, _ = meta.FromHeader(resp.Header)
+
+	entry.Value, err = io.ReadAll(resp.Body)
+	return entry, err
+}
+
+func (s *Shard) set(ctx context.Context, key string, value []byte, ttl time.Duration, m meta.Meta) (err error) {
+	_, sent, err := s.sendBinary(ctx, wire.Request{Op: wire.OpSet, Key: key, Value: value, TTL: ttl, Meta: m}, false)
+	if sent {
+		return err
+	}
+
 	url := s.addr + setEndpoint
 
 	body := bytes.NewReader(value)
@@ -70,15 +665,23 @@ func (s *Shard) Set(ctx context.Context, key string, value []byte, ttl time.Dura
 
 	putKey(req, key)
 	putTTL(req, ttl)
+	m.Put(req.Header)
 
 	resp, err := s.client.Do(req)
 	if err != nil {
 		return err
 	}
-	resp.Body.Close()
-	return nil
+	defer resp.Body.Close()
+
+	return checkStatus(resp)
 }
-func (s *Shard) Delete(ctx context.Context, key string) (err error) {
+
+func (s *Shard) delete(ctx context.Context, key string) (err error) {
+	_, sent, err := s.sendBinary(ctx, wire.Request{Op: wire.OpDelete, Key: key}, true)
+	if sent {
+		return err
+	}
+
 	url := s.addr + deleteEndpoint
 	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, http.NoBody)
 	if err != nil {
@@ -91,8 +694,9 @@ func (s *Shard) Delete(ctx context.Context, key string) (err error) {
 	if err != nil {
 		return err
 	}
-	resp.Body.Close()
-	return nil
+	defer resp.Body.Close()
+
+	return checkStatus(resp)
 }
 
 func (s *Shard) IsAlive() bool {
@@ -133,17 +737,46 @@ func putTTL(req *http.Request, ttl time.Duration) {
 	req.URL.RawQuery = query.Encode()
 }
 
+func putBy(req *http.Request, by string) {
+	query := req.URL.Query()
+	query.Set("by", by)
+	req.URL.RawQuery = query.Encode()
+}
+
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	msg, _ := io.ReadAll(resp.Body)
+	return statusError(resp.StatusCode, strings.TrimSpace(string(msg)))
+}
+
+func statusError(code int, msg string) error {
+	switch code {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return ErrKeyNotExist
+	default:
+		return &StatusError{Code: code, Msg: msg}
+	}
+}
+
 func (s *Shard) healthCheck() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeouts.Total)
+	defer cancel()
+
 	url := s.addr + healthCheckEndpoint
-	req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
 	if err != nil {
 		slog.Error(fmt.Sprintf("health check %q failed: %v", s.addr, err))
 		return false
 	}
 
-	_, err = s.client.Do(req)
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return false
 	}
+	resp.Body.Close()
 	return true
 }
@@ -0,0 +1,252 @@
+package bouncer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/keeper"
+	"github.com/aosderzhikov/sticky/internal/lease"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/aosderzhikov/sticky/internal/ratelimit"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestShard(t *testing.T, h http.HandlerFunc, opts ShardOptions) *Shard {
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return NewShard(srv.URL, time.Second, nil, opts)
+}
+
+func TestShardGetRetry(t *testing.T) {
+	var calls atomic.Int32
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		handler.PutTTLHeader(w, time.Minute)
+		_, _ = w.Write([]byte("data"))
+	}, ShardOptions{Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}})
+
+	entry, err := s.Get(context.Background(), "key1")
+	require.NoError(t, err)
+	require.Equal(t, "data", string(entry.Value))
+	require.WithinDuration(t, time.Now().Add(time.Minute), entry.ExpiresAt, time.Second)
+	require.Equal(t, int32(3), calls.Load())
+}
+
+func TestShardGetNotFoundNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}, ShardOptions{Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}})
+
+	_, err := s.Get(context.Background(), "key1")
+	require.ErrorIs(t, err, ErrKeyNotExist)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestShardSetNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}, ShardOptions{Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}})
+
+	err := s.Set(context.Background(), "key1", []byte("data"), 0, meta.Meta{})
+	require.Error(t, err)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestShardPersistNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		// persist is applied, but response is lost, so retry would get 404
+		if calls.Add(1) > 1 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}, ShardOptions{Retry: RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}})
+
+	err := s.Persist(context.Background(), "key1")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrKeyNotExist)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestShardSetStream(t *testing.T) {
+	received := make(chan string, 2)
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/set", r.URL.Path)
+		require.Equal(t, int64(-1), r.ContentLength)
+
+		first := make([]byte, 5)
+		_, err := io.ReadFull(r.Body, first)
+		require.NoError(t, err)
+		received <- string(first)
+
+		rest, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received <- string(rest)
+	}, ShardOptions{})
+
+	body, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("first"))
+		// storage gets the first part before the rest is written
+		require.Equal(t, "first", <-received)
+		_, _ = pw.Write([]byte("second"))
+		_ = pw.Close()
+	}()
+
+	require.NoError(t, s.SetStream(context.Background(), "key1", body, -1, 0, meta.Meta{}))
+	require.Equal(t, "second", <-received)
+}
+
+func TestShardSetStreamSlowUpload(t *testing.T) {
+	k := keeper.NewService(time.Minute)
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /set", keeper.NewHandler(k, keeper.HandlerOptions{}).SetHandle)
+	// keeper is configured like cmd/keeper
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.ReadHeaderTimeout = 200 * time.Millisecond
+	srv.Config.WriteTimeout = 1 * time.Second
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	s := NewShard(srv.URL, time.Second, nil, ShardOptions{Timeouts: Timeouts{Total: 300 * time.Millisecond}})
+
+	// upload takes longer than total timeout of shard and write timeout of keeper
+	body, pw := io.Pipe()
+	go func() {
+		for _, part := range []string{"slow", "ly", " uploaded"} {
+			time.Sleep(500 * time.Millisecond)
+			_, _ = pw.Write([]byte(part))
+		}
+		_ = pw.Close()
+	}()
+
+	require.NoError(t, s.SetStream(context.Background(), "key1", body, -1, 0, meta.Meta{}))
+	entry, ok, _ := k.Get("key1")
+	require.True(t, ok)
+	require.Equal(t, []byte("slowly uploaded"), entry.Data)
+}
+
+func TestShardMeta(t *testing.T) {
+	var stored meta.Meta
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/set":
+			var err error
+			stored, err = meta.FromHeader(r.Header)
+			require.NoError(t, err)
+		case "/get":
+			stored.Put(w.Header())
+			_, _ = w.Write([]byte("not gzip"))
+		}
+	}, ShardOptions{})
+
+	m := meta.Meta{ContentType: "application/json", ContentEncoding: "gzip", User: map[string]string{"Owner": "team-a"}}
+	require.NoError(t, s.Set(context.Background(), "key1", []byte("not gzip"), 0, m))
+	require.Equal(t, m, stored)
+
+	// value is returned as stored, client doesnt try to decompress it
+	entry, err := s.Get(context.Background(), "key1")
+	require.NoError(t, err)
+	require.Equal(t, []byte("not gzip"), entry.Value)
+	require.Equal(t, m, entry.Meta)
+}
+
+func TestShardReadTimeout(t *testing.T) {
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}, ShardOptions{
+		Timeouts: Timeouts{Total: 50 * time.Millisecond},
+		Retry:    RetryPolicy{MaxAttempts: 3, BaseDelay: 10 * time.Millisecond},
+	})
+
+	start := time.Now()
+	_, err := s.Get(context.Background(), "key1")
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestRetryRespectsDeadline(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second, MaxDelay: time.Second}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := p.do(ctx, func(ctx context.Context) error {
+		return &StatusError{Code: http.StatusBadGateway}
+	})
+	require.Error(t, err)
+	require.Less(t, time.Since(start), 500*time.Millisecond)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 40 * time.Millisecond}
+	for attempt := 1; attempt < 10; attempt++ {
+		require.Less(t, p.backoff(attempt), p.MaxDelay)
+	}
+	require.Less(t, p.backoff(1), p.BaseDelay)
+}
+
+func TestShardRateLimitDenied(t *testing.T) {
+	p := ratelimit.Params{Algorithm: ratelimit.TokenBucket, Limit: 5, Window: time.Second, Cost: 1}
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "5", r.URL.Query().Get("limit"))
+		require.Equal(t, "token_bucket", r.URL.Query().Get("algorithm"))
+		handler.WriteRateLimitResponse(w, p, ratelimit.Decision{RetryAfter: 200 * time.Millisecond})
+	}, ShardOptions{})
+
+	d, err := s.RateLimit(context.Background(), "user42", p)
+	require.NoError(t, err)
+	require.Equal(t, ratelimit.Decision{RetryAfter: 200 * time.Millisecond}, d)
+}
+
+func TestShardLockWait(t *testing.T) {
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/lock", r.URL.Path)
+		require.Equal(t, "100ms", r.URL.Query().Get("wait"))
+
+		// lock is acquired later than read timeout
+		time.Sleep(50 * time.Millisecond)
+		handler.WriteLease(w, lease.Lease{Token: 7})
+	}, ShardOptions{Timeouts: Timeouts{Read: 10 * time.Millisecond}})
+
+	l, err := s.Lock(context.Background(), "job", "worker1", time.Minute, 100*time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), l.Token)
+}
+
+func TestShardBlockingPopDeadline(t *testing.T) {
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		timeout, err := time.ParseDuration(r.URL.Query().Get("timeout"))
+		require.NoError(t, err)
+		require.LessOrEqual(t, timeout, 100*time.Millisecond)
+
+		// response headers are sent later than read timeout
+		time.Sleep(timeout / 2)
+		handler.WriteReply(w, typed.Reply{Value: []byte("job1")})
+	}, ShardOptions{Timeouts: Timeouts{Read: 10 * time.Millisecond}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	reply, err := s.Do(ctx, typed.Command{Op: typed.LPop, Key: "queue", Timeout: time.Minute})
+	require.NoError(t, err)
+	require.Equal(t, "job1", string(reply.Value))
+}
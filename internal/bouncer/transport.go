@@ -0,0 +1,86 @@
+package bouncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/wire"
+)
+
+const (
+	TransportHTTP   = "http"
+	TransportBinary = "binary"
+)
+
+var (
+	ErrUnknownTransport error = errors.New("unknown transport, expected http or binary")
+	ErrEmptyAddr        error = errors.New("addr cannot be empty")
+)
+
+// Transport describes how shard sends get, set, delete and batch operations to storage.
+// Binary transport sends them over persistent connections to Addr, the rest
+// operations and requests which cant be sent over binary transport go over http.
+type Transport struct {
+	Kind string
+	// Addr is address of binary listener of keeper.
+	Addr string
+	// Conns and MaxInFlight are the same as in wire.ClientOptions.
+	Conns       int
+	MaxInFlight int
+}
+
+func (t Transport) Validate() error {
+	switch t.Kind {
+	case "", TransportHTTP:
+		return nil
+	case TransportBinary:
+		if t.Addr == "" {
+			return fmt.Errorf("binary transport: %w", ErrEmptyAddr)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownTransport, t.Kind)
+	}
+}
+
+func newBinaryClient(t Transport, timeouts Timeouts) *wire.Client {
+	if t.Kind != TransportBinary || t.Addr == "" {
+		return nil
+	}
+	return wire.NewClient(t.Addr, wire.ClientOptions{
+		Conns:       t.Conns,
+		MaxInFlight: t.MaxInFlight,
+		DialTimeout: timeouts.Connect,
+	})
+}
+
+// sendBinary sends request over binary transport, sent is false when request
+// should be sent over http instead. Request which could be applied by storage
+// before connection was lost is sent again only if it is idempotent.
+func (s *Shard) sendBinary(ctx context.Context, req wire.Request, idempotent bool) (resp wire.Response, sent bool, err error) {
+	if s.bin == nil {
+		return wire.Response{}, false, nil
+	}
+
+	resp, err = s.bin.Do(ctx, req)
+	switch {
+	case err == nil:
+		return resp, true, statusError(resp.Status, resp.Msg)
+	case errors.Is(err, wire.ErrUnavailable), errors.Is(err, wire.ErrConnLost) && idempotent:
+		slog.Debug(fmt.Sprintf("%s of %q falls back to http: %v", req.Op, s.addr, err))
+		return wire.Response{}, false, nil
+	default:
+		return wire.Response{}, true, err
+	}
+}
+
+func entryFromWire(resp wire.Response, start time.Time) Entry {
+	entry := Entry{Value: resp.Value, Version: resp.Version, CreatedAt: resp.CreatedAt, UpdatedAt: resp.UpdatedAt, Meta: resp.Meta}
+	if resp.TTL >= 0 {
+		entry.ExpiresAt = start.Add(resp.TTL)
+	}
+	return entry
+}
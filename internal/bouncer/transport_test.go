@@ -0,0 +1,165 @@
+package bouncer
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/aosderzhikov/sticky/internal/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func startWireServer(t *testing.T, h wire.HandlerFunc) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := wire.NewServer(h, 0)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return l.Addr().String()
+}
+
+// startLosingServer reads request and closes connection without response.
+func startLosingServer(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			buf := make([]byte, 64)
+			_, _ = conn.Read(buf)
+			_ = conn.Close()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func closedAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return addr
+}
+
+func TestShardBinaryTransport(t *testing.T) {
+	addr := startWireServer(t, func(_ context.Context, req wire.Request) wire.Response {
+		switch req.Op {
+		case wire.OpGet:
+			if req.Key == "absent" {
+				return wire.ErrorResponse(http.StatusNotFound, handler.ErrKeyNotFound)
+			}
+			resp := wire.OKResponse()
+			resp.Value = []byte("value")
+			resp.TTL = time.Minute
+			resp.Version = 3
+			return resp
+		case wire.OpMGet:
+			resp := wire.OKResponse()
+			resp.Results = []batch.Result{{Key: "a", Status: batch.StatusOK, Value: []byte("1")}}
+			return resp
+		default:
+			return wire.OKResponse()
+		}
+	})
+
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected http request %s", r.URL)
+	}, ShardOptions{Transport: Transport{Kind: TransportBinary, Addr: addr}})
+	ctx := context.Background()
+
+	entry, err := s.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), entry.Value)
+	require.Equal(t, uint64(3), entry.Version)
+	require.WithinDuration(t, time.Now().Add(time.Minute), entry.ExpiresAt, time.Second)
+
+	_, err = s.Get(ctx, "absent")
+	require.ErrorIs(t, err, ErrKeyNotExist)
+
+	require.NoError(t, s.Set(ctx, "key", []byte("value"), time.Minute, meta.Meta{}))
+	require.NoError(t, s.Delete(ctx, "key"))
+
+	results, err := s.MGet(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), results[0].Value)
+}
+
+func TestShardBinaryFallback(t *testing.T) {
+	tests := []struct {
+		name      string
+		addr      func(t *testing.T) string
+		op        func(ctx context.Context, s *Shard) error
+		httpCalls int32
+		err       error
+	}{
+		{
+			name: "unavailable get",
+			addr: closedAddr,
+			op: func(ctx context.Context, s *Shard) error {
+				_, err := s.Get(ctx, "key")
+				return err
+			},
+			httpCalls: 1,
+		},
+		{
+			name: "unavailable set",
+			addr: closedAddr,
+			op: func(ctx context.Context, s *Shard) error {
+				return s.Set(ctx, "key", []byte("value"), 0, meta.Meta{})
+			},
+			httpCalls: 1,
+		},
+		{
+			name: "lost delete",
+			addr: startLosingServer,
+			op: func(ctx context.Context, s *Shard) error {
+				return s.Delete(ctx, "key")
+			},
+			httpCalls: 1,
+		},
+		{
+			name: "lost set isnt resent",
+			addr: startLosingServer,
+			op: func(ctx context.Context, s *Shard) error {
+				return s.Set(ctx, "key", []byte("value"), 0, meta.Meta{})
+			},
+			err: wire.ErrConnLost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+			}, ShardOptions{Transport: Transport{Kind: TransportBinary, Addr: tt.addr(t)}})
+
+			err := tt.op(context.Background(), s)
+			if tt.err != nil {
+				require.ErrorIs(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.httpCalls, calls.Load())
+		})
+	}
+}
+
+func TestTransportValidate(t *testing.T) {
+	require.NoError(t, Transport{}.Validate())
+	require.NoError(t, Transport{Kind: TransportHTTP}.Validate())
+	require.NoError(t, Transport{Kind: TransportBinary, Addr: "localhost:9181"}.Validate())
+	require.ErrorIs(t, Transport{Kind: TransportBinary}.Validate(), ErrEmptyAddr)
+	require.ErrorIs(t, Transport{Kind: "grpc"}.Validate(), ErrUnknownTransport)
+}
@@ -0,0 +1,77 @@
+package bouncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aosderzhikov/sticky/internal/tx"
+)
+
+var ErrCrossShard error = errors.New("keys of transaction belong to different shards, use the same hash tag in all keys, e.g. {user42}:profile")
+
+// Exec routes transaction to single storage, all its keys must share the same hash tag.
+func (b *ShardService) Exec(ctx context.Context, t tx.Tx) (tx.Response, error) {
+	keys := t.Keys()
+	defer func() {
+		for _, key := range keys {
+			b.cache.invalidate(key)
+		}
+	}()
+
+	i, err := b.txPlacement(keys)
+	if err != nil {
+		return tx.Response{}, err
+	}
+
+	resp, err := b.storages[i].Exec(ctx, t)
+	if err != nil || !resp.Committed {
+		return resp, err
+	}
+
+	for _, op := range t.Ops {
+		switch op.Op {
+		case tx.OpSet:
+			b.setStorageIndex(op.Key, i)
+		case tx.OpDelete:
+			b.deletStorageIndex(op.Key)
+		}
+	}
+	return resp, nil
+}
+
+// txPlacement returns storage which already has keys of transaction
+// or storage selected the same way as for any of its keys.
+func (b *ShardService) txPlacement(keys []string) (int, error) {
+	tag := routingKey(keys[0])
+	for _, key := range keys[1:] {
+		if routingKey(key) != tag {
+			return 0, ErrCrossShard
+		}
+	}
+
+	storage := -1
+	for _, key := range keys {
+		i, exist := b.isExist(key)
+		if !exist {
+			continue
+		}
+		if storage != -1 && storage != i {
+			return 0, ErrCrossShard
+		}
+		storage = i
+	}
+
+	if storage == -1 {
+		i, ok := b.placement(keys[0])
+		if !ok {
+			return 0, ErrAllStorage
+		}
+		return i, nil
+	}
+
+	if s := b.storages[storage]; !s.IsAlive() {
+		return 0, fmt.Errorf("storage %q isnt alive", s.Addr())
+	}
+	return storage, nil
+}
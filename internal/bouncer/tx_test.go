@@ -0,0 +1,75 @@
+package bouncer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aosderzhikov/sticky/internal/tx"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRoutingKey(t *testing.T) {
+	cases := map[string]string{
+		"key1":               "key1",
+		"{user42}:profile":   "user42",
+		"profile:{user42}":   "user42",
+		"{user42}:{user43}":  "user42",
+		"{}:profile":         "{}:profile",
+		"{user42:profile":    "{user42:profile",
+		"user42}:{profile}":  "profile",
+		"{{user42}}:profile": "{user42",
+	}
+	for key, want := range cases {
+		require.Equal(t, want, routingKey(key), key)
+	}
+}
+
+func TestExecCrossShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage1 := NewMockStorage(ctrl)
+	storage1.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage2 := NewMockStorage(ctrl)
+	storage2.EXPECT().IsAlive().Return(true).AnyTimes()
+
+	s := NewShardService([]Storage{storage1, storage2}, ServiceOptions{})
+
+	_, err := s.Exec(context.Background(), tx.Tx{Ops: []tx.Op{
+		{Op: tx.OpSet, Key: "{user42}:profile"},
+		{Op: tx.OpSet, Key: "{user43}:profile"},
+	}})
+	require.ErrorIs(t, err, ErrCrossShard)
+
+	s.setStorageIndex("{user42}:profile", 0)
+	s.setStorageIndex("{user42}:email", 1)
+	_, err = s.Exec(context.Background(), tx.Tx{Ops: []tx.Op{
+		{Op: tx.OpSet, Key: "{user42}:profile"},
+		{Op: tx.OpSet, Key: "{user42}:email"},
+	}})
+	require.ErrorIs(t, err, ErrCrossShard)
+}
+
+func TestExecUpdatesIndex(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage1 := NewMockStorage(ctrl)
+	storage1.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage2 := NewMockStorage(ctrl)
+	storage2.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage2.EXPECT().Exec(gomock.Any(), gomock.Any()).Return(tx.Response{Committed: true}, nil)
+
+	s := NewShardService([]Storage{storage1, storage2}, ServiceOptions{})
+	s.setStorageIndex("{user42}:email", 1)
+
+	resp, err := s.Exec(context.Background(), tx.Tx{Ops: []tx.Op{
+		{Op: tx.OpSet, Key: "{user42}:profile"},
+		{Op: tx.OpDelete, Key: "{user42}:email"},
+	}})
+	require.NoError(t, err)
+	require.True(t, resp.Committed)
+
+	i, exist := s.isExist("{user42}:profile")
+	require.True(t, exist)
+	require.Equal(t, 1, i)
+	_, exist = s.isExist("{user42}:email")
+	require.False(t, exist)
+}
@@ -0,0 +1,43 @@
+package bouncer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aosderzhikov/sticky/internal/typed"
+)
+
+// Do routes command on typed value by key the same way as plain values are routed.
+// Writes dont fallback to other storages, so typed value never splits between them.
+func (b *ShardService) Do(ctx context.Context, cmd typed.Command) (typed.Reply, error) {
+	if cmd.Op.ReadOnly() {
+		i, ok := b.isExist(cmd.Key)
+		if !ok {
+			return typed.Reply{}, ErrKeyNotExist
+		}
+
+		s := b.storages[i]
+		if !s.IsAlive() {
+			return typed.Reply{}, fmt.Errorf("storage %q isnt alive", s.Addr())
+		}
+		return s.Do(ctx, cmd)
+	}
+
+	s, i, err := b.ownerStorage(cmd.Key)
+	if err != nil {
+		return typed.Reply{}, err
+	}
+	defer b.cache.invalidate(cmd.Key)
+
+	reply, err := s.Do(ctx, cmd)
+	if err != nil {
+		return typed.Reply{}, err
+	}
+
+	if reply.Exists {
+		b.setStorageIndex(cmd.Key, i)
+	} else {
+		b.deletStorageIndex(cmd.Key)
+	}
+	return reply, nil
+}
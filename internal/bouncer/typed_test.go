@@ -0,0 +1,38 @@
+package bouncer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestDoRoutesByKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage1 := NewMockStorage(ctrl)
+	storage1.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage2 := NewMockStorage(ctrl)
+	storage2.EXPECT().IsAlive().Return(true).AnyTimes()
+
+	s := NewShardService([]Storage{storage1, storage2}, ServiceOptions{})
+
+	_, err := s.Do(context.Background(), typed.Command{Op: typed.LRange, Key: "queue"})
+	require.ErrorIs(t, err, ErrKeyNotExist)
+
+	s.setStorageIndex("queue", 1)
+	push := typed.Command{Op: typed.RPush, Key: "queue", Value: []byte("job")}
+	storage2.EXPECT().Do(gomock.Any(), push).Return(typed.Reply{Count: 1, Exists: true}, nil)
+	_, err = s.Do(context.Background(), push)
+	require.NoError(t, err)
+
+	pop := typed.Command{Op: typed.LPop, Key: "queue"}
+	storage2.EXPECT().Do(gomock.Any(), pop).Return(typed.Reply{Value: []byte("job")}, nil)
+	reply, err := s.Do(context.Background(), pop)
+	require.NoError(t, err)
+	require.Equal(t, "job", string(reply.Value))
+
+	_, ok := s.isExist("queue")
+	require.False(t, ok, "removed list is kept in index")
+}
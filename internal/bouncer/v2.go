@@ -0,0 +1,73 @@
+package bouncer
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aosderzhikov/sticky/internal/handler"
+)
+
+// GetV2Handle responds with json envelope of entry read from its storage, HEAD request gets only its headers.
+func (h *Handler) GetV2Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, err := handler.ExtractKeyV2(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.s.GetEntry(ctx, key)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+	if handler.NotModified(r, entry.Version, entry.UpdatedAt) {
+		handler.WriteNotModified(w, entry.Version, entry.UpdatedAt)
+		return
+	}
+
+	handler.WriteEntryV2(w, r, handler.EntryV2{
+		Key:       key,
+		Value:     entry.Value,
+		Version:   entry.Version,
+		TTL:       handler.RemainingTTL(entry.ExpiresAt),
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+		Meta:      entry.Meta,
+	})
+}
+
+func (h *Handler) PutV2Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := handler.ExtractPutV2(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err = h.setValue(w, r, req.Key, req.TTL, req.Meta); err != nil {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteV2Handle responds with 204 whether key existed or not like keeper does, unless request has If-Match.
+func (h *Handler) DeleteV2Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, err := handler.ExtractKeyV2(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	err = h.deleteIfMatch(w, r, key)
+	if err != nil && !errors.Is(err, ErrKeyNotExist) {
+		handler.ErrorHandle(ctx, w, err, errorCode(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
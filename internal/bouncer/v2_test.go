@@ -0,0 +1,103 @@
+package bouncer
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// serveV2 serves request with headers passed as name and value pairs.
+func serveV2(s Service, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	h := NewHandler(s, HandlerOptions{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/keys/{key...}", h.GetV2Handle)
+	mux.HandleFunc("PUT /v2/keys/{key...}", h.PutV2Handle)
+	mux.HandleFunc("DELETE /v2/keys/{key...}", h.DeleteV2Handle)
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGetV2Handle(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	ctrl := gomock.NewController(t)
+	service := NewMockService(ctrl)
+	service.EXPECT().GetEntry(gomock.Any(), "user/1").Return(Entry{
+		Value:     []byte("value"),
+		Version:   3,
+		ExpiresAt: time.Now().Add(time.Minute),
+		CreatedAt: created,
+		UpdatedAt: updated,
+		Meta:      meta.Meta{ContentType: "application/json", ContentEncoding: "gzip", User: map[string]string{"Owner": "team-a"}},
+	}, nil).Times(2)
+	service.EXPECT().GetEntry(gomock.Any(), "absent").Return(Entry{}, ErrKeyNotExist)
+
+	rec := serveV2(service, http.MethodGet, "/v2/keys/user/1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var entry handler.EntryV2
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&entry))
+	require.Equal(t, "user/1", entry.Key)
+	require.Equal(t, []byte("value"), entry.Value)
+	require.Equal(t, uint64(3), entry.Version)
+	require.Equal(t, 5, entry.Size)
+	require.Equal(t, created, entry.CreatedAt)
+	require.Equal(t, updated, entry.UpdatedAt)
+	require.InDelta(t, time.Minute, time.Duration(*entry.TTL), float64(time.Second))
+	require.Equal(t, "application/json", entry.ContentType)
+	require.Equal(t, "gzip", entry.ContentEncoding)
+	require.Equal(t, map[string]string{"Owner": "team-a"}, entry.User)
+
+	rec = serveV2(service, http.MethodHead, "/v2/keys/user/1", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, rec.Body.Bytes())
+	require.Equal(t, "3", rec.Header().Get(handler.VersionHeader))
+	require.Equal(t, updated, handler.ExtractTimeHeader(rec.Header(), handler.UpdatedAtHeader))
+	require.Equal(t, "application/json", rec.Header().Get(handler.ContentTypeHeader))
+	require.Equal(t, "gzip", rec.Header().Get(handler.ContentEncodingHeader))
+	require.Equal(t, "team-a", rec.Header().Get("X-Sticky-Meta-Owner"))
+
+	rec = serveV2(service, http.MethodGet, "/v2/keys/absent", "")
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestPutV2Handle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := NewMockService(ctrl)
+	m := meta.Meta{ContentType: "text/csv", User: map[string]string{"Source": "import"}}
+	service.EXPECT().SetWithMeta(gomock.Any(), "key", []byte("value"), 5*time.Second, m).Return(nil)
+
+	rec := serveV2(service, http.MethodPut, "/v2/keys/key?ttl=5s", "value", "Content-Type", "text/csv", "X-Sticky-Meta-Source", "import")
+	require.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = serveV2(service, http.MethodPut, "/v2/keys/key?ttl=abc", "value")
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDeleteV2Handle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := NewMockService(ctrl)
+	service.EXPECT().Delete(gomock.Any(), "key").Return(nil)
+	service.EXPECT().Delete(gomock.Any(), "absent").Return(ErrKeyNotExist)
+	service.EXPECT().Delete(gomock.Any(), "down").Return(errors.New("storage isnt alive"))
+
+	require.Equal(t, http.StatusNoContent, serveV2(service, http.MethodDelete, "/v2/keys/key", "").Code)
+	require.Equal(t, http.StatusNoContent, serveV2(service, http.MethodDelete, "/v2/keys/absent", "").Code)
+	require.Equal(t, http.StatusInternalServerError, serveV2(service, http.MethodDelete, "/v2/keys/down", "").Code)
+}
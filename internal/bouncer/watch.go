@@ -0,0 +1,74 @@
+package bouncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+var ErrNotModified error = errors.New("key isnt changed")
+
+const rewatchInterval = 100 * time.Millisecond
+
+// Watch proxies watch of key to storage owning it. If storage fails, watch is established again
+// on storage owning the key then, so client waits until timeout regardless of failover.
+func (b *ShardService) Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (Entry, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		remaining := time.Until(deadline(ctx))
+		if remaining <= 0 {
+			return Entry{}, ErrNotModified
+		}
+
+		i, err := b.watchStorage(key)
+		if err == nil {
+			var entry Entry
+			entry, err = b.storages[i].Watch(ctx, key, sinceVersion, remaining)
+			switch {
+			case err == nil:
+				b.setStorageIndex(key, i)
+				return entry, nil
+			case errors.Is(err, ErrKeyNotExist):
+				b.deletStorageIndex(key)
+				return Entry{}, err
+			case errors.Is(err, ErrNotModified):
+				return Entry{}, err
+			}
+		}
+
+		if ctx.Err() != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return Entry{}, ErrNotModified
+			}
+			return Entry{}, ctx.Err()
+		}
+		slog.ErrorContext(ctx, fmt.Sprintf("watch key %q failed, it will be established again: %v", key, err))
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(rewatchInterval):
+		}
+	}
+}
+
+// watchStorage returns alive storage owning the key or selected for new key.
+func (b *ShardService) watchStorage(key string) (int, error) {
+	if i, exist := b.isExist(key); exist && b.storages[i].IsAlive() {
+		return i, nil
+	}
+
+	i, ok := b.placement(key)
+	if !ok {
+		return 0, ErrAllStorage
+	}
+	return i, nil
+}
+
+func deadline(ctx context.Context) time.Time {
+	d, _ := ctx.Deadline()
+	return d
+}
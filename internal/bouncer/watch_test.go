@@ -0,0 +1,75 @@
+package bouncer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestWatchReestablishedAfterFailover(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage1 := NewMockStorage(ctrl)
+	storage2 := NewMockStorage(ctrl)
+	storage2.EXPECT().IsAlive().Return(true).AnyTimes()
+
+	gomock.InOrder(
+		storage1.EXPECT().IsAlive().Return(true),
+		storage1.EXPECT().Watch(gomock.Any(), "flag", uint64(3), gomock.Any()).Return(Entry{}, errors.New("connection reset")),
+		storage1.EXPECT().IsAlive().Return(false).AnyTimes(),
+	)
+	storage2.EXPECT().Watch(gomock.Any(), "flag", uint64(3), gomock.Any()).Return(Entry{Value: []byte("off"), Version: 1}, nil)
+
+	s := NewShardService([]Storage{storage1, storage2}, ServiceOptions{})
+	s.setStorageIndex("flag", 0)
+
+	entry, err := s.Watch(context.Background(), "flag", 3, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "off", string(entry.Value))
+
+	i, ok := s.isExist("flag")
+	require.True(t, ok)
+	require.Equal(t, 1, i)
+}
+
+func TestWatchTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	storage := NewMockStorage(ctrl)
+	storage.EXPECT().IsAlive().Return(true).AnyTimes()
+	storage.EXPECT().Watch(gomock.Any(), "flag", uint64(3), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ string, _ uint64, _ time.Duration) (Entry, error) {
+			<-ctx.Done()
+			return Entry{}, ctx.Err()
+		})
+
+	s := NewShardService([]Storage{storage}, ServiceOptions{})
+	s.setStorageIndex("flag", 0)
+
+	_, err := s.Watch(context.Background(), "flag", 3, 20*time.Millisecond)
+	require.ErrorIs(t, err, ErrNotModified)
+}
+
+func TestShardWatch(t *testing.T) {
+	s := newTestShard(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("sinceVersion") == "7" {
+			handler.PutVersionHeader(w, 8)
+			meta.Meta{}.Put(w.Header())
+			_, _ = w.Write([]byte("off"))
+			return
+		}
+		w.WriteHeader(http.StatusNotModified)
+	}, ShardOptions{})
+
+	entry, err := s.Watch(context.Background(), "flag", 7, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, Entry{Value: []byte("off"), Version: 8}, entry)
+
+	_, err = s.Watch(context.Background(), "flag", 8, time.Second)
+	require.ErrorIs(t, err, ErrNotModified)
+}
@@ -0,0 +1,234 @@
+package events
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Op string
+
+const (
+	OpSet     Op = "set"
+	OpDelete  Op = "delete"
+	OpExpired Op = "expired"
+	// OpEvicted is for keys removed because of memory limit. Keeper has no memory limit
+	// and doesnt evict keys, so it is never sent.
+	OpEvicted Op = "evicted"
+	// OpMessage is a message published to channel.
+	OpMessage Op = "message"
+	// OpGap is sent by bouncer when events of some storage could be lost, e.g. it was down,
+	// Data describes reason. Client should read keys it follows again.
+	OpGap Op = "gap"
+)
+
+// Event is a change of key or a message published to channel.
+type Event struct {
+	Op      Op        `json:"op"`
+	Key     string    `json:"key,omitempty"`
+	Channel string    `json:"channel,omitempty"`
+	Data    []byte    `json:"data,omitempty"`
+	Version uint64    `json:"version,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Filter selects events of subscriber. Pattern is a glob with * and ? matched against key,
+// or against channel if Channels is true.
+type Filter struct {
+	Pattern  string
+	Channels bool
+}
+
+func (f Filter) Match(e Event) bool {
+	name := e.Key
+	if f.Channels {
+		if e.Op != OpMessage {
+			return false
+		}
+		name = e.Channel
+	} else if e.Op == OpMessage {
+		return false
+	}
+
+	return Match(f.Pattern, name)
+}
+
+// Match reports whether name matches glob pattern, where * matches any sequence
+// of characters, ? matches any single character and \ escapes the next character.
+func Match(pattern, name string) bool {
+	// star is position in pattern after the last *, next is position in name it is tried from
+	star, next := -1, 0
+	p, n := 0, 0
+	for n < len(name) {
+		if p < len(pattern) {
+			switch c := pattern[p]; {
+			case c == '*':
+				star, next = p+1, n
+				p++
+				continue
+			case c == '?':
+				p++
+				n++
+				continue
+			case c == '\\' && p+1 < len(pattern) && pattern[p+1] == name[n]:
+				p += 2
+				n++
+				continue
+			case c != '\\' && c == name[n]:
+				p++
+				n++
+				continue
+			}
+		}
+		if star == -1 {
+			return false
+		}
+		next++
+		p, n = star, next
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// ExactPattern returns pattern which matches only name.
+func ExactPattern(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		switch r {
+		case '*', '?', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (f Filter) Validate() error {
+	if strings.HasSuffix(strings.ReplaceAll(f.Pattern, `\\`, ""), `\`) {
+		return ErrInvalidPattern
+	}
+	return nil
+}
+
+// Policy is what broker does with subscriber whose buffer is full.
+type Policy string
+
+const (
+	// Disconnect closes subscriber, client has to subscribe again and can detect loss of events.
+	Disconnect Policy = "disconnect"
+	// DropOldest drops the oldest buffered event to make room for the new one.
+	DropOldest Policy = "drop_oldest"
+)
+
+type Options struct {
+	BufferSize int
+	Policy     Policy
+}
+
+const DefaultBufferSize = 256
+
+func (o Options) withDefaults() Options {
+	if o.BufferSize <= 0 {
+		o.BufferSize = DefaultBufferSize
+	}
+	if o.Policy == "" {
+		o.Policy = Disconnect
+	}
+	return o
+}
+
+var (
+	ErrInvalidPattern error = errors.New("invalid pattern")
+	ErrSlowSubscriber error = errors.New("subscriber is disconnected because it is too slow")
+)
+
+func NewBroker(opts Options) *Broker {
+	return &Broker{
+		opts: opts.withDefaults(),
+		subs: make(map[*Subscriber]struct{}),
+	}
+}
+
+// Broker delivers events to subscribers, publishing never blocks on slow subscriber.
+type Broker struct {
+	opts Options
+
+	mu   sync.Mutex
+	subs map[*Subscriber]struct{}
+}
+
+type Subscriber struct {
+	filter Filter
+	events chan Event
+	// err is set before events is closed by broker.
+	err error
+}
+
+// Events returns channel of events, it is closed when subscriber is disconnected as slow.
+func (s *Subscriber) Events() <-chan Event {
+	return s.events
+}
+
+// Err returns reason of disconnect, it is valid after events channel is closed.
+func (s *Subscriber) Err() error {
+	return s.err
+}
+
+func (b *Broker) Subscribe(f Filter) *Subscriber {
+	s := &Subscriber{filter: f, events: make(chan Event, b.opts.BufferSize)}
+
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	return s
+}
+
+func (b *Broker) Unsubscribe(s *Subscriber) {
+	b.mu.Lock()
+	delete(b.subs, s)
+	b.mu.Unlock()
+}
+
+// Publish sends event to matched subscribers and returns their number.
+func (b *Broker) Publish(e Event) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delivered := 0
+	for s := range b.subs {
+		if !s.filter.Match(e) {
+			continue
+		}
+		if b.send(s, e) {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+func (b *Broker) send(s *Subscriber, e Event) bool {
+	select {
+	case s.events <- e:
+		return true
+	default:
+	}
+
+	if b.opts.Policy == DropOldest {
+		// only broker sends to events under lock, so after receive there is room for event
+		select {
+		case <-s.events:
+		default:
+		}
+		s.events <- e
+		return true
+	}
+
+	delete(b.subs, s)
+	s.err = ErrSlowSubscriber
+	close(s.events)
+	return false
+}
@@ -0,0 +1,100 @@
+package events
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter Filter
+		event  Event
+		want   bool
+	}{
+		{"key by pattern", Filter{Pattern: "user:*"}, Event{Op: OpSet, Key: "user:42"}, true},
+		{"key not matched", Filter{Pattern: "user:*"}, Event{Op: OpSet, Key: "order:42"}, false},
+		{"message isnt keyspace event", Filter{Pattern: "*"}, Event{Op: OpMessage, Channel: "news"}, false},
+		{"channel by pattern", Filter{Pattern: "news.*", Channels: true}, Event{Op: OpMessage, Channel: "news.sport"}, true},
+		{"keyspace event isnt message", Filter{Pattern: "*", Channels: true}, Event{Op: OpDelete, Key: "news"}, false},
+		{"star matches slash", Filter{Pattern: "config/*"}, Event{Op: OpSet, Key: "config/app/flags"}, true},
+		{"question mark", Filter{Pattern: "user:?"}, Event{Op: OpSet, Key: "user:42"}, false},
+		{"several stars", Filter{Pattern: "*:*:end"}, Event{Op: OpSet, Key: "a:b:c:end"}, true},
+		{"exact pattern", Filter{Pattern: ExactPattern("flag*?")}, Event{Op: OpSet, Key: "flag*?"}, true},
+		{"exact pattern isnt glob", Filter{Pattern: ExactPattern("flag*")}, Event{Op: OpSet, Key: "flag1"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.filter.Match(tt.event))
+		})
+	}
+
+	require.ErrorIs(t, Filter{Pattern: `user\`}.Validate(), ErrInvalidPattern)
+	require.NoError(t, Filter{Pattern: `user\\`}.Validate())
+}
+
+func TestBrokerDisconnectSlowSubscriber(t *testing.T) {
+	b := NewBroker(Options{BufferSize: 2})
+	slow := b.Subscribe(Filter{Pattern: "*"})
+	other := b.Subscribe(Filter{Pattern: "other"})
+
+	for i := 0; i < 3; i++ {
+		b.Publish(Event{Op: OpSet, Key: "key1"})
+	}
+
+	require.Len(t, slow.Events(), 2)
+	<-slow.Events()
+	<-slow.Events()
+	_, ok := <-slow.Events()
+	require.False(t, ok, "slow subscriber isnt disconnected")
+	require.ErrorIs(t, slow.Err(), ErrSlowSubscriber)
+
+	require.Equal(t, 1, b.Publish(Event{Op: OpSet, Key: "other"}))
+	require.NoError(t, other.Err())
+}
+
+func TestBrokerDropOldest(t *testing.T) {
+	b := NewBroker(Options{BufferSize: 2, Policy: DropOldest})
+	s := b.Subscribe(Filter{Pattern: "*"})
+
+	for _, key := range []string{"key1", "key2", "key3"} {
+		b.Publish(Event{Op: OpSet, Key: key})
+	}
+
+	require.Equal(t, "key2", (<-s.Events()).Key)
+	require.Equal(t, "key3", (<-s.Events()).Key)
+	require.NoError(t, s.Err())
+}
+
+func TestStream(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	sent := []Event{
+		{Op: OpSet, Key: "key1", Version: 1, Time: now},
+		{Op: OpMessage, Channel: "news", Data: []byte("hello"), Time: now},
+	}
+
+	var buf bytes.Buffer
+	for _, e := range sent {
+		require.NoError(t, WriteEvent(&buf, e))
+	}
+	require.NoError(t, WriteKeepAlive(&buf))
+	require.NoError(t, WriteError(&buf, ErrSlowSubscriber))
+
+	var received []Event
+	err := ReadStream(&buf, func(e Event) error {
+		received = append(received, e)
+		return nil
+	})
+	require.ErrorIs(t, err, ErrStreamClosed)
+	require.Equal(t, sent, received)
+
+	stop := errors.New("stop")
+	buf.Reset()
+	require.NoError(t, WriteEvent(&buf, sent[0]))
+	require.ErrorIs(t, ReadStream(&buf, func(Event) error { return stop }), stop)
+}
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Events are streamed as Server-Sent Events, name of event is operation and data is json of event.
+
+const errorEvent = "error"
+
+var ErrStreamClosed error = errors.New("event stream is closed by server")
+
+func WriteEvent(w io.Writer, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Op, data)
+	return err
+}
+
+// WriteError writes the last event of stream with reason why stream is closed.
+func WriteError(w io.Writer, err error) error {
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", errorEvent, strings.ReplaceAll(err.Error(), "\n", " "))
+	return err
+}
+
+// WriteKeepAlive writes comment, so proxies dont close idle stream.
+func WriteKeepAlive(w io.Writer) error {
+	_, err := io.WriteString(w, ":\n\n")
+	return err
+}
+
+// ReadStream calls fn for each event of stream until stream ends or fn returns error.
+func ReadStream(r io.Reader, fn func(Event) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	var name, data string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if data == "" {
+				continue
+			}
+			if name == errorEvent {
+				return fmt.Errorf("%w: %s", ErrStreamClosed, data)
+			}
+
+			var e Event
+			if err := json.Unmarshal([]byte(data), &e); err != nil {
+				return err
+			}
+			if err := fn(e); err != nil {
+				return err
+			}
+			name, data = "", ""
+		case strings.HasPrefix(line, "event:"):
+			name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
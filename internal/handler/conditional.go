@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrPreconditionFailed error = errors.New("precondition failed")
+
+// ETag is strong entity tag of value, value gets new version on each set, so version identifies it.
+func ETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+// GzipETag is strong entity tag of value sent with gzip Content-Encoding. It differs from ETag,
+// as bytes of the same version differ in both representations and caches mustnt mix them.
+func GzipETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `-gzip"`
+}
+
+// PutValidators puts ETag and Last-Modified of value, they arent put if storage didnt report them.
+func PutValidators(w http.ResponseWriter, version uint64, updatedAt time.Time) {
+	etag := ""
+	if version != 0 {
+		etag = ETag(version)
+	}
+	PutETagValidators(w, etag, updatedAt)
+}
+
+// PutETagValidators is PutValidators with ETag of representation, empty etag isnt put.
+func PutETagValidators(w http.ResponseWriter, etag string, updatedAt time.Time) {
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	if !updatedAt.IsZero() {
+		w.Header().Set("Last-Modified", updatedAt.UTC().Format(http.TimeFormat))
+	}
+}
+
+// NotModified reports whether client already has the value, so it should get 304.
+// If-Modified-Since is ignored when If-None-Match is present, as standard requires.
+func NotModified(r *http.Request, version uint64, updatedAt time.Time) bool {
+	return NotModifiedETag(r, ETag(version), updatedAt)
+}
+
+// NotModifiedETag is NotModified with ETag of representation sent to client.
+func NotModifiedETag(r *http.Request, etag string, updatedAt time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		// weak comparison is used for If-None-Match
+		return matchETag(inm, true, etag)
+	}
+
+	ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || updatedAt.IsZero() {
+		return false
+	}
+	// Last-Modified has seconds precision
+	return !updatedAt.Truncate(time.Second).After(ims)
+}
+
+// WriteNotModified responds with 304 and validators of value.
+func WriteNotModified(w http.ResponseWriter, version uint64, updatedAt time.Time) {
+	PutValidators(w, version, updatedAt)
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// WriteNotModifiedETag is WriteNotModified with ETag of representation.
+func WriteNotModifiedETag(w http.ResponseWriter, etag string, updatedAt time.Time) {
+	PutETagValidators(w, etag, updatedAt)
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// IfMatch is list of ETags from If-Match header, write holds if value exists and its ETag
+// is in the list, "*" matches any existing value.
+type IfMatch string
+
+// ExtractIfMatch returns If-Match of request, it is false if header is absent.
+func ExtractIfMatch(r *http.Request) (IfMatch, bool) {
+	v := strings.TrimSpace(r.Header.Get("If-Match"))
+	return IfMatch(v), v != ""
+}
+
+// Matches uses strong comparison, so weak tags never match. Write applies to value whatever
+// representation client got, so ETag of gzip representation matches too.
+func (m IfMatch) Matches(version uint64) bool {
+	return matchETag(string(m), false, ETag(version), GzipETag(version))
+}
+
+// matchETag reports whether comma separated list of tags has one of wanted tags or is "*".
+func matchETag(list string, weak bool, want ...string) bool {
+	for _, tag := range strings.Split(list, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if slices.Contains(want, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -5,15 +5,70 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/lease"
+	"github.com/aosderzhikov/sticky/internal/typed"
 )
 
 var (
 	ErrEmptyParam   error = errors.New("key query param cannot be empty")
 	ErrInvalidParam error = errors.New("invalid ttl query param")
+	ErrInvalidBy    error = errors.New("invalid by query param")
+	ErrEmptyOwner   error = errors.New("owner query param cannot be empty")
+	ErrInvalidWait  error = errors.New("invalid wait query param")
+	ErrEmptyID      error = errors.New("id query param cannot be empty")
+	ErrEmptyChannel error = errors.New("channel query param cannot be empty")
+	ErrFilter       error = errors.New("only one of pattern and channel query params can be passed")
 
 	ErrBodyRead error = errors.New("cant read value from body")
+
+	ErrKeyNotFound  error = errors.New("key not found")
+	ErrNotPersisted error = errors.New("key not found or already doesnt expire")
 )
 
+// ValueErrorCode returns status code for error of value extraction.
+func ValueErrorCode(err error) int {
+	switch {
+	case errors.Is(err, ErrValueTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUploadIdle):
+		return http.StatusRequestTimeout
+	case errors.Is(err, ErrBodyRead):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// BatchErrorCode returns status code for error of batch request extraction.
+func BatchErrorCode(err error) int {
+	if errors.Is(err, batch.ErrUnsupportedContentType) {
+		return http.StatusUnsupportedMediaType
+	}
+	return http.StatusBadRequest
+}
+
+// LeaseErrorCode returns 409 for lease conflicts.
+func LeaseErrorCode(err error) int {
+	if errors.Is(err, lease.ErrLocked) || errors.Is(err, lease.ErrNotOwner) {
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// TypedErrorCode returns 404 if key or element isnt found and 409 for wrong type of value.
+func TypedErrorCode(err error) int {
+	switch {
+	case errors.Is(err, typed.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, typed.ErrWrongType):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func ErrorHandle(ctx context.Context, w http.ResponseWriter, err error, code int) {
 	errText := err.Error()
 	slog.ErrorContext(ctx, errText)
@@ -1,33 +1,291 @@
 package handler
 
 import (
+	"encoding/json"
 	"errors"
+	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/aosderzhikov/sticky/internal/ratelimit"
+	"github.com/aosderzhikov/sticky/internal/tx"
+	"github.com/aosderzhikov/sticky/internal/typed"
 )
 
 const (
 	keyParam = "key"
 	ttlParam = "ttl"
+	byParam  = "by"
+
+	limitParam     = "limit"
+	windowParam    = "window"
+	costParam      = "cost"
+	algorithmParam = "algorithm"
+
+	patternParam = "pattern"
+	channelParam = "channel"
+
+	sinceVersionParam = "sinceVersion"
+	timeoutParam      = "timeout"
+
+	ownerParam = "owner"
+	waitParam  = "wait"
+
+	// TTLHeader carries remaining ttl of entry in get responses.
+	TTLHeader = "X-Sticky-TTL"
+	// VersionHeader carries version of entry in get responses.
+	VersionHeader = "X-Sticky-Version"
 )
 
+func PutVersionHeader(w http.ResponseWriter, version uint64) {
+	w.Header().Set(VersionHeader, strconv.FormatUint(version, 10))
+}
+
+func PutTTLHeader(w http.ResponseWriter, ttl time.Duration) {
+	w.Header().Set(TTLHeader, ttl.Round(time.Millisecond).String())
+}
+
+// ExtractTTLHeader returns remaining ttl of entry, false if header is absent or invalid.
+func ExtractTTLHeader(h http.Header) (time.Duration, bool) {
+	ttl, err := time.ParseDuration(h.Get(TTLHeader))
+	if err != nil {
+		return 0, false
+	}
+	return ttl, true
+}
+
 func ExtractKeyAndTTL(r *http.Request) (key string, ttl time.Duration, err error) {
 	key, err = ExtractKey(r)
 	if err != nil {
 		return "", 0, err
 	}
 
-	query := r.URL.Query()
-	ttlStr := query.Get("ttl")
+	ttl, err = extractTTL(r)
+	if err != nil {
+		return "", 0, err
+	}
+	return key, ttl, nil
+}
+
+func extractTTL(r *http.Request) (time.Duration, error) {
+	ttlStr := r.URL.Query().Get(ttlParam)
 	if ttlStr == "" {
-		ttlStr = "0"
+		return 0, nil
 	}
-	ttl, err = time.ParseDuration(ttlStr)
+	ttl, err := time.ParseDuration(ttlStr)
 	if err != nil {
-		return "", 0, errors.Join(ErrInvalidParam, err)
+		return 0, errors.Join(ErrInvalidParam, err)
 	}
+	return ttl, nil
+}
 
-	return key, ttl, nil
+// ExtractBy returns integer delta of counter, 1 by default.
+func ExtractBy(r *http.Request) (int64, error) {
+	byStr := r.URL.Query().Get(byParam)
+	if byStr == "" {
+		return 1, nil
+	}
+
+	by, err := strconv.ParseInt(byStr, 10, 64)
+	if err != nil || by == math.MinInt64 {
+		return 0, errors.Join(ErrInvalidBy, err)
+	}
+	return by, nil
+}
+
+// ExtractFloatBy returns float delta of counter, 1 by default.
+func ExtractFloatBy(r *http.Request) (float64, error) {
+	byStr := r.URL.Query().Get(byParam)
+	if byStr == "" {
+		return 1, nil
+	}
+
+	by, err := strconv.ParseFloat(byStr, 64)
+	if err != nil || math.IsNaN(by) || math.IsInf(by, 0) {
+		return 0, errors.Join(ErrInvalidBy, err)
+	}
+	return by, nil
+}
+
+// ExtractRateLimit returns params of rate limit, cost is 1 and algorithm is sliding window by default.
+func ExtractRateLimit(r *http.Request) (key string, p ratelimit.Params, err error) {
+	key, err = ExtractKey(r)
+	if err != nil {
+		return "", p, err
+	}
+
+	query := r.URL.Query()
+	p.Algorithm = ratelimit.Algorithm(query.Get(algorithmParam))
+	if p.Algorithm == "" {
+		p.Algorithm = ratelimit.SlidingWindow
+	}
+
+	p.Limit, err = strconv.ParseInt(query.Get(limitParam), 10, 64)
+	if err != nil {
+		return "", p, errors.Join(ratelimit.ErrInvalidParams, err)
+	}
+
+	p.Window, err = time.ParseDuration(query.Get(windowParam))
+	if err != nil {
+		return "", p, errors.Join(ratelimit.ErrInvalidParams, err)
+	}
+
+	p.Cost = 1
+	if costStr := query.Get(costParam); costStr != "" {
+		p.Cost, err = strconv.ParseInt(costStr, 10, 64)
+		if err != nil {
+			return "", p, errors.Join(ratelimit.ErrInvalidParams, err)
+		}
+	}
+
+	return key, p, p.Validate()
+}
+
+// ExtractLock returns params of lock, wait is zero if isnt passed.
+func ExtractLock(r *http.Request) (key, owner string, ttl, wait time.Duration, err error) {
+	key, ttl, err = ExtractKeyAndTTL(r)
+	if err != nil {
+		return "", "", 0, 0, err
+	}
+
+	query := r.URL.Query()
+	owner = query.Get(ownerParam)
+	if owner == "" {
+		return "", "", 0, 0, ErrEmptyOwner
+	}
+
+	if waitStr := query.Get(waitParam); waitStr != "" {
+		wait, err = time.ParseDuration(waitStr)
+		if err != nil || wait < 0 {
+			return "", "", 0, 0, errors.Join(ErrInvalidWait, err)
+		}
+	}
+	return key, owner, ttl, wait, nil
+}
+
+// ExtractCommand returns command of typed value, value of command is read from body.
+func ExtractCommand(r *http.Request, op typed.Op) (typed.Command, error) {
+	key, ttl, err := ExtractKeyAndTTL(r)
+	if err != nil {
+		return typed.Command{}, err
+	}
+
+	cmd, err := typed.ParseCommand(op, r.URL.Query())
+	if err != nil {
+		return typed.Command{}, err
+	}
+	cmd.Key = key
+	cmd.TTL = ttl
+
+	if cmd.HasBody() {
+		cmd.Value, err = io.ReadAll(r.Body)
+		if err != nil {
+			return typed.Command{}, errors.Join(ErrBodyRead, err)
+		}
+	}
+	return cmd, nil
+}
+
+// DefaultWatchTimeout is used if timeout of watch isnt passed.
+const DefaultWatchTimeout = 30 * time.Second
+
+// ExtractWatch returns params of watch, zero sinceVersion is used if it isnt passed.
+func ExtractWatch(r *http.Request) (key string, sinceVersion uint64, timeout time.Duration, err error) {
+	key, err = ExtractKey(r)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	query := r.URL.Query()
+	if str := query.Get(sinceVersionParam); str != "" {
+		sinceVersion, err = strconv.ParseUint(str, 10, 64)
+		if err != nil {
+			return "", 0, 0, errors.Join(ErrInvalidParam, err)
+		}
+	}
+
+	timeout = DefaultWatchTimeout
+	if str := query.Get(timeoutParam); str != "" {
+		timeout, err = time.ParseDuration(str)
+		if err != nil || timeout <= 0 {
+			return "", 0, 0, errors.Join(ErrInvalidParam, err)
+		}
+	}
+	return key, sinceVersion, timeout, nil
+}
+
+// ExtractVersionHeader returns version of entry, it is false if header isnt valid.
+func ExtractVersionHeader(h http.Header) (uint64, bool) {
+	version, err := strconv.ParseUint(h.Get(VersionHeader), 10, 64)
+	return version, err == nil
+}
+
+// ExtractFilter returns filter of keyspace events by pattern param, or filter
+// of channel messages by channel param. Empty filter matches all keys.
+func ExtractFilter(r *http.Request) (events.Filter, error) {
+	query := r.URL.Query()
+	pattern, channel := query.Get(patternParam), query.Get(channelParam)
+	if pattern != "" && channel != "" {
+		return events.Filter{}, ErrFilter
+	}
+
+	f := events.Filter{Pattern: pattern}
+	if channel != "" {
+		f = events.Filter{Pattern: channel, Channels: true}
+	}
+	if f.Pattern == "" {
+		f.Pattern = "*"
+	}
+	return f, f.Validate()
+}
+
+// ExtractPublish returns channel and message of publish request.
+func ExtractPublish(r *http.Request) (string, []byte, error) {
+	channel := r.URL.Query().Get(channelParam)
+	if channel == "" {
+		return "", nil, ErrEmptyChannel
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return "", nil, errors.Join(ErrBodyRead, err)
+	}
+	return channel, data, nil
+}
+
+// ExtractBatch decodes batch request from body, content type of request is returned
+// to encode response the same way.
+func ExtractBatch(r *http.Request) (req batch.Request, contentType string, err error) {
+	contentType, err = batch.ContentType(r.Header)
+	if err != nil {
+		return batch.Request{}, "", err
+	}
+
+	req, err = batch.DecodeRequest(r.Body, contentType)
+	if err != nil {
+		return batch.Request{}, "", errors.Join(ErrBodyRead, err)
+	}
+
+	if err = req.Validate(); err != nil {
+		return batch.Request{}, "", err
+	}
+	return req, contentType, nil
+}
+
+func ExtractTx(r *http.Request) (tx.Tx, error) {
+	var t tx.Tx
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		return tx.Tx{}, errors.Join(ErrBodyRead, err)
+	}
+
+	if err := t.Validate(); err != nil {
+		return tx.Tx{}, err
+	}
+	return t, nil
 }
 
 func ExtractKey(r *http.Request) (string, error) {
@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/aosderzhikov/sticky/internal/lease"
+	"github.com/aosderzhikov/sticky/internal/ratelimit"
+	"github.com/aosderzhikov/sticky/internal/tx"
+	"github.com/aosderzhikov/sticky/internal/typed"
+)
+
+const (
+	RateLimitLimitHeader      = "X-RateLimit-Limit"
+	RateLimitRemainingHeader  = "X-RateLimit-Remaining"
+	RateLimitRetryAfterHeader = "X-RateLimit-Retry-After"
+)
+
+// WriteRateLimitResponse responds with 429 if request is denied. Retry-After is rounded up to seconds
+// as standard requires, X-RateLimit-Retry-After has exact duration.
+func WriteRateLimitResponse(w http.ResponseWriter, p ratelimit.Params, d ratelimit.Decision) {
+	h := w.Header()
+	h.Set(RateLimitLimitHeader, strconv.FormatInt(p.Limit, 10))
+	h.Set(RateLimitRemainingHeader, strconv.FormatInt(d.Remaining, 10))
+
+	if d.Allowed {
+		_, _ = w.Write([]byte("allowed"))
+		return
+	}
+
+	h.Set(RateLimitRetryAfterHeader, d.RetryAfter.String())
+	h.Set("Retry-After", strconv.FormatInt(int64(math.Ceil(d.RetryAfter.Seconds())), 10))
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, _ = w.Write([]byte("denied"))
+}
+
+// ExtractRateLimitDecision reads decision from response written by WriteRateLimitResponse.
+func ExtractRateLimitDecision(resp *http.Response) (ratelimit.Decision, error) {
+	var (
+		d   ratelimit.Decision
+		err error
+	)
+	d.Allowed = resp.StatusCode == http.StatusOK
+
+	d.Remaining, err = strconv.ParseInt(resp.Header.Get(RateLimitRemainingHeader), 10, 64)
+	if err != nil {
+		return d, err
+	}
+
+	if !d.Allowed {
+		d.RetryAfter, err = time.ParseDuration(resp.Header.Get(RateLimitRetryAfterHeader))
+	}
+	return d, err
+}
+
+// ExtendWriteDeadline allows response to be written later than write timeout of server,
+// e.g. when request waits for lock or element of list.
+func ExtendWriteDeadline(w http.ResponseWriter, wait time.Duration) {
+	if wait == 0 {
+		return
+	}
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + writeDeadlineMargin))
+}
+
+const writeDeadlineMargin = time.Second
+
+// LiftWriteDeadline removes write timeout of server while request body is read, as upload
+// of large value can take longer than it. Returned func limits writing of response again.
+func LiftWriteDeadline(w http.ResponseWriter) (restore func()) {
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+	return func() {
+		_ = rc.SetWriteDeadline(time.Now().Add(writeDeadlineMargin))
+	}
+}
+
+const keepAliveInterval = 15 * time.Second
+
+// ServeEvents streams events as Server-Sent Events until client disconnects or events is closed,
+// then closed is called to get reason of closing which is sent to client.
+func ServeEvents(w http.ResponseWriter, r *http.Request, ch <-chan events.Event, closed func() error) {
+	rc := http.NewResponseController(w)
+	// stream isnt limited by write timeout of server
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	_ = rc.Flush()
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			err = events.WriteKeepAlive(w)
+		case e, ok := <-ch:
+			if !ok {
+				if err = closed(); err != nil {
+					_ = events.WriteError(w, err)
+					_ = rc.Flush()
+				}
+				return
+			}
+			err = events.WriteEvent(w, e)
+		}
+		if err != nil {
+			return
+		}
+		_ = rc.Flush()
+	}
+}
+
+func WriteLease(w http.ResponseWriter, l lease.Lease) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(l)
+}
+
+func WriteReply(w http.ResponseWriter, reply typed.Reply) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(reply)
+}
+
+// WriteTxResponse responds with 409 if transaction isnt committed.
+func WriteTxResponse(w http.ResponseWriter, resp tx.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	if !resp.Committed {
+		w.WriteHeader(http.StatusConflict)
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
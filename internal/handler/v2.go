@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/meta"
+)
+
+// Headers of v2 api, they are sent with the same values as fields of EntryV2.
+const (
+	CreatedAtHeader       = "X-Sticky-Created-At"
+	UpdatedAtHeader       = "X-Sticky-Updated-At"
+	SizeHeader            = "X-Sticky-Size"
+	ContentTypeHeader     = "X-Sticky-Content-Type"
+	ContentEncodingHeader = "X-Sticky-Content-Encoding"
+
+	// DefaultContentType is content type of values stored without it.
+	DefaultContentType = "application/octet-stream"
+
+	keyPathValue = "key"
+)
+
+var ErrEmptyKey error = errors.New("key cannot be empty")
+
+// EntryV2 is json envelope of value in v2 api. Value is base64 encoded,
+// TTL is absent if value doesnt expire. Metadata of value is inlined.
+type EntryV2 struct {
+	Key       string          `json:"key"`
+	Value     []byte          `json:"value"`
+	Version   uint64          `json:"version"`
+	TTL       *batch.Duration `json:"ttl,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+	Size      int             `json:"size"`
+	meta.Meta
+}
+
+// RemainingTTL returns ttl for EntryV2, it is nil if expiresAt is zero.
+func RemainingTTL(expiresAt time.Time) *batch.Duration {
+	if expiresAt.IsZero() {
+		return nil
+	}
+	ttl := batch.Duration(max(time.Until(expiresAt), 0).Round(time.Millisecond))
+	return &ttl
+}
+
+// WriteEntryV2 puts metadata of entry to headers, the whole entry is written
+// to body unless it is response to HEAD request.
+func WriteEntryV2(w http.ResponseWriter, r *http.Request, e EntryV2) {
+	e.Size = len(e.Value)
+	if e.ContentType == "" {
+		e.ContentType = DefaultContentType
+	}
+
+	h := w.Header()
+	h.Set(VersionHeader, strconv.FormatUint(e.Version, 10))
+	PutValidators(w, e.Version, e.UpdatedAt)
+	if e.TTL != nil {
+		PutTTLHeader(w, time.Duration(*e.TTL))
+	}
+	PutTimeHeader(w, CreatedAtHeader, e.CreatedAt)
+	PutTimeHeader(w, UpdatedAtHeader, e.UpdatedAt)
+	h.Set(SizeHeader, strconv.Itoa(e.Size))
+	h.Set(ContentTypeHeader, e.ContentType)
+	if e.ContentEncoding != "" {
+		h.Set(ContentEncodingHeader, e.ContentEncoding)
+	}
+	e.PutUser(h)
+	h.Set("Content-Type", "application/json")
+
+	if r.Method == http.MethodHead {
+		return
+	}
+	_ = json.NewEncoder(w).Encode(e)
+}
+
+// ExtractKeyV2 returns key from path of v2 api, key can contain slashes.
+func ExtractKeyV2(r *http.Request) (string, error) {
+	key := r.PathValue(keyPathValue)
+	if key == "" {
+		return "", ErrEmptyKey
+	}
+	return key, nil
+}
+
+// PutV2 is request to store value in v2 api, value is read from body by caller.
+type PutV2 struct {
+	Key  string
+	TTL  time.Duration
+	Meta meta.Meta
+}
+
+// ExtractPutV2 returns key from path, ttl from query and metadata from headers.
+func ExtractPutV2(r *http.Request) (PutV2, error) {
+	key, err := ExtractKeyV2(r)
+	if err != nil {
+		return PutV2{}, err
+	}
+
+	ttl, err := extractTTL(r)
+	if err != nil {
+		return PutV2{}, err
+	}
+
+	m, err := meta.FromHeader(r.Header)
+	if err != nil {
+		return PutV2{}, err
+	}
+	return PutV2{Key: key, TTL: ttl, Meta: m}, nil
+}
+
+// PutTimeHeader puts time in RFC 3339 format, zero time isnt put.
+func PutTimeHeader(w http.ResponseWriter, name string, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	w.Header().Set(name, t.UTC().Format(time.RFC3339Nano))
+}
+
+// ExtractTimeHeader returns zero time if header is absent or invalid.
+func ExtractTimeHeader(h http.Header, name string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, h.Get(name))
+	return t
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// DefaultMaxValueSize limits value of set request if limit isnt configured.
+const DefaultMaxValueSize int64 = 64 << 20
+
+// DefaultUploadIdleTimeout limits waiting for next part of body if timeout isnt configured.
+const DefaultUploadIdleTimeout = 10 * time.Second
+
+var (
+	ErrValueTooLarge error = errors.New("value is too large")
+	ErrUploadIdle    error = errors.New("client sent no part of value within idle timeout")
+)
+
+// LimitBody makes reading more than limit bytes of body fail, request with larger
+// Content-Length fails at once. Zero limit is DefaultMaxValueSize.
+func LimitBody(w http.ResponseWriter, r *http.Request, limit int64) error {
+	if limit <= 0 {
+		limit = DefaultMaxValueSize
+	}
+	if r.ContentLength > limit {
+		return fmt.Errorf("%w: %d bytes is more than %d", ErrValueTooLarge, r.ContentLength, limit)
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit)
+	return nil
+}
+
+// ReadValue reads body not larger than limit, body of known length is read into single buffer.
+func ReadValue(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	if err := LimitBody(w, r, limit); err != nil {
+		return nil, err
+	}
+
+	var (
+		value []byte
+		err   error
+	)
+	if r.ContentLength >= 0 {
+		value = make([]byte, r.ContentLength)
+		_, err = io.ReadFull(r.Body, value)
+	} else {
+		value, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		return nil, BodyError(err)
+	}
+	return value, nil
+}
+
+// BodyError wraps error of reading body, it is ErrValueTooLarge if body exceeded its limit.
+func BodyError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return fmt.Errorf("%w: more than %d bytes", ErrValueTooLarge, maxErr.Limit)
+	}
+	return errors.Join(ErrBodyRead, err)
+}
+
+// WatchUpload makes reading of body fail if client sends nothing for idle, so stalled upload
+// doesnt hold connection while upload itself isnt limited in time. Zero idle is DefaultUploadIdleTimeout.
+func WatchUpload(w http.ResponseWriter, r *http.Request, idle time.Duration) {
+	if idle <= 0 {
+		idle = DefaultUploadIdleTimeout
+	}
+	r.Body = &idleBody{ReadCloser: r.Body, rc: http.NewResponseController(w), idle: idle}
+}
+
+// idleBody extends read deadline of connection before each read of body.
+type idleBody struct {
+	io.ReadCloser
+	rc   *http.ResponseController
+	idle time.Duration
+}
+
+func (b *idleBody) Read(p []byte) (int, error) {
+	_ = b.rc.SetReadDeadline(time.Now().Add(b.idle))
+	n, err := b.ReadCloser.Read(p)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return n, errors.Join(ErrUploadIdle, err)
+	}
+	if err == io.EOF {
+		// server reads connection in background after body is read to detect closing
+		// by client, it mustnt time out while request is handled. Deadline is kept
+		// if body isnt read to the end, as server may discard rest of it.
+		_ = b.rc.SetReadDeadline(time.Time{})
+	}
+	return n, err
+}
@@ -0,0 +1,204 @@
+package keeper
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/aosderzhikov/sticky/internal/keyring"
+)
+
+// Codec is compression of stored value, value with empty codec is stored as is.
+type Codec string
+
+const (
+	CodecGzip  Codec = "gzip"
+	CodecFlate Codec = "flate"
+
+	// DefaultCompressionThreshold is min size of compressed value if threshold isnt configured.
+	DefaultCompressionThreshold = 1024
+)
+
+var ErrUnknownCodec error = errors.New("unknown compression codec")
+
+// ParseCodec returns empty codec for empty string, so compression is disabled.
+func ParseCodec(s string) (Codec, error) {
+	switch c := Codec(s); c {
+	case "", CodecGzip, CodecFlate:
+		return c, nil
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnknownCodec, s)
+	}
+}
+
+type CompressionOptions struct {
+	// Codec is empty if values arent compressed.
+	Codec Codec
+	// Threshold is min size of value in bytes to be compressed, zero is DefaultCompressionThreshold.
+	Threshold int
+}
+
+// SetCompression makes keeper compress values set after it, stored values are kept as they are.
+func (k *Keeper) SetCompression(opts CompressionOptions) {
+	if opts.Threshold <= 0 {
+		opts.Threshold = DefaultCompressionThreshold
+	}
+	k.compression.Store(&opts)
+}
+
+// CompressionStats describes values compressed by keeper, values stored as is arent counted.
+type CompressionStats struct {
+	Values int `json:"values"`
+	// Size is size of compressed data including encryption overhead if values are encrypted,
+	// RawSize is size of the same data before compression.
+	Size    int64 `json:"size"`
+	RawSize int64 `json:"rawSize"`
+}
+
+// Ratio is RawSize to Size, it is zero if there are no compressed values.
+func (s CompressionStats) Ratio() float64 {
+	if s.Size == 0 {
+		return 0
+	}
+	return float64(s.RawSize) / float64(s.Size)
+}
+
+func (k *Keeper) CompressionStats() CompressionStats {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.compressed
+}
+
+// compressValue compresses data of value if it is large enough. Value is kept as is
+// if compression doesnt make it smaller or client already encoded it.
+func (k *Keeper) compressValue(val value) value {
+	opts := k.compression.Load()
+	if opts == nil || opts.Codec == "" || len(val.data) < opts.Threshold || val.meta.ContentEncoding != "" {
+		return val
+	}
+
+	data, err := compress(opts.Codec, val.data)
+	if err != nil {
+		slog.Error(fmt.Sprintf("compress value of key %q: %v", val.key, err))
+		return val
+	}
+	if len(data) >= len(val.data) {
+		return val
+	}
+
+	val.rawSize = len(val.data)
+	val.data = data
+	val.codec = opts.Codec
+	return val
+}
+
+// accountLocked adds size of value to memory and compression stats, sign is -1 to subtract it.
+func (k *Keeper) accountLocked(val value, sign int64) {
+	k.memory += sign * int64(val.size())
+	if val.keyID != "" {
+		k.keyIDs[val.keyID] += int(sign)
+		if k.keyIDs[val.keyID] == 0 {
+			delete(k.keyIDs, val.keyID)
+		}
+	}
+	if val.codec == "" {
+		return
+	}
+	k.compressed.Values += int(sign)
+	k.compressed.Size += sign * int64(len(val.data))
+	k.compressed.RawSize += sign * int64(val.rawSize)
+}
+
+// plain returns decrypted and decompressed data of value.
+func (v value) plain(kr *keyring.Keyring) ([]byte, error) {
+	data, err := v.open(kr)
+	if err != nil || v.codec == "" {
+		return data, err
+	}
+	return decompress(v.codec, data, v.rawSize)
+}
+
+// decompress returns entry with decompressed Data, entry without codec is returned as is.
+func (e Entry) decompress() (Entry, error) {
+	if e.Codec == "" {
+		return e, nil
+	}
+	data, err := decompress(e.Codec, e.Data, e.rawSize)
+	if err != nil {
+		return Entry{}, err
+	}
+	e.Data, e.Codec = data, ""
+	return e, nil
+}
+
+func compress(codec Codec, data []byte) ([]byte, error) {
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+	)
+	switch codec {
+	case CodecGzip:
+		w = gzip.NewWriter(&buf)
+	case CodecFlate:
+		// default level never fails
+		w, _ = flate.NewWriter(&buf, flate.DefaultCompression)
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownCodec, codec)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompress reads data of known size into single buffer.
+func decompress(codec Codec, data []byte, size int) ([]byte, error) {
+	var r io.ReadCloser
+	switch codec {
+	case CodecGzip:
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		r = zr
+	case CodecFlate:
+		r = flate.NewReader(bytes.NewReader(data))
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownCodec, codec)
+	}
+	defer r.Close()
+
+	plain := make([]byte, size)
+	if _, err := io.ReadFull(r, plain); err != nil {
+		return nil, err
+	}
+	return plain, nil
+}
+
+// acceptsGzip reports whether client accepts gzip encoded response, gzip with zero q isnt accepted.
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(enc, ";")
+		if name = strings.TrimSpace(name); name != "gzip" && name != "*" {
+			continue
+		}
+		q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+		if !ok {
+			return true
+		}
+		weight, err := strconv.ParseFloat(q, 64)
+		return err == nil && weight > 0
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package keeper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/stretchr/testify/require"
+)
+
+var verbose = []byte(strings.Repeat(`{"name":"sticky","tags":["cache","keeper"]},`, 100))
+
+func TestCompression(t *testing.T) {
+	for _, codec := range []Codec{CodecGzip, CodecFlate} {
+		t.Run(string(codec), func(t *testing.T) {
+			k := NewService(time.Minute)
+			k.SetCompression(CompressionOptions{Codec: codec, Threshold: 100})
+
+			k.Set("large", verbose, 0)
+			k.Set("small", []byte("short"), 0)
+
+			entry, ok, _ := k.Get("large")
+			require.True(t, ok)
+			require.Equal(t, verbose, entry.Data)
+			require.Empty(t, entry.Codec)
+
+			stored, ok, _ := k.GetStored("large")
+			require.True(t, ok)
+			require.Equal(t, codec, stored.Codec)
+			require.Less(t, len(stored.Data), len(verbose))
+
+			stored, ok, _ = k.GetStored("small")
+			require.True(t, ok)
+			require.Empty(t, stored.Codec)
+
+			stats := k.CompressionStats()
+			require.Equal(t, 1, stats.Values)
+			require.Equal(t, int64(len(verbose)), stats.RawSize)
+			require.Greater(t, stats.Ratio(), 1.0)
+			require.Equal(t, Stats{Memory: k.Memory(), Compression: stats, CompressionRatio: stats.Ratio()}, k.Stats())
+			// memory is counted by compressed size
+			require.Less(t, k.Memory(), int64(len(verbose)))
+
+			k.Delete("large")
+			k.Delete("small")
+			require.Equal(t, CompressionStats{}, k.CompressionStats())
+			require.Equal(t, int64(0), k.Memory())
+		})
+	}
+}
+
+func TestCompressionSkipped(t *testing.T) {
+	k := NewService(time.Minute)
+	k.Set("before", verbose, 0)
+	k.SetCompression(CompressionOptions{Codec: CodecGzip, Threshold: 10})
+
+	// value encoded by client isnt compressed again
+	k.SetWithMeta("encoded", verbose, 0, meta.Meta{ContentEncoding: "br"})
+	// random data doesnt get smaller
+	k.Set("incompressible", []byte("q8Zx1pLw0v"), 0)
+
+	for _, key := range []string{"before", "encoded", "incompressible"} {
+		stored, ok, _ := k.GetStored(key)
+		require.True(t, ok)
+		require.Empty(t, stored.Codec, key)
+	}
+	require.Equal(t, 0, k.CompressionStats().Values)
+}
+
+func TestCompressedCounter(t *testing.T) {
+	k := NewService(time.Minute)
+	k.SetCompression(CompressionOptions{Codec: CodecGzip, Threshold: 1})
+	k.Set("counter", []byte(strings.Repeat("0", 1000)+"1"), 0)
+
+	stored, _, _ := k.GetStored("counter")
+	require.Equal(t, CodecGzip, stored.Codec)
+
+	n, err := k.Incr("counter", 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+	require.Equal(t, 0, k.CompressionStats().Values)
+}
+
+func TestGetHandleCompressed(t *testing.T) {
+	k := NewService(time.Minute)
+	k.SetCompression(CompressionOptions{Codec: CodecGzip, Threshold: 100})
+	k.Set("key", verbose, 0)
+	h := NewHandler(k, HandlerOptions{})
+
+	req := httptest.NewRequest(http.MethodGet, "/get?key=key", http.NoBody)
+	req.Header.Set("Accept-Encoding", "br, gzip")
+	rec := httptest.NewRecorder()
+	h.GetHandle(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	zr, err := gzip.NewReader(rec.Body)
+	require.NoError(t, err)
+	data, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	require.Equal(t, verbose, data)
+
+	req = httptest.NewRequest(http.MethodGet, "/get?key=key", http.NoBody)
+	rec = httptest.NewRecorder()
+	h.GetHandle(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, rec.Header().Get("Content-Encoding"))
+	require.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	require.True(t, bytes.Equal(verbose, rec.Body.Bytes()))
+}
+
+func TestGetHandleCompressedETag(t *testing.T) {
+	k := NewService(time.Minute)
+	k.SetCompression(CompressionOptions{Codec: CodecGzip, Threshold: 100})
+	k.Set("key", verbose, 0)
+	entry, _, _ := k.Get("key")
+	h := NewHandler(k, HandlerOptions{})
+
+	// representations have different bytes, so they have different ETags
+	rec := serveConditional(h, http.MethodGet, "/get?key=key", "", "Accept-Encoding", "gzip")
+	gzipETag := rec.Header().Get("ETag")
+	require.Equal(t, handler.GzipETag(entry.Version), gzipETag)
+	rec = serveConditional(h, http.MethodGet, "/get?key=key", "")
+	etag := rec.Header().Get("ETag")
+	require.Equal(t, handler.ETag(entry.Version), etag)
+	require.NotEqual(t, etag, gzipETag)
+
+	tests := []struct {
+		name    string
+		headers []string
+		code    int
+		etag    string
+	}{
+		{"gzip etag with gzip", []string{"Accept-Encoding", "gzip", "If-None-Match", gzipETag}, http.StatusNotModified, gzipETag},
+		{"gzip etag without gzip", []string{"If-None-Match", gzipETag}, http.StatusOK, etag},
+		{"identity etag with gzip", []string{"Accept-Encoding", "gzip", "If-None-Match", etag}, http.StatusOK, gzipETag},
+		{"identity etag without gzip", []string{"If-None-Match", etag}, http.StatusNotModified, etag},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveConditional(h, http.MethodGet, "/get?key=key", "", tt.headers...)
+			require.Equal(t, tt.code, rec.Code)
+			require.Equal(t, tt.etag, rec.Header().Get("ETag"))
+			require.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+		})
+	}
+
+	// write applies to value, so ETag of any representation matches
+	rec = serveConditional(h, http.MethodPost, "/set?key=key", "v2", "If-Match", gzipETag)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=0.5", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.0", false},
+		{"*", true},
+		{"br", false},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/get", http.NoBody)
+		req.Header.Set("Accept-Encoding", tt.header)
+		require.Equal(t, tt.want, acceptsGzip(req), tt.header)
+	}
+}
+
+func TestParseCodec(t *testing.T) {
+	codec, err := ParseCodec("flate")
+	require.NoError(t, err)
+	require.Equal(t, CodecFlate, codec)
+
+	codec, err = ParseCodec("")
+	require.NoError(t, err)
+	require.Empty(t, codec)
+
+	_, err = ParseCodec("zstd")
+	require.ErrorIs(t, err, ErrUnknownCodec)
+}
@@ -0,0 +1,51 @@
+package keeper
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/aosderzhikov/sticky/internal/tx"
+)
+
+// setIfMatch stores value if request has no If-Match or it holds, otherwise it responds with 412.
+func (h *Handler) setIfMatch(w http.ResponseWriter, r *http.Request, key string, value []byte, ttl time.Duration, m meta.Meta) bool {
+	cond, ok := handler.ExtractIfMatch(r)
+	if !ok {
+		h.s.SetWithMeta(key, value, ttl, m)
+		return true
+	}
+	return h.writeIfMatch(w, r, key, cond, tx.Op{Op: tx.OpSet, Key: key, Value: value, TTL: batch.Duration(ttl), Meta: m})
+}
+
+// deleteIfMatch deletes key if request has no If-Match or it holds, otherwise it responds with 412.
+func (h *Handler) deleteIfMatch(w http.ResponseWriter, r *http.Request, key string) bool {
+	cond, ok := handler.ExtractIfMatch(r)
+	if !ok {
+		h.s.Delete(key)
+		return true
+	}
+	return h.writeIfMatch(w, r, key, cond, tx.Op{Op: tx.OpDelete, Key: key})
+}
+
+// writeIfMatch applies op to key if ETag of key matches cond, ETag of written value is put to response.
+// Version is checked again in transaction, so key changed after it was read isnt written.
+func (h *Handler) writeIfMatch(w http.ResponseWriter, r *http.Request, key string, cond handler.IfMatch, op tx.Op) bool {
+	entry, found, err := h.s.Get(key)
+	if err != nil {
+		handler.ErrorHandle(r.Context(), w, err, http.StatusInternalServerError)
+		return false
+	}
+	if found && cond.Matches(entry.Version) {
+		resp := h.s.Exec(tx.Tx{Ops: []tx.Op{{Op: tx.OpCheck, Key: key, Version: entry.Version}, op}})
+		if resp.Committed {
+			handler.PutValidators(w, resp.Results[1].Version, time.Time{})
+			return true
+		}
+	}
+
+	handler.ErrorHandle(r.Context(), w, handler.ErrPreconditionFailed, http.StatusPreconditionFailed)
+	return false
+}
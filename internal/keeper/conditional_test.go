@@ -0,0 +1,101 @@
+package keeper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/stretchr/testify/require"
+)
+
+func serveConditional(h *Handler, method, target, body string, headers ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	rec := httptest.NewRecorder()
+	switch method {
+	case http.MethodGet:
+		h.GetHandle(rec, req)
+	case http.MethodPost:
+		h.SetHandle(rec, req)
+	case http.MethodDelete:
+		h.DeleteHandle(rec, req)
+	}
+	return rec
+}
+
+func TestConditionalGet(t *testing.T) {
+	k := NewService(time.Minute)
+	h := NewHandler(k, HandlerOptions{})
+	k.Set("key", []byte("value"), 0)
+
+	rec := serveConditional(h, http.MethodGet, "/get?key=key", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	entry, _, _ := k.Get("key")
+	require.Equal(t, handler.ETag(entry.Version), etag)
+	lastModified := rec.Header().Get("Last-Modified")
+	require.NotEmpty(t, lastModified)
+
+	cases := []struct {
+		name    string
+		headers []string
+		want    int
+	}{
+		{"matching etag", []string{"If-None-Match", etag}, http.StatusNotModified},
+		{"weak etag", []string{"If-None-Match", `"7", W/` + etag}, http.StatusNotModified},
+		{"any etag", []string{"If-None-Match", "*"}, http.StatusNotModified},
+		{"other etag", []string{"If-None-Match", `"7"`}, http.StatusOK},
+		{"not modified since", []string{"If-Modified-Since", lastModified}, http.StatusNotModified},
+		{"modified since", []string{"If-Modified-Since", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)}, http.StatusOK},
+		{"etag has priority", []string{"If-None-Match", `"7"`, "If-Modified-Since", lastModified}, http.StatusOK},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serveConditional(h, http.MethodGet, "/get?key=key", "", tt.headers...)
+			require.Equal(t, tt.want, rec.Code)
+			require.Equal(t, etag, rec.Header().Get("ETag"))
+			if tt.want == http.StatusNotModified {
+				require.Empty(t, rec.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestConditionalWrite(t *testing.T) {
+	k := NewService(time.Minute)
+	h := NewHandler(k, HandlerOptions{})
+
+	rec := serveConditional(h, http.MethodPost, "/set?key=key", "v1", "If-Match", "*")
+	require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	_, found, _ := k.Get("key")
+	require.False(t, found)
+
+	k.Set("key", []byte("v1"), 0)
+	entry, _, _ := k.Get("key")
+	version := entry.Version
+
+	rec = serveConditional(h, http.MethodPost, "/set?key=key", "v2", "If-Match", handler.ETag(7))
+	require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = serveConditional(h, http.MethodPost, "/set?key=key", "v2",
+		"If-Match", handler.ETag(version), "Content-Type", "text/plain")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, handler.ETag(version+1), rec.Header().Get("ETag"))
+	entry, _, _ = k.Get("key")
+	require.Equal(t, []byte("v2"), entry.Data)
+	require.Equal(t, "text/plain", entry.Meta.ContentType)
+
+	rec = serveConditional(h, http.MethodDelete, "/delete?key=key", "", "If-Match", handler.ETag(version))
+	require.Equal(t, http.StatusPreconditionFailed, rec.Code)
+
+	rec = serveConditional(h, http.MethodDelete, "/delete?key=key", "", "If-Match", handler.ETag(version+1))
+	require.Equal(t, http.StatusOK, rec.Code)
+	_, found, _ = k.Get("key")
+	require.False(t, found)
+}
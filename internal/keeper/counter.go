@@ -0,0 +1,98 @@
+package keeper
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/aosderzhikov/sticky/internal/keyring"
+)
+
+var (
+	ErrNotNumber error = errors.New("value isnt a number")
+	ErrOverflow  error = errors.New("increment would overflow")
+)
+
+// Incr adds delta to integer value of key, value is created with zero if key doesnt exist.
+// Ttl is used only when key is created.
+func (k *Keeper) Incr(key string, by int64, ttl time.Duration) (int64, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var current int64
+	if val, ok := k.values[key]; ok && !val.expired(time.Now()) {
+		var err error
+		current, err = parseInt(val, k.keyring)
+		if err != nil {
+			return 0, ErrNotNumber
+		}
+	}
+
+	if (by > 0 && current > math.MaxInt64-by) || (by < 0 && current < math.MinInt64-by) {
+		return 0, ErrOverflow
+	}
+
+	current += by
+	k.updateLocked(key, []byte(strconv.FormatInt(current, 10)), ttl)
+	return current, nil
+}
+
+// IncrFloat is the same as Incr for float values.
+func (k *Keeper) IncrFloat(key string, by float64, ttl time.Duration) (float64, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var current float64
+	if val, ok := k.values[key]; ok && !val.expired(time.Now()) {
+		var err error
+		current, err = parseFloat(val, k.keyring)
+		if err != nil || math.IsNaN(current) || math.IsInf(current, 0) {
+			return 0, ErrNotNumber
+		}
+	}
+
+	current += by
+	if math.IsInf(current, 0) || math.IsNaN(current) {
+		return 0, ErrOverflow
+	}
+
+	k.updateLocked(key, []byte(strconv.FormatFloat(current, 'f', -1, 64)), ttl)
+	return current, nil
+}
+
+// updateLocked replaces data of existing key keeping its ttl, or creates key with ttl.
+func (k *Keeper) updateLocked(key string, data []byte, ttl time.Duration) {
+	val, ok := k.values[key]
+	if !ok || val.expired(time.Now()) {
+		k.setLocked(key, data, ttl)
+		return
+	}
+
+	k.version++
+	k.accountLocked(val, -1)
+	val.data, val.codec, val.rawSize, val.keyID = data, "", 0, ""
+	val = k.sealLocked(val)
+	k.accountLocked(val, 1)
+	val.version = k.version
+	val.updatedAt = time.Now()
+	k.values[key] = val
+	k.notifyLocked(events.OpSet, key, val.version)
+}
+
+func parseInt(val value, kr *keyring.Keyring) (int64, error) {
+	data, err := val.plain(kr)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(string(data), 10, 64)
+}
+
+func parseFloat(val value, kr *keyring.Keyring) (float64, error) {
+	data, err := val.plain(kr)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(data), 64)
+}
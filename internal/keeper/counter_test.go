@@ -0,0 +1,69 @@
+package keeper
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestIncr(t *testing.T) {
+	k := NewService(10 * time.Second)
+
+	value, err := k.Incr("counter", 5, time.Minute)
+	if err != nil || value != 5 {
+		t.Fatalf("want 5, but got %d, %v", value, err)
+	}
+	created, _, _ := k.Get("counter")
+
+	value, err = k.Incr("counter", -7, time.Hour)
+	if err != nil || value != -2 {
+		t.Fatalf("want -2, but got %d, %v", value, err)
+	}
+
+	entry, _, _ := k.Get("counter")
+	if string(entry.Data) != "-2" {
+		t.Errorf("want stored -2, but got %s", string(entry.Data))
+	}
+	if !entry.ExpiresAt.Equal(created.ExpiresAt) {
+		t.Error("ttl of existing counter is changed but shoudnt")
+	}
+	if entry.Version <= created.Version {
+		t.Error("version of counter isnt incremented")
+	}
+}
+
+func TestIncrErrors(t *testing.T) {
+	k := NewService(10 * time.Second)
+
+	k.Set("text", []byte("abc"), 0)
+	if _, err := k.Incr("text", 1, 0); !errors.Is(err, ErrNotNumber) {
+		t.Errorf("want error %v, but got %v", ErrNotNumber, err)
+	}
+
+	k.Set("max", []byte(strconv.FormatInt(math.MaxInt64, 10)), 0)
+	if _, err := k.Incr("max", 1, 0); !errors.Is(err, ErrOverflow) {
+		t.Errorf("want error %v, but got %v", ErrOverflow, err)
+	}
+
+	k.Set("min", []byte(strconv.FormatInt(math.MinInt64, 10)), 0)
+	if _, err := k.Incr("min", -1, 0); !errors.Is(err, ErrOverflow) {
+		t.Errorf("want error %v, but got %v", ErrOverflow, err)
+	}
+}
+
+func TestIncrFloat(t *testing.T) {
+	k := NewService(10 * time.Second)
+
+	k.Set("counter", []byte("10"), 0)
+	value, err := k.IncrFloat("counter", 0.5, 0)
+	if err != nil || value != 10.5 {
+		t.Fatalf("want 10.5, but got %v, %v", value, err)
+	}
+
+	k.Set("max", []byte(strconv.FormatFloat(math.MaxFloat64, 'f', -1, 64)), 0)
+	if _, err = k.IncrFloat("max", math.MaxFloat64, 0); !errors.Is(err, ErrOverflow) {
+		t.Errorf("want error %v, but got %v", ErrOverflow, err)
+	}
+}
@@ -0,0 +1,116 @@
+package keeper
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aosderzhikov/sticky/internal/keyring"
+)
+
+// reencryptBatch is number of values reencrypted under one lock.
+const reencryptBatch = 256
+
+// SetEncryption makes keeper encrypt string values set after it with primary key of kr.
+// Keyring is refused if some stored value is encrypted with key missing in it, as the value
+// couldnt be read then. Values encrypted with other keys or not encrypted are kept until Reencrypt.
+func (k *Keeper) SetEncryption(kr *keyring.Keyring) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	for id, n := range k.keyIDs {
+		if kr == nil || !kr.Has(id) {
+			return fmt.Errorf("%w %q, it is used by %d values", keyring.ErrUnknownKey, id, n)
+		}
+	}
+	k.keyring = kr
+	return nil
+}
+
+// Reencrypt encrypts string values with primary key if they are encrypted with another one
+// or arent encrypted. Lock is released between batches, so it can run in background while
+// keeper serves requests. It returns number of reencrypted values.
+func (k *Keeper) Reencrypt(ctx context.Context) (int, error) {
+	k.mu.RLock()
+	var keys []string
+	for key, val := range k.values {
+		if k.staleLocked(val) {
+			keys = append(keys, key)
+		}
+	}
+	k.mu.RUnlock()
+
+	reencrypted := 0
+	for len(keys) > 0 {
+		if err := ctx.Err(); err != nil {
+			return reencrypted, err
+		}
+
+		n := min(reencryptBatch, len(keys))
+		done, err := k.reencryptBatch(keys[:n])
+		reencrypted += done
+		if err != nil {
+			return reencrypted, err
+		}
+		keys = keys[n:]
+	}
+	return reencrypted, nil
+}
+
+func (k *Keeper) reencryptBatch(keys []string) (int, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	reencrypted := 0
+	for _, key := range keys {
+		// value could be set again or removed since keys were collected
+		val, ok := k.values[key]
+		if !ok || !k.staleLocked(val) {
+			continue
+		}
+
+		data, err := k.openLocked(val)
+		if err != nil {
+			return reencrypted, fmt.Errorf("decrypt value of key %q: %w", key, err)
+		}
+
+		// version isnt changed, value is the same for clients
+		k.accountLocked(val, -1)
+		val.data, val.keyID = data, ""
+		val = k.sealLocked(val)
+		k.accountLocked(val, 1)
+		k.values[key] = val
+		reencrypted++
+	}
+	return reencrypted, nil
+}
+
+// staleLocked reports whether string value isnt encrypted with primary key.
+func (k *Keeper) staleLocked(val value) bool {
+	return k.keyring != nil && val.obj == nil && val.keyID != k.keyring.Primary()
+}
+
+// sealLocked encrypts data of string value with primary key, key of value is authenticated
+// with it, so data cant be moved to another key.
+func (k *Keeper) sealLocked(val value) value {
+	if k.keyring == nil || val.obj != nil {
+		return val
+	}
+	val.data = k.keyring.Seal(val.data, []byte(val.key))
+	val.keyID = k.keyring.Primary()
+	return val
+}
+
+func (k *Keeper) openLocked(val value) ([]byte, error) {
+	return val.open(k.keyring)
+}
+
+// open returns decrypted data of value, kr is keyring of keeper when value was read.
+func (v value) open(kr *keyring.Keyring) ([]byte, error) {
+	if v.keyID == "" {
+		return v.data, nil
+	}
+	if kr == nil {
+		return nil, fmt.Errorf("%w %q", keyring.ErrUnknownKey, v.keyID)
+	}
+	return kr.Open(v.data, []byte(v.key))
+}
@@ -0,0 +1,137 @@
+package keeper
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/keyring"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKeyring(t *testing.T, primary string, ids ...string) *keyring.Keyring {
+	keys := make(map[string][]byte, len(ids))
+	for _, id := range ids {
+		key := sha256.Sum256([]byte(id))
+		keys[id] = key[:]
+	}
+	kr, err := keyring.New(primary, keys)
+	require.NoError(t, err)
+	return kr
+}
+
+func TestEncryption(t *testing.T) {
+	k := NewService(time.Minute)
+	require.NoError(t, k.SetEncryption(newTestKeyring(t, "k1", "k1")))
+
+	k.Set("secret", []byte("pii"), 0)
+	_, err := k.Incr("counter", 5, 0)
+	require.NoError(t, err)
+
+	k.mu.RLock()
+	stored := k.values["secret"]
+	k.mu.RUnlock()
+	require.Equal(t, "k1", stored.keyID)
+	require.NotContains(t, string(stored.data), "pii")
+
+	entry, ok, _ := k.Get("secret")
+	require.True(t, ok)
+	require.Equal(t, []byte("pii"), entry.Data)
+
+	n, err := k.Incr("counter", 1, 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(6), n)
+
+	entries, _ := k.MGet([]string{"secret", "counter"})
+	require.Equal(t, []byte("6"), entries["counter"].Data)
+
+	k.Delete("secret")
+	k.Delete("counter")
+	require.Empty(t, k.keyIDs)
+	require.Equal(t, int64(0), k.Memory())
+}
+
+func TestEncryptionWithCompression(t *testing.T) {
+	k := NewService(time.Minute)
+	k.SetCompression(CompressionOptions{Codec: CodecGzip, Threshold: 100})
+	require.NoError(t, k.SetEncryption(newTestKeyring(t, "k1", "k1")))
+
+	k.Set("key", verbose, 0)
+
+	stored, ok, _ := k.GetStored("key")
+	require.True(t, ok)
+	require.Equal(t, CodecGzip, stored.Codec)
+
+	entry, ok, _ := k.Get("key")
+	require.True(t, ok)
+	require.Equal(t, verbose, entry.Data)
+}
+
+func TestReencrypt(t *testing.T) {
+	k := NewService(time.Minute)
+	k.Set("plain", []byte("before encryption"), 0)
+	_, err := k.Do(typed.Command{Op: typed.HSet, Key: "hash", Field: "f", Value: []byte("v")})
+	require.NoError(t, err)
+
+	require.NoError(t, k.SetEncryption(newTestKeyring(t, "k1", "k1")))
+	for i := range reencryptBatch + 1 {
+		k.Set("key"+strconv.Itoa(i), []byte("value"), 0)
+	}
+
+	// keyring without key of stored values is refused
+	err = k.SetEncryption(newTestKeyring(t, "k2", "k2"))
+	require.ErrorIs(t, err, keyring.ErrUnknownKey)
+
+	require.NoError(t, k.SetEncryption(newTestKeyring(t, "k2", "k1", "k2")))
+	n, err := k.Reencrypt(context.Background())
+	require.NoError(t, err)
+	// values of k1 and value set before encryption, typed value isnt encrypted
+	require.Equal(t, reencryptBatch+2, n)
+	require.Equal(t, map[string]int{"k2": reencryptBatch + 2}, k.keyIDs)
+
+	entry, ok, _ := k.Get("plain")
+	require.True(t, ok)
+	require.Equal(t, []byte("before encryption"), entry.Data)
+
+	// k1 isnt used anymore, so it can be removed
+	require.NoError(t, k.SetEncryption(newTestKeyring(t, "k2", "k2")))
+	entry, ok, _ = k.Get("key0")
+	require.True(t, ok)
+	require.Equal(t, []byte("value"), entry.Data)
+
+	n, err = k.Reencrypt(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, n)
+}
+
+func TestUndecryptableValue(t *testing.T) {
+	k := NewService(time.Minute)
+	require.NoError(t, k.SetEncryption(newTestKeyring(t, "k1", "k1")))
+	k.Set("key", []byte("secret"), 0)
+
+	// SetEncryption refuses such keyring, but value could be corrupted as well
+	k.mu.Lock()
+	k.keyring = newTestKeyring(t, "k2", "k2")
+	k.mu.Unlock()
+
+	_, found, err := k.Get("key")
+	require.ErrorIs(t, err, keyring.ErrUnknownKey)
+	require.False(t, found)
+	_, err = k.MGet([]string{"key"})
+	require.ErrorIs(t, err, keyring.ErrUnknownKey)
+	_, _, err = k.Watch(context.Background(), "key", 0, time.Second)
+	require.ErrorIs(t, err, keyring.ErrUnknownKey)
+
+	// client gets error instead of empty value
+	h := NewHandler(k, HandlerOptions{})
+	rec := serveConditional(h, http.MethodGet, "/get?key=key", "")
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	srv := newV2Server(t, k)
+	resp := doV2(t, http.MethodGet, srv.URL+"/v2/keys/key", "")
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+}
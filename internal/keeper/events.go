@@ -0,0 +1,39 @@
+package keeper
+
+import (
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/aosderzhikov/sticky/internal/webhook"
+)
+
+// Subscribe returns subscriber of keyspace events or channel messages, it has to be unsubscribed.
+func (k *Keeper) Subscribe(f events.Filter) *events.Subscriber {
+	return k.events.Subscribe(f)
+}
+
+func (k *Keeper) Unsubscribe(s *events.Subscriber) {
+	k.events.Unsubscribe(s)
+}
+
+// Publish sends message to subscribers of channel and returns their number.
+// Channels dont intersect with keys.
+func (k *Keeper) Publish(channel string, data []byte) int {
+	return k.events.Publish(events.Event{Op: events.OpMessage, Channel: channel, Data: data, Time: time.Now()})
+}
+
+func (k *Keeper) RegisterWebhook(w webhook.Webhook) (webhook.Webhook, error) {
+	return k.webhooks.Register(w)
+}
+
+func (k *Keeper) UnregisterWebhook(id string) error {
+	return k.webhooks.Unregister(id)
+}
+
+func (k *Keeper) Webhooks() []webhook.Status {
+	return k.webhooks.List()
+}
+
+func (k *Keeper) notifyLocked(op events.Op, key string, version uint64) {
+	k.events.Publish(events.Event{Op: op, Key: key, Version: version, Time: time.Now()})
+}
@@ -0,0 +1,136 @@
+package keeper
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyspaceEvents(t *testing.T) {
+	k := NewService(10 * time.Second)
+	s := k.Subscribe(events.Filter{Pattern: "user:*"})
+	defer k.Unsubscribe(s)
+
+	k.Set("user:1", []byte("data"), 20*time.Millisecond)
+	k.Set("order:1", []byte("data"), 0)
+	_, err := k.Incr("user:2", 1, 0)
+	require.NoError(t, err)
+	k.Delete("user:2")
+	_, err = k.Do(typed.Command{Op: typed.SAdd, Key: "user:3", Member: "a"})
+	require.NoError(t, err)
+
+	time.Sleep(30 * time.Millisecond)
+	k.expire("user:1")
+
+	want := []struct {
+		op  events.Op
+		key string
+	}{
+		{events.OpSet, "user:1"},
+		{events.OpSet, "user:2"},
+		{events.OpDelete, "user:2"},
+		{events.OpSet, "user:3"},
+		{events.OpExpired, "user:1"},
+	}
+	for _, w := range want {
+		e := <-s.Events()
+		require.Equal(t, w.op, e.Op)
+		require.Equal(t, w.key, e.Key)
+		require.NotZero(t, e.Version)
+		require.WithinDuration(t, time.Now(), e.Time, time.Second)
+	}
+	require.Empty(t, s.Events())
+}
+
+func TestSubscribeHandle(t *testing.T) {
+	k := NewService(10 * time.Second)
+	h := NewHandler(k, HandlerOptions{})
+	srv := httptest.NewServer(http.HandlerFunc(h.SubscribeHandle))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?channel=news", http.NoBody)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	go func() {
+		require.Eventually(t, func() bool { return k.Publish("news", []byte("hello")) == 1 }, time.Second, 10*time.Millisecond)
+	}()
+
+	err = events.ReadStream(resp.Body, func(e events.Event) error {
+		require.Equal(t, events.OpMessage, e.Op)
+		require.Equal(t, "news", e.Channel)
+		require.Equal(t, "hello", string(e.Data))
+		cancel()
+		return nil
+	})
+	require.Error(t, err)
+}
+
+func TestPublishHandle(t *testing.T) {
+	h := NewHandler(NewService(10*time.Second), HandlerOptions{})
+
+	tests := []struct {
+		name string
+		url  string
+		code int
+	}{
+		{"published", "http://test?channel=news", http.StatusOK},
+		{"empty channel", "http://test", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, tt.url, bytes.NewReader([]byte("hello")))
+			require.NoError(t, err)
+			rec := httptest.NewRecorder()
+			h.PublishHandle(rec, req)
+			require.Equal(t, tt.code, rec.Result().StatusCode)
+		})
+	}
+}
+
+func TestWebhookHandles(t *testing.T) {
+	k := NewService(10 * time.Second)
+	h := NewHandler(k, HandlerOptions{})
+
+	body := bytes.NewReader([]byte(`{"id":"sessions","url":"http://localhost:1/hook","pattern":"session:*","ops":["expired"],"secret":"s"}`))
+	req, err := http.NewRequest(http.MethodPost, "http://test", body)
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h.RegisterWebhookHandle(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Result().StatusCode)
+	require.NotContains(t, rec.Body.String(), "secret")
+
+	body = bytes.NewReader([]byte(`{"id":"sessions","url":"http://localhost:1/hook"}`))
+	req, err = http.NewRequest(http.MethodPost, "http://test", body)
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	h.RegisterWebhookHandle(rec, req)
+	require.Equal(t, http.StatusConflict, rec.Result().StatusCode)
+
+	req, err = http.NewRequest(http.MethodGet, "http://test", http.NoBody)
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	h.WebhooksHandle(rec, req)
+	require.JSONEq(t, `[{"id":"sessions","url":"http://localhost:1/hook","pattern":"session:*","ops":["expired"],"stats":{"delivered":0,"retries":0,"deadLetters":0}}]`, rec.Body.String())
+
+	for _, code := range []int{http.StatusOK, http.StatusNotFound} {
+		req, err = http.NewRequest(http.MethodDelete, "http://test?id=sessions", http.NoBody)
+		require.NoError(t, err)
+		rec = httptest.NewRecorder()
+		h.UnregisterWebhookHandle(rec, req)
+		require.Equal(t, code, rec.Result().StatusCode)
+	}
+}
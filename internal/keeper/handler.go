@@ -2,26 +2,81 @@
 package keeper
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
-	"io"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/events"
 	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/lease"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/aosderzhikov/sticky/internal/ratelimit"
+	"github.com/aosderzhikov/sticky/internal/tx"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/aosderzhikov/sticky/internal/webhook"
 )
 
-func NewHandler(s Service) *Handler {
-	return &Handler{s}
+type HandlerOptions struct {
+	// MaxValueSize limits value of set request, zero is handler.DefaultMaxValueSize.
+	MaxValueSize int64
+	// UploadIdleTimeout limits waiting for next part of value, zero is handler.DefaultUploadIdleTimeout.
+	UploadIdleTimeout time.Duration
+}
+
+func NewHandler(s Service, opts HandlerOptions) *Handler {
+	if opts.MaxValueSize <= 0 {
+		opts.MaxValueSize = handler.DefaultMaxValueSize
+	}
+	return &Handler{s: s, opts: opts}
 }
 
 type Handler struct {
-	s Service
+	s    Service
+	opts HandlerOptions
 }
 
 type Service interface {
-	Get(key string) (value []byte)
+	Get(key string) (entry Entry, found bool, err error)
+	GetStored(key string) (entry Entry, found bool, err error)
 	Set(key string, value []byte, ttl time.Duration)
+	SetWithMeta(key string, value []byte, ttl time.Duration, m meta.Meta)
 	Delete(key string)
+
+	Expire(key string, ttl time.Duration) (found bool)
+	Persist(key string) (persisted bool)
+
+	MGet(keys []string) (entries map[string]Entry, err error)
+	MSet(items []batch.Item)
+	MDelete(keys []string)
+
+	Exec(t tx.Tx) (resp tx.Response)
+
+	Incr(key string, by int64, ttl time.Duration) (value int64, err error)
+	IncrFloat(key string, by float64, ttl time.Duration) (value float64, err error)
+
+	RateLimit(key string, p ratelimit.Params) (d ratelimit.Decision)
+
+	Lock(ctx context.Context, key, owner string, ttl, wait time.Duration) (l lease.Lease, err error)
+	Unlock(key, owner string) (err error)
+	Renew(key, owner string, ttl time.Duration) (l lease.Lease, err error)
+
+	Do(cmd typed.Command) (reply typed.Reply, err error)
+	BlockingPop(ctx context.Context, cmd typed.Command) (reply typed.Reply, err error)
+
+	Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (entry Entry, found bool, err error)
+
+	Subscribe(f events.Filter) (s *events.Subscriber)
+	Unsubscribe(s *events.Subscriber)
+	Publish(channel string, data []byte) (receivers int)
+
+	RegisterWebhook(w webhook.Webhook) (registered webhook.Webhook, err error)
+	UnregisterWebhook(id string) (err error)
+	Webhooks() (statuses []webhook.Status)
 }
 
 func (h *Handler) GetHandle(w http.ResponseWriter, r *http.Request) {
@@ -33,8 +88,56 @@ func (h *Handler) GetHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	value := h.s.Get(key)
-	_, _ = w.Write(value)
+	entry, found, err := h.s.GetStored(key)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		handler.ErrorHandle(ctx, w, handler.ErrKeyNotFound, http.StatusNotFound)
+		return
+	}
+	if entry.Type != "" {
+		handler.ErrorHandle(ctx, w, typed.ErrWrongType, http.StatusConflict)
+		return
+	}
+
+	// response depends on Accept-Encoding only if value is stored with gzip
+	vary := entry.Codec == CodecGzip
+	gzipped := vary && acceptsGzip(r)
+	etag := handler.ETag(entry.Version)
+	if gzipped {
+		etag = handler.GzipETag(entry.Version)
+	}
+	if vary {
+		w.Header().Set("Vary", "Accept-Encoding")
+	}
+	if handler.NotModifiedETag(r, etag, entry.UpdatedAt) {
+		handler.WriteNotModifiedETag(w, etag, entry.UpdatedAt)
+		return
+	}
+
+	if !gzipped {
+		if entry, err = entry.decompress(); err != nil {
+			handler.ErrorHandle(ctx, w, err, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if !entry.ExpiresAt.IsZero() {
+		handler.PutTTLHeader(w, time.Until(entry.ExpiresAt))
+	}
+	handler.PutVersionHeader(w, entry.Version)
+	handler.PutETagValidators(w, etag, entry.UpdatedAt)
+	handler.PutTimeHeader(w, handler.CreatedAtHeader, entry.CreatedAt)
+	handler.PutTimeHeader(w, handler.UpdatedAtHeader, entry.UpdatedAt)
+	entry.Meta.Put(w.Header())
+	if gzipped {
+		// compressed value is sent as is, client decompresses it
+		w.Header().Set("Content-Encoding", "gzip")
+	}
+	// validators are already put, so zero modtime keeps Last-Modified as is
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(entry.Data))
 }
 
 func (h *Handler) SetHandle(w http.ResponseWriter, r *http.Request) {
@@ -46,14 +149,28 @@ func (h *Handler) SetHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	value, err := io.ReadAll(r.Body)
+	m, err := meta.FromHeader(r.Header)
 	if err != nil {
-		err = errors.Join(handler.ErrBodyRead, err)
-		handler.ErrorHandle(ctx, w, err, http.StatusInternalServerError)
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	value, err := h.readValue(w, r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, handler.ValueErrorCode(err))
 		return
 	}
 
-	h.s.Set(key, value, ttl)
+	h.setIfMatch(w, r, key, value, ttl, m)
+}
+
+// readValue reads value of set request. Upload of large value can take longer than write timeout
+// of server, so it is limited only by idle timeout while body is read.
+func (h *Handler) readValue(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	restore := handler.LiftWriteDeadline(w)
+	defer restore()
+	handler.WatchUpload(w, r, h.opts.UploadIdleTimeout)
+	return handler.ReadValue(w, r, h.opts.MaxValueSize)
 }
 
 func (h *Handler) DeleteHandle(w http.ResponseWriter, r *http.Request) {
@@ -65,7 +182,373 @@ func (h *Handler) DeleteHandle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.s.Delete(key)
+	h.deleteIfMatch(w, r, key)
+}
+
+func (h *Handler) ExpireHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, ttl, err := handler.ExtractKeyAndTTL(r)
+	if err == nil && ttl <= 0 {
+		err = handler.ErrInvalidParam
+	}
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	if !h.s.Expire(key, ttl) {
+		handler.ErrorHandle(ctx, w, handler.ErrKeyNotFound, http.StatusNotFound)
+	}
+}
+
+func (h *Handler) PersistHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, err := handler.ExtractKey(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	if !h.s.Persist(key) {
+		handler.ErrorHandle(ctx, w, handler.ErrNotPersisted, http.StatusNotFound)
+	}
+}
+
+func (h *Handler) MGetHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, contentType, err := handler.ExtractBatch(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, handler.BatchErrorCode(err))
+		return
+	}
+
+	results, err := h.mget(req)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusInternalServerError)
+		return
+	}
+	_ = batch.WriteResponse(w, contentType, results)
+}
+
+func (h *Handler) mget(req batch.Request) ([]batch.Result, error) {
+	entries, err := h.s.MGet(req.Keys())
+	if err != nil {
+		return nil, err
+	}
+	return mgetResults(req, entries), nil
+}
+
+func mgetResults(req batch.Request, entries map[string]Entry) []batch.Result {
+	results := make([]batch.Result, 0, len(req.Items))
+	for _, item := range req.Items {
+		entry, found := entries[item.Key]
+		if !found {
+			results = append(results, batch.Result{Key: item.Key, Status: batch.StatusNotFound})
+			continue
+		}
+		if entry.Type != "" {
+			results = append(results, batch.ErrorResult(item.Key, typed.ErrWrongType))
+			continue
+		}
+		results = append(results, batch.Result{Key: item.Key, Status: batch.StatusOK, Value: entry.Data})
+	}
+	return results
+}
+
+func (h *Handler) MSetHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, contentType, err := handler.ExtractBatch(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, handler.BatchErrorCode(err))
+		return
+	}
+
+	h.s.MSet(req.Items)
+	_ = batch.WriteResponse(w, contentType, okResults(req))
+}
+
+func (h *Handler) MDeleteHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, contentType, err := handler.ExtractBatch(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, handler.BatchErrorCode(err))
+		return
+	}
+
+	h.s.MDelete(req.Keys())
+	_ = batch.WriteResponse(w, contentType, okResults(req))
+}
+
+func okResults(req batch.Request) []batch.Result {
+	results := make([]batch.Result, 0, len(req.Items))
+	for _, item := range req.Items {
+		results = append(results, batch.Result{Key: item.Key, Status: batch.StatusOK})
+	}
+	return results
+}
+
+func (h *Handler) TxHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	t, err := handler.ExtractTx(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	handler.WriteTxResponse(w, h.s.Exec(t))
+}
+
+func (h *Handler) IncrHandle(w http.ResponseWriter, r *http.Request) {
+	h.incr(w, r, 1)
+}
+
+func (h *Handler) DecrHandle(w http.ResponseWriter, r *http.Request) {
+	h.incr(w, r, -1)
+}
+
+func (h *Handler) incr(w http.ResponseWriter, r *http.Request, sign int64) {
+	ctx := r.Context()
+
+	key, ttl, err := handler.ExtractKeyAndTTL(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	by, err := handler.ExtractBy(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	value, err := h.s.Incr(key, sign*by, ttl)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusConflict)
+		return
+	}
+	_, _ = w.Write([]byte(strconv.FormatInt(value, 10)))
+}
+
+func (h *Handler) IncrFloatHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, ttl, err := handler.ExtractKeyAndTTL(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	by, err := handler.ExtractFloatBy(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	value, err := h.s.IncrFloat(key, by, ttl)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusConflict)
+		return
+	}
+	_, _ = w.Write([]byte(strconv.FormatFloat(value, 'f', -1, 64)))
+}
+
+func (h *Handler) RateLimitHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, p, err := handler.ExtractRateLimit(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	handler.WriteRateLimitResponse(w, p, h.s.RateLimit(key, p))
+}
+
+func (h *Handler) LockHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, owner, ttl, wait, err := handler.ExtractLock(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	handler.ExtendWriteDeadline(w, wait)
+	l, err := h.s.Lock(ctx, key, owner, ttl, wait)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, handler.LeaseErrorCode(err))
+		return
+	}
+	handler.WriteLease(w, l)
+}
+
+func (h *Handler) UnlockHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, owner, _, _, err := handler.ExtractLock(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	if err = h.s.Unlock(key, owner); err != nil {
+		handler.ErrorHandle(ctx, w, err, handler.LeaseErrorCode(err))
+		return
+	}
+}
+
+func (h *Handler) RenewHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, owner, ttl, _, err := handler.ExtractLock(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	l, err := h.s.Renew(key, owner, ttl)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, handler.LeaseErrorCode(err))
+		return
+	}
+	handler.WriteLease(w, l)
+}
+
+// TypedHandle returns handler of operation on typed value.
+func (h *Handler) TypedHandle(op typed.Op) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		cmd, err := handler.ExtractCommand(r, op)
+		if err != nil {
+			handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+			return
+		}
+
+		var reply typed.Reply
+		if cmd.Blocking() {
+			handler.ExtendWriteDeadline(w, cmd.Timeout)
+			reply, err = h.s.BlockingPop(ctx, cmd)
+		} else {
+			reply, err = h.s.Do(cmd)
+		}
+		if err != nil {
+			handler.ErrorHandle(ctx, w, err, handler.TypedErrorCode(err))
+			return
+		}
+		handler.WriteReply(w, reply)
+	}
+}
+
+// WatchHandle responds with value and version of changed key, 404 if key was deleted
+// or 304 if key isnt changed until timeout.
+func (h *Handler) WatchHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, sinceVersion, timeout, err := handler.ExtractWatch(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	handler.ExtendWriteDeadline(w, timeout)
+	entry, found, err := h.s.Watch(ctx, key, sinceVersion, timeout)
+	switch {
+	case errors.Is(err, ErrNotModified):
+		w.WriteHeader(http.StatusNotModified)
+		return
+	case err != nil:
+		handler.ErrorHandle(ctx, w, err, http.StatusInternalServerError)
+		return
+	case !found:
+		handler.ErrorHandle(ctx, w, handler.ErrKeyNotFound, http.StatusNotFound)
+		return
+	}
+
+	if !entry.ExpiresAt.IsZero() {
+		handler.PutTTLHeader(w, time.Until(entry.ExpiresAt))
+	}
+	handler.PutVersionHeader(w, entry.Version)
+	entry.Meta.Put(w.Header())
+	_, _ = w.Write(entry.Data)
+}
+
+func (h *Handler) SubscribeHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	f, err := handler.ExtractFilter(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	s := h.s.Subscribe(f)
+	defer h.s.Unsubscribe(s)
+	handler.ServeEvents(w, r, s.Events(), s.Err)
+}
+
+func (h *Handler) PublishHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	channel, data, err := handler.ExtractPublish(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	receivers := h.s.Publish(channel, data)
+	_, _ = w.Write([]byte(strconv.Itoa(receivers)))
+}
+
+func (h *Handler) WebhooksHandle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(h.s.Webhooks())
+}
+
+func (h *Handler) RegisterWebhookHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var hook webhook.Webhook
+	if err := json.NewDecoder(r.Body).Decode(&hook); err != nil {
+		handler.ErrorHandle(ctx, w, errors.Join(handler.ErrBodyRead, err), http.StatusBadRequest)
+		return
+	}
+
+	registered, err := h.s.RegisterWebhook(hook)
+	switch {
+	case errors.Is(err, webhook.ErrExist):
+		handler.ErrorHandle(ctx, w, err, http.StatusConflict)
+		return
+	case err != nil:
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	registered.Secret = ""
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(registered)
+}
+
+func (h *Handler) UnregisterWebhookHandle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		handler.ErrorHandle(ctx, w, handler.ErrEmptyID, http.StatusBadRequest)
+		return
+	}
+
+	if err := h.s.UnregisterWebhook(id); err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusNotFound)
+		return
+	}
 }
 
 func (h *Handler) HealthCheckHandle(w http.ResponseWriter, r *http.Request) {}
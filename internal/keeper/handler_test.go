@@ -1,12 +1,19 @@
 package keeper
 
 import (
+	"bufio"
 	"bytes"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/meta"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 )
@@ -44,19 +51,58 @@ func TestGetHandle(t *testing.T) {
 			serviceFunc: func(t *testing.T) Service {
 				ctrl := gomock.NewController(t)
 				service := NewMockService(ctrl)
-				service.EXPECT().Get("key1").Return([]byte("data"))
+				service.EXPECT().GetStored("key1").Return(Entry{Data: []byte("data"), ExpiresAt: time.Now().Add(time.Minute)}, true, nil)
 				return service
 			},
 			wantFunc: func(t *testing.T, rec *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, rec.Result().StatusCode)
 				require.Equal(t, rec.Body.String(), "data")
+
+				ttl, ok := handler.ExtractTTLHeader(rec.Header())
+				require.True(t, ok)
+				require.InDelta(t, time.Minute, ttl, float64(time.Second))
+			},
+		},
+		{
+			name: "get not found",
+			reqFunc: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodGet, "http://test?key=key1", http.NoBody)
+				require.NoError(t, err)
+				return req
+			},
+			serviceFunc: func(t *testing.T) Service {
+				ctrl := gomock.NewController(t)
+				service := NewMockService(ctrl)
+				service.EXPECT().GetStored("key1").Return(Entry{}, false, nil)
+				return service
+			},
+			wantFunc: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, rec.Result().StatusCode)
+			},
+		},
+		{
+			name: "get empty value",
+			reqFunc: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodGet, "http://test?key=key1", http.NoBody)
+				require.NoError(t, err)
+				return req
+			},
+			serviceFunc: func(t *testing.T) Service {
+				ctrl := gomock.NewController(t)
+				service := NewMockService(ctrl)
+				service.EXPECT().GetStored("key1").Return(Entry{Data: []byte{}, ExpiresAt: time.Now().Add(time.Minute)}, true, nil)
+				return service
+			},
+			wantFunc: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+				require.Empty(t, rec.Body.String())
 			},
 		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			h := NewHandler(c.serviceFunc(t))
+			h := NewHandler(c.serviceFunc(t), HandlerOptions{})
 			rec := httptest.NewRecorder()
 			h.GetHandle(rec, c.reqFunc(t))
 			c.wantFunc(t, rec)
@@ -98,13 +144,50 @@ func TestSetHandle(t *testing.T) {
 			serviceFunc: func(t *testing.T) Service {
 				ctrl := gomock.NewController(t)
 				service := NewMockService(ctrl)
-				service.EXPECT().Set("key1", []byte("data"), 5*time.Second)
+				service.EXPECT().SetWithMeta("key1", []byte("data"), 5*time.Second, meta.Meta{})
 				return service
 			},
 			wantFunc: func(t *testing.T, rec *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusOK, rec.Result().StatusCode)
 			},
 		},
+		{
+			name: "set with metadata",
+			reqFunc: func(t *testing.T) *http.Request {
+				body := bytes.NewReader([]byte("{}"))
+				req, err := http.NewRequest(http.MethodGet, "http://test?key=key1", body)
+				require.NoError(t, err)
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("X-Sticky-Meta-Owner", "team-a")
+				return req
+			},
+			serviceFunc: func(t *testing.T) Service {
+				ctrl := gomock.NewController(t)
+				service := NewMockService(ctrl)
+				m := meta.Meta{ContentType: "application/json", User: map[string]string{"Owner": "team-a"}}
+				service.EXPECT().SetWithMeta("key1", []byte("{}"), time.Duration(0), m)
+				return service
+			},
+			wantFunc: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+			},
+		},
+		{
+			name: "too large metadata",
+			reqFunc: func(t *testing.T) *http.Request {
+				req, err := http.NewRequest(http.MethodGet, "http://test?key=key1", http.NoBody)
+				require.NoError(t, err)
+				req.Header.Set("X-Sticky-Meta-Big", strings.Repeat("a", meta.MaxSize))
+				return req
+			},
+			serviceFunc: func(t *testing.T) Service {
+				ctrl := gomock.NewController(t)
+				return NewMockService(ctrl)
+			},
+			wantFunc: func(t *testing.T, rec *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+			},
+		},
 		{
 			name: "invalid ttl query param",
 			reqFunc: func(t *testing.T) *http.Request {
@@ -125,7 +208,7 @@ func TestSetHandle(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			h := NewHandler(c.serviceFunc(t))
+			h := NewHandler(c.serviceFunc(t), HandlerOptions{})
 			rec := httptest.NewRecorder()
 			h.SetHandle(rec, c.reqFunc(t))
 			c.wantFunc(t, rec)
@@ -177,10 +260,175 @@ func TestDeleteHandle(t *testing.T) {
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
-			h := NewHandler(c.serviceFunc(t))
+			h := NewHandler(c.serviceFunc(t), HandlerOptions{})
 			rec := httptest.NewRecorder()
 			h.DeleteHandle(rec, c.reqFunc(t))
 			c.wantFunc(t, rec)
 		})
 	}
 }
+
+func TestMGetHandle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := NewMockService(ctrl)
+	service.EXPECT().MGet([]string{"key1", "key2"}).Return(map[string]Entry{
+		"key1": {Data: []byte("data"), ExpiresAt: time.Now().Add(time.Minute)},
+	}, nil)
+
+	body := bytes.NewReader([]byte(`{"items":[{"key":"key1"},{"key":"key2"}]}`))
+	req, err := http.NewRequest(http.MethodPost, "http://test", body)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	NewHandler(service, HandlerOptions{}).MGetHandle(rec, req)
+	require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+
+	resp, err := batch.DecodeResponse(rec.Body, batch.ContentTypeJSON)
+	require.NoError(t, err)
+	require.Equal(t, []batch.Result{
+		{Key: "key1", Status: batch.StatusOK, Value: []byte("data")},
+		{Key: "key2", Status: batch.StatusNotFound},
+	}, resp.Results)
+}
+
+func TestMSetHandleInvalidBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	service := NewMockService(ctrl)
+
+	body := bytes.NewReader([]byte(`{"items":[]}`))
+	req, err := http.NewRequest(http.MethodPost, "http://test", body)
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	NewHandler(service, HandlerOptions{}).MSetHandle(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+}
+
+func TestSetHandleValueTooLarge(t *testing.T) {
+	k := NewService(time.Minute)
+	h := NewHandler(k, HandlerOptions{MaxValueSize: 4})
+
+	tests := []struct {
+		name   string
+		body   string
+		length int64
+		code   int
+	}{
+		{"at limit", "abcd", 4, http.StatusOK},
+		{"too large length", "abcde", 5, http.StatusRequestEntityTooLarge},
+		{"too large chunked", "abcde", -1, http.StatusRequestEntityTooLarge},
+		{"chunked at limit", "dcba", -1, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/set?key=key", strings.NewReader(tt.body))
+			req.ContentLength = tt.length
+			rec := httptest.NewRecorder()
+			h.SetHandle(rec, req)
+			require.Equal(t, tt.code, rec.Code)
+		})
+	}
+
+	entry, ok, _ := k.Get("key")
+	require.True(t, ok)
+	require.Equal(t, []byte("dcba"), entry.Data)
+}
+
+// newTimeoutServer starts server with timeouts of cmd/keeper.
+func newTimeoutServer(t *testing.T, h http.Handler) *httptest.Server {
+	srv := httptest.NewUnstartedServer(h)
+	srv.Config.ReadHeaderTimeout = 200 * time.Millisecond
+	srv.Config.WriteTimeout = 1 * time.Second
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// slowBody writes parts of value to pipe with delay between them.
+func slowBody(parts []string, delay time.Duration) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		for _, part := range parts {
+			time.Sleep(delay)
+			_, _ = pw.Write([]byte(part))
+		}
+		_ = pw.Close()
+	}()
+	return pr
+}
+
+func TestSetHandleSlowUpload(t *testing.T) {
+	k := NewService(time.Minute)
+	h := NewHandler(k, HandlerOptions{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /set", h.SetHandle)
+	mux.HandleFunc("PUT /v2/keys/{key...}", h.PutV2Handle)
+	srv := newTimeoutServer(t, mux)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		key    string
+		code   int
+	}{
+		{"set", http.MethodPost, "/set?key=key1", "key1", http.StatusOK},
+		{"put v2", http.MethodPut, "/v2/keys/key2", "key2", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// upload takes longer than write timeout of server
+			body := slowBody([]string{"slow", "ly", " uploaded"}, 500*time.Millisecond)
+			req, err := http.NewRequest(tt.method, srv.URL+tt.target, body)
+			require.NoError(t, err)
+
+			resp, err := srv.Client().Do(req)
+			require.NoError(t, err)
+			_ = resp.Body.Close()
+			require.Equal(t, tt.code, resp.StatusCode)
+
+			entry, ok, _ := k.Get(tt.key)
+			require.True(t, ok)
+			require.Equal(t, []byte("slowly uploaded"), entry.Data)
+		})
+	}
+}
+
+func TestSetHandleStalledUpload(t *testing.T) {
+	k := NewService(time.Minute)
+	h := NewHandler(k, HandlerOptions{UploadIdleTimeout: 300 * time.Millisecond})
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /set", h.SetHandle)
+	srv := newTimeoutServer(t, mux)
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// client sends part of value and stalls
+	_, err = conn.Write([]byte("POST /set?key=key HTTP/1.1\r\nHost: keeper\r\nContent-Length: 15\r\n\r\nslow"))
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(3*time.Second)))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, http.StatusRequestTimeout, resp.StatusCode)
+
+	_, ok, _ := k.Get("key")
+	require.False(t, ok)
+}
+
+func TestGetHandleRange(t *testing.T) {
+	k := NewService(time.Minute)
+	k.Set("key", []byte("0123456789"), 0)
+
+	req := httptest.NewRequest(http.MethodGet, "/get?key=key", http.NoBody)
+	req.Header.Set("Range", "bytes=-3")
+	rec := httptest.NewRecorder()
+	NewHandler(k, HandlerOptions{}).GetHandle(rec, req)
+
+	require.Equal(t, http.StatusPartialContent, rec.Code)
+	require.Equal(t, "bytes 7-9/10", rec.Header().Get("Content-Range"))
+	require.Equal(t, "789", rec.Body.String())
+}
@@ -0,0 +1,72 @@
+package keeper
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/scan"
+)
+
+// Expire sets new ttl of existing key, false if key doesnt exist.
+// Version of key isnt changed, because its value is the same.
+func (k *Keeper) Expire(key string, ttl time.Duration) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	val, ok := k.values[key]
+	if !ok || val.expired(time.Now()) {
+		return false
+	}
+
+	val.ttl.Stop()
+	val.ttl = time.NewTimer(ttl)
+	val.expiresAt = time.Now().Add(ttl)
+	k.values[key] = val
+
+	slog.Debug(fmt.Sprintf("expire key %q in %s", key, ttl))
+	return true
+}
+
+// Persist removes ttl of key, so it is kept until deleted or set again.
+// It returns false if key doesnt exist or already doesnt expire.
+func (k *Keeper) Persist(key string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	val, ok := k.values[key]
+	if !ok || val.expiresAt.IsZero() || val.expired(time.Now()) {
+		return false
+	}
+
+	val.ttl.Stop()
+	val.expiresAt = time.Time{}
+	k.values[key] = val
+
+	slog.Debug(fmt.Sprintf("persist key %q", key))
+	return true
+}
+
+// Scan returns up to count keys matched by pattern starting from cursor and cursor of the next call,
+// see scan.Page for guarantees of iteration.
+func (k *Keeper) Scan(cursor uint64, pattern string, count int) (uint64, []string) {
+	page := scan.New(cursor, pattern, count)
+	now := time.Now()
+
+	k.mu.RLock()
+	for key, val := range k.values {
+		if !val.expired(now) {
+			page.Add(key)
+		}
+	}
+	k.mu.RUnlock()
+
+	return page.Result()
+}
+
+// Len returns number of stored keys including expired but not yet removed ones.
+func (k *Keeper) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.values)
+}
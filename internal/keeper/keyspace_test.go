@@ -0,0 +1,105 @@
+package keeper
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpire(t *testing.T) {
+	k := NewService(time.Minute)
+	k.Set("key", []byte("v"), 0)
+	before, _, _ := k.Get("key")
+
+	require.True(t, k.Expire("key", 30*time.Millisecond))
+	require.False(t, k.Expire("absent", time.Second))
+
+	entry, found, _ := k.Get("key")
+	require.True(t, found)
+	require.Equal(t, before.Version, entry.Version)
+	require.WithinDuration(t, time.Now().Add(30*time.Millisecond), entry.ExpiresAt, 10*time.Millisecond)
+
+	time.Sleep(40 * time.Millisecond)
+	_, found, _ = k.Get("key")
+	require.False(t, found)
+}
+
+func TestPersist(t *testing.T) {
+	k := NewService(time.Minute)
+	k.Run()
+	k.Set("key", []byte("v"), 30*time.Millisecond)
+
+	require.True(t, k.Persist("key"))
+	require.False(t, k.Persist("key"), "key already doesnt expire")
+	require.False(t, k.Persist("absent"))
+
+	time.Sleep(50 * time.Millisecond)
+	entry, found, _ := k.Get("key")
+	require.True(t, found)
+	require.True(t, entry.ExpiresAt.IsZero())
+
+	// set gives ttl again
+	k.Set("key", []byte("v"), 0)
+	entry, _, _ = k.Get("key")
+	require.False(t, entry.ExpiresAt.IsZero())
+}
+
+func TestScan(t *testing.T) {
+	k := NewService(time.Minute)
+	for i := range 25 {
+		k.Set(fmt.Sprintf("user:%d", i), []byte("v"), 0)
+	}
+	k.Set("session:1", []byte("v"), 0)
+
+	var (
+		cursor uint64
+		keys   []string
+	)
+	for {
+		var page []string
+		cursor, page = k.Scan(cursor, "user:*", 10)
+		keys = append(keys, page...)
+		if cursor == 0 {
+			break
+		}
+	}
+	require.Len(t, keys, 25)
+	require.NotContains(t, keys, "session:1")
+}
+
+func TestExpireAndPersistHandles(t *testing.T) {
+	k := NewService(time.Minute)
+	k.Set("key", []byte("v"), 0)
+	h := NewHandler(k, HandlerOptions{})
+
+	cases := []struct {
+		name   string
+		handle http.HandlerFunc
+		url    string
+		want   int
+	}{
+		{"expire", h.ExpireHandle, "http://test?key=key&ttl=1h", http.StatusOK},
+		{"expire without ttl", h.ExpireHandle, "http://test?key=key", http.StatusBadRequest},
+		{"expire absent", h.ExpireHandle, "http://test?key=absent&ttl=1h", http.StatusNotFound},
+		{"persist", h.PersistHandle, "http://test?key=key", http.StatusOK},
+		{"persist again", h.PersistHandle, "http://test?key=key", http.StatusNotFound},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handle(rec, httptest.NewRequest(http.MethodPost, tt.url, http.NoBody))
+			require.Equal(t, tt.want, rec.Code)
+		})
+	}
+
+	// get of persisted key has no ttl header
+	rec := httptest.NewRecorder()
+	h.GetHandle(rec, httptest.NewRequest(http.MethodGet, "http://test?key=key", http.NoBody))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Empty(t, rec.Header().Get("X-Sticky-TTL"))
+}
@@ -0,0 +1,123 @@
+package keeper
+
+import (
+	"context"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/lease"
+)
+
+type lock struct {
+	lease.Lease
+	// released is closed when lock is unlocked, waiters try to acquire lock again.
+	released chan struct{}
+}
+
+func (l *lock) expired(now time.Time) bool {
+	return !now.Before(l.ExpiresAt)
+}
+
+// Lock acquires lock by key for owner. If lock is held by another owner Lock waits
+// until it is released or expired, but not longer than wait. Lock of the same owner is renewed.
+func (k *Keeper) Lock(ctx context.Context, key, owner string, ttl, wait time.Duration) (lease.Lease, error) {
+	if ttl == 0 {
+		ttl = k.defaultTTL
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		l, released, held := k.tryLock(key, owner, ttl)
+		if !held {
+			return l, nil
+		}
+
+		expiry := time.NewTimer(time.Until(l.ExpiresAt))
+		select {
+		case <-released:
+		case <-expiry.C:
+		case <-timer.C:
+			expiry.Stop()
+			return lease.Lease{}, lease.ErrLocked
+		case <-ctx.Done():
+			expiry.Stop()
+			return lease.Lease{}, ctx.Err()
+		}
+		expiry.Stop()
+	}
+}
+
+// tryLock returns acquired lease or lease held by another owner with channel closed on its release.
+func (k *Keeper) tryLock(key, owner string, ttl time.Duration) (lease.Lease, <-chan struct{}, bool) {
+	k.locksMu.Lock()
+	defer k.locksMu.Unlock()
+
+	now := time.Now()
+	l, ok := k.locks[key]
+	if ok && !l.expired(now) {
+		if l.Owner != owner {
+			return l.Lease, l.released, true
+		}
+		l.ExpiresAt = now.Add(ttl)
+		return l.Lease, nil, false
+	}
+
+	if ok {
+		close(l.released)
+	}
+
+	k.fencingToken++
+	l = &lock{
+		Lease:    lease.Lease{Key: key, Owner: owner, Token: k.fencingToken, ExpiresAt: now.Add(ttl)},
+		released: make(chan struct{}),
+	}
+	k.locks[key] = l
+	return l.Lease, nil, false
+}
+
+// Unlock releases lock only if it is held by owner.
+func (k *Keeper) Unlock(key, owner string) error {
+	k.locksMu.Lock()
+	defer k.locksMu.Unlock()
+
+	l, ok := k.locks[key]
+	if !ok || l.expired(time.Now()) || l.Owner != owner {
+		return lease.ErrNotOwner
+	}
+
+	delete(k.locks, key)
+	close(l.released)
+	return nil
+}
+
+// Renew extends lock held by owner, fencing token isnt changed.
+func (k *Keeper) Renew(key, owner string, ttl time.Duration) (lease.Lease, error) {
+	if ttl == 0 {
+		ttl = k.defaultTTL
+	}
+
+	k.locksMu.Lock()
+	defer k.locksMu.Unlock()
+
+	now := time.Now()
+	l, ok := k.locks[key]
+	if !ok || l.expired(now) || l.Owner != owner {
+		return lease.Lease{}, lease.ErrNotOwner
+	}
+
+	l.ExpiresAt = now.Add(ttl)
+	return l.Lease, nil
+}
+
+func (k *Keeper) cleanupLocks(now time.Time) {
+	k.locksMu.Lock()
+	defer k.locksMu.Unlock()
+
+	for key, l := range k.locks {
+		if l.expired(now) {
+			delete(k.locks, key)
+			close(l.released)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package keeper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/lease"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFencingToken(t *testing.T) {
+	k := NewService(10 * time.Second)
+	ctx := context.Background()
+
+	l1, err := k.Lock(ctx, "job", "a", time.Minute, 0)
+	require.NoError(t, err)
+
+	_, err = k.Lock(ctx, "job", "b", time.Minute, 0)
+	require.ErrorIs(t, err, lease.ErrLocked)
+
+	renewed, err := k.Lock(ctx, "job", "a", time.Minute, 0)
+	require.NoError(t, err)
+	require.Equal(t, l1.Token, renewed.Token)
+
+	require.NoError(t, k.Unlock("job", "a"))
+
+	l2, err := k.Lock(ctx, "job", "b", time.Minute, 0)
+	require.NoError(t, err)
+	require.Greater(t, l2.Token, l1.Token)
+}
+
+func TestLockWaitsForUnlock(t *testing.T) {
+	k := NewService(10 * time.Second)
+	ctx := context.Background()
+
+	_, err := k.Lock(ctx, "job", "a", time.Minute, 0)
+	require.NoError(t, err)
+
+	time.AfterFunc(20*time.Millisecond, func() { _ = k.Unlock("job", "a") })
+
+	l, err := k.Lock(ctx, "job", "b", time.Minute, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "b", l.Owner)
+}
+
+func TestLockWaitsForExpiry(t *testing.T) {
+	k := NewService(10 * time.Second)
+	ctx := context.Background()
+
+	_, err := k.Lock(ctx, "job", "a", 20*time.Millisecond, 0)
+	require.NoError(t, err)
+
+	l, err := k.Lock(ctx, "job", "b", time.Minute, time.Second)
+	require.NoError(t, err)
+	require.Equal(t, "b", l.Owner)
+}
+
+func TestLockWaitTimeout(t *testing.T) {
+	k := NewService(10 * time.Second)
+	ctx := context.Background()
+
+	_, err := k.Lock(ctx, "job", "a", time.Minute, 0)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = k.Lock(ctx, "job", "b", time.Minute, 30*time.Millisecond)
+	require.ErrorIs(t, err, lease.ErrLocked)
+	require.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+}
+
+func TestUnlockRenewNotOwner(t *testing.T) {
+	k := NewService(10 * time.Second)
+
+	_, err := k.Lock(context.Background(), "job", "a", time.Minute, 0)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, k.Unlock("job", "b"), lease.ErrNotOwner)
+	_, err = k.Renew("job", "b", time.Minute)
+	require.ErrorIs(t, err, lease.ErrNotOwner)
+
+	l, err := k.Renew("job", "a", time.Hour)
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), l.ExpiresAt, time.Second)
+}
+
+func TestLockHandle(t *testing.T) {
+	h := NewHandler(NewService(10*time.Second), HandlerOptions{})
+
+	tests := []struct {
+		name string
+		url  string
+		code int
+	}{
+		{"acquired", "http://test?key=job&owner=a&ttl=1m", http.StatusOK},
+		{"held by another owner", "http://test?key=job&owner=b", http.StatusConflict},
+		{"empty owner", "http://test?key=job", http.StatusBadRequest},
+		{"invalid wait", "http://test?key=job&owner=b&wait=-1s", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, tt.url, http.NoBody)
+			require.NoError(t, err)
+			rec := httptest.NewRecorder()
+			h.LockHandle(rec, req)
+			require.Equal(t, tt.code, rec.Result().StatusCode)
+		})
+	}
+}
@@ -10,9 +10,18 @@
 package keeper
 
 import (
+	context "context"
 	reflect "reflect"
 	time "time"
 
+	batch "github.com/aosderzhikov/sticky/internal/batch"
+	events "github.com/aosderzhikov/sticky/internal/events"
+	lease "github.com/aosderzhikov/sticky/internal/lease"
+	meta "github.com/aosderzhikov/sticky/internal/meta"
+	ratelimit "github.com/aosderzhikov/sticky/internal/ratelimit"
+	tx "github.com/aosderzhikov/sticky/internal/tx"
+	typed "github.com/aosderzhikov/sticky/internal/typed"
+	webhook "github.com/aosderzhikov/sticky/internal/webhook"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -39,6 +48,21 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 	return m.recorder
 }
 
+// BlockingPop mocks base method.
+func (m *MockService) BlockingPop(ctx context.Context, cmd typed.Command) (typed.Reply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockingPop", ctx, cmd)
+	ret0, _ := ret[0].(typed.Reply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BlockingPop indicates an expected call of BlockingPop.
+func (mr *MockServiceMockRecorder) BlockingPop(ctx, cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockingPop", reflect.TypeOf((*MockService)(nil).BlockingPop), ctx, cmd)
+}
+
 // Delete mocks base method.
 func (m *MockService) Delete(key string) {
 	m.ctrl.T.Helper()
@@ -51,12 +75,57 @@ func (mr *MockServiceMockRecorder) Delete(key any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockService)(nil).Delete), key)
 }
 
+// Do mocks base method.
+func (m *MockService) Do(cmd typed.Command) (typed.Reply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Do", cmd)
+	ret0, _ := ret[0].(typed.Reply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Do indicates an expected call of Do.
+func (mr *MockServiceMockRecorder) Do(cmd any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockService)(nil).Do), cmd)
+}
+
+// Exec mocks base method.
+func (m *MockService) Exec(t tx.Tx) tx.Response {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exec", t)
+	ret0, _ := ret[0].(tx.Response)
+	return ret0
+}
+
+// Exec indicates an expected call of Exec.
+func (mr *MockServiceMockRecorder) Exec(t any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exec", reflect.TypeOf((*MockService)(nil).Exec), t)
+}
+
+// Expire mocks base method.
+func (m *MockService) Expire(key string, ttl time.Duration) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Expire", key, ttl)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Expire indicates an expected call of Expire.
+func (mr *MockServiceMockRecorder) Expire(key, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Expire", reflect.TypeOf((*MockService)(nil).Expire), key, ttl)
+}
+
 // Get mocks base method.
-func (m *MockService) Get(key string) []byte {
+func (m *MockService) Get(key string) (Entry, bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Get", key)
-	ret0, _ := ret[0].([]byte)
-	return ret0
+	ret0, _ := ret[0].(Entry)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // Get indicates an expected call of Get.
@@ -65,6 +134,178 @@ func (mr *MockServiceMockRecorder) Get(key any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockService)(nil).Get), key)
 }
 
+// GetStored mocks base method.
+func (m *MockService) GetStored(key string) (Entry, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStored", key)
+	ret0, _ := ret[0].(Entry)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetStored indicates an expected call of GetStored.
+func (mr *MockServiceMockRecorder) GetStored(key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStored", reflect.TypeOf((*MockService)(nil).GetStored), key)
+}
+
+// Incr mocks base method.
+func (m *MockService) Incr(key string, by int64, ttl time.Duration) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Incr", key, by, ttl)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Incr indicates an expected call of Incr.
+func (mr *MockServiceMockRecorder) Incr(key, by, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Incr", reflect.TypeOf((*MockService)(nil).Incr), key, by, ttl)
+}
+
+// IncrFloat mocks base method.
+func (m *MockService) IncrFloat(key string, by float64, ttl time.Duration) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrFloat", key, by, ttl)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrFloat indicates an expected call of IncrFloat.
+func (mr *MockServiceMockRecorder) IncrFloat(key, by, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrFloat", reflect.TypeOf((*MockService)(nil).IncrFloat), key, by, ttl)
+}
+
+// Lock mocks base method.
+func (m *MockService) Lock(ctx context.Context, key, owner string, ttl, wait time.Duration) (lease.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, key, owner, ttl, wait)
+	ret0, _ := ret[0].(lease.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockServiceMockRecorder) Lock(ctx, key, owner, ttl, wait any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockService)(nil).Lock), ctx, key, owner, ttl, wait)
+}
+
+// MDelete mocks base method.
+func (m *MockService) MDelete(keys []string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MDelete", keys)
+}
+
+// MDelete indicates an expected call of MDelete.
+func (mr *MockServiceMockRecorder) MDelete(keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MDelete", reflect.TypeOf((*MockService)(nil).MDelete), keys)
+}
+
+// MGet mocks base method.
+func (m *MockService) MGet(keys []string) (map[string]Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MGet", keys)
+	ret0, _ := ret[0].(map[string]Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MGet indicates an expected call of MGet.
+func (mr *MockServiceMockRecorder) MGet(keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MGet", reflect.TypeOf((*MockService)(nil).MGet), keys)
+}
+
+// MSet mocks base method.
+func (m *MockService) MSet(items []batch.Item) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MSet", items)
+}
+
+// MSet indicates an expected call of MSet.
+func (mr *MockServiceMockRecorder) MSet(items any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MSet", reflect.TypeOf((*MockService)(nil).MSet), items)
+}
+
+// Persist mocks base method.
+func (m *MockService) Persist(key string) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Persist", key)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Persist indicates an expected call of Persist.
+func (mr *MockServiceMockRecorder) Persist(key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Persist", reflect.TypeOf((*MockService)(nil).Persist), key)
+}
+
+// Publish mocks base method.
+func (m *MockService) Publish(channel string, data []byte) int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", channel, data)
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockServiceMockRecorder) Publish(channel, data any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockService)(nil).Publish), channel, data)
+}
+
+// RateLimit mocks base method.
+func (m *MockService) RateLimit(key string, p ratelimit.Params) ratelimit.Decision {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RateLimit", key, p)
+	ret0, _ := ret[0].(ratelimit.Decision)
+	return ret0
+}
+
+// RateLimit indicates an expected call of RateLimit.
+func (mr *MockServiceMockRecorder) RateLimit(key, p any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RateLimit", reflect.TypeOf((*MockService)(nil).RateLimit), key, p)
+}
+
+// RegisterWebhook mocks base method.
+func (m *MockService) RegisterWebhook(w webhook.Webhook) (webhook.Webhook, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterWebhook", w)
+	ret0, _ := ret[0].(webhook.Webhook)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterWebhook indicates an expected call of RegisterWebhook.
+func (mr *MockServiceMockRecorder) RegisterWebhook(w any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterWebhook", reflect.TypeOf((*MockService)(nil).RegisterWebhook), w)
+}
+
+// Renew mocks base method.
+func (m *MockService) Renew(key, owner string, ttl time.Duration) (lease.Lease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Renew", key, owner, ttl)
+	ret0, _ := ret[0].(lease.Lease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Renew indicates an expected call of Renew.
+func (mr *MockServiceMockRecorder) Renew(key, owner, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Renew", reflect.TypeOf((*MockService)(nil).Renew), key, owner, ttl)
+}
+
 // Set mocks base method.
 func (m *MockService) Set(key string, value []byte, ttl time.Duration) {
 	m.ctrl.T.Helper()
@@ -76,3 +317,99 @@ func (mr *MockServiceMockRecorder) Set(key, value, ttl any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockService)(nil).Set), key, value, ttl)
 }
+
+// SetWithMeta mocks base method.
+func (m_2 *MockService) SetWithMeta(key string, value []byte, ttl time.Duration, m meta.Meta) {
+	m_2.ctrl.T.Helper()
+	m_2.ctrl.Call(m_2, "SetWithMeta", key, value, ttl, m)
+}
+
+// SetWithMeta indicates an expected call of SetWithMeta.
+func (mr *MockServiceMockRecorder) SetWithMeta(key, value, ttl, m any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWithMeta", reflect.TypeOf((*MockService)(nil).SetWithMeta), key, value, ttl, m)
+}
+
+// Subscribe mocks base method.
+func (m *MockService) Subscribe(f events.Filter) *events.Subscriber {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", f)
+	ret0, _ := ret[0].(*events.Subscriber)
+	return ret0
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockServiceMockRecorder) Subscribe(f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockService)(nil).Subscribe), f)
+}
+
+// Unlock mocks base method.
+func (m *MockService) Unlock(key, owner string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unlock", key, owner)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unlock indicates an expected call of Unlock.
+func (mr *MockServiceMockRecorder) Unlock(key, owner any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unlock", reflect.TypeOf((*MockService)(nil).Unlock), key, owner)
+}
+
+// UnregisterWebhook mocks base method.
+func (m *MockService) UnregisterWebhook(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnregisterWebhook", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UnregisterWebhook indicates an expected call of UnregisterWebhook.
+func (mr *MockServiceMockRecorder) UnregisterWebhook(id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnregisterWebhook", reflect.TypeOf((*MockService)(nil).UnregisterWebhook), id)
+}
+
+// Unsubscribe mocks base method.
+func (m *MockService) Unsubscribe(s *events.Subscriber) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Unsubscribe", s)
+}
+
+// Unsubscribe indicates an expected call of Unsubscribe.
+func (mr *MockServiceMockRecorder) Unsubscribe(s any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unsubscribe", reflect.TypeOf((*MockService)(nil).Unsubscribe), s)
+}
+
+// Watch mocks base method.
+func (m *MockService) Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (Entry, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, key, sinceVersion, timeout)
+	ret0, _ := ret[0].(Entry)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockServiceMockRecorder) Watch(ctx, key, sinceVersion, timeout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockService)(nil).Watch), ctx, key, sinceVersion, timeout)
+}
+
+// Webhooks mocks base method.
+func (m *MockService) Webhooks() []webhook.Status {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Webhooks")
+	ret0, _ := ret[0].([]webhook.Status)
+	return ret0
+}
+
+// Webhooks indicates an expected call of Webhooks.
+func (mr *MockServiceMockRecorder) Webhooks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Webhooks", reflect.TypeOf((*MockService)(nil).Webhooks))
+}
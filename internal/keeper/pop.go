@@ -0,0 +1,125 @@
+package keeper
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/typed"
+)
+
+// popWaiter is a client waiting for element of list, reply is buffered
+// so element is handed to waiter without blocking of keeper.
+type popWaiter struct {
+	op    typed.Op
+	reply chan typed.Reply
+	// expiresAt is expiry of list when element was handed over, it is set before reply is sent.
+	expiresAt time.Time
+}
+
+// BlockingPop pops element of list, if list is empty it waits for element until timeout
+// of command elapses or ctx is cancelled. Waiters of the same key are served in FIFO order.
+func (k *Keeper) BlockingPop(ctx context.Context, cmd typed.Command) (typed.Reply, error) {
+	k.mu.Lock()
+	reply, err := k.doLocked(cmd)
+	if !errors.Is(err, typed.ErrNotFound) || !cmd.Blocking() {
+		k.mu.Unlock()
+		return reply, err
+	}
+
+	w := &popWaiter{op: cmd.Op, reply: make(chan typed.Reply, 1)}
+	k.popWaiters[cmd.Key] = append(k.popWaiters[cmd.Key], w)
+	k.mu.Unlock()
+
+	timer := time.NewTimer(cmd.Timeout)
+	defer timer.Stop()
+
+	select {
+	case reply = <-w.reply:
+		return reply, nil
+	case <-timer.C:
+		err = typed.ErrNotFound
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.removePopWaiterLocked(cmd.Key, w) {
+		return typed.Reply{}, err
+	}
+
+	// element was handed to waiter concurrently, it is returned to list for another client
+	reply = <-w.reply
+	if errors.Is(err, typed.ErrNotFound) {
+		return reply, nil
+	}
+	k.returnElementLocked(cmd, reply.Value, w.expiresAt)
+	return typed.Reply{}, err
+}
+
+// returnElementLocked pushes element of cancelled waiter back to its end of list. List could be
+// removed after its last element was handed over, then it is created again with its original expiry.
+func (k *Keeper) returnElementLocked(cmd typed.Command, element []byte, expiresAt time.Time) {
+	push := typed.Command{Op: typed.LPush, Key: cmd.Key, Value: element}
+	if cmd.Op == typed.RPop {
+		push.Op = typed.RPush
+	}
+
+	now := time.Now()
+	val, ok := k.values[cmd.Key]
+	recreated := !ok || val.expired(now)
+	if recreated && !expiresAt.IsZero() {
+		if !now.Before(expiresAt) {
+			// list would have expired with element by now
+			return
+		}
+		push.TTL = expiresAt.Sub(now)
+	}
+
+	if _, err := k.doLocked(push); err != nil || !recreated || !expiresAt.IsZero() {
+		return
+	}
+	// list didnt expire, element could be handed to another waiter already
+	if val, ok = k.values[cmd.Key]; ok {
+		val.ttl.Stop()
+		val.expiresAt = time.Time{}
+		k.values[cmd.Key] = val
+	}
+}
+
+// servePopWaitersLocked hands elements of list to waiters in order of their arrival.
+func (k *Keeper) servePopWaitersLocked(key string, l *list) {
+	waiters := k.popWaiters[key]
+	served := 0
+	for ; served < len(waiters) && len(l.items) > 0; served++ {
+		w := waiters[served]
+		reply, _ := write(l, typed.Command{Op: w.op, Key: key})
+		reply.Exists = len(l.items) > 0
+		w.expiresAt = k.values[key].expiresAt
+		w.reply <- reply
+	}
+
+	if served == len(waiters) {
+		delete(k.popWaiters, key)
+		return
+	}
+	k.popWaiters[key] = waiters[served:]
+}
+
+func (k *Keeper) removePopWaiterLocked(key string, w *popWaiter) bool {
+	waiters := k.popWaiters[key]
+	i := slices.Index(waiters, w)
+	if i == -1 {
+		return false
+	}
+
+	waiters = slices.Delete(waiters, i, i+1)
+	if len(waiters) == 0 {
+		delete(k.popWaiters, key)
+		return true
+	}
+	k.popWaiters[key] = waiters
+	return true
+}
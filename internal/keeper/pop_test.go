@@ -0,0 +1,139 @@
+package keeper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/stretchr/testify/require"
+)
+
+func waitPopWaiters(t *testing.T, k *Keeper, key string, n int) {
+	require.Eventually(t, func() bool {
+		k.mu.RLock()
+		defer k.mu.RUnlock()
+		return len(k.popWaiters[key]) == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestBlockingPopFIFO(t *testing.T) {
+	k := NewService(10 * time.Second)
+	pop := typed.Command{Op: typed.LPop, Key: "queue", Timeout: time.Second}
+
+	results := make([]chan string, 3)
+	for i := range results {
+		results[i] = make(chan string, 1)
+		go func() {
+			reply, err := k.BlockingPop(context.Background(), pop)
+			require.NoError(t, err)
+			results[i] <- string(reply.Value)
+		}()
+		waitPopWaiters(t, k, "queue", i+1)
+	}
+
+	for _, job := range []string{"job1", "job2", "job3"} {
+		_, err := k.Do(typed.Command{Op: typed.RPush, Key: "queue", Value: []byte(job)})
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, "job1", <-results[0])
+	require.Equal(t, "job2", <-results[1])
+	require.Equal(t, "job3", <-results[2])
+
+	_, found, _ := k.Get("queue")
+	require.False(t, found, "empty list isnt removed")
+	require.Equal(t, int64(0), k.Memory())
+}
+
+func TestBlockingPopTimeout(t *testing.T) {
+	k := NewService(10 * time.Second)
+
+	start := time.Now()
+	_, err := k.BlockingPop(context.Background(), typed.Command{Op: typed.RPop, Key: "queue", Timeout: 30 * time.Millisecond})
+	require.ErrorIs(t, err, typed.ErrNotFound)
+	require.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+	waitPopWaiters(t, k, "queue", 0)
+}
+
+func TestBlockingPopCancel(t *testing.T) {
+	k := NewService(10 * time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := k.BlockingPop(ctx, typed.Command{Op: typed.LPop, Key: "queue", Timeout: time.Minute})
+		done <- err
+	}()
+	waitPopWaiters(t, k, "queue", 1)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	waitPopWaiters(t, k, "queue", 0)
+
+	_, err := k.Do(typed.Command{Op: typed.RPush, Key: "queue", Value: []byte("job1")})
+	require.NoError(t, err)
+	reply, err := k.Do(typed.Command{Op: typed.LRange, Key: "queue", Stop: -1})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("job1")}, reply.Values, "element is handed to cancelled waiter")
+}
+
+func TestBlockingPopHandle(t *testing.T) {
+	k := NewService(10 * time.Second)
+	h := NewHandler(k, HandlerOptions{})
+
+	go func() {
+		waitPopWaiters(t, k, "queue", 1)
+		_, _ = k.Do(typed.Command{Op: typed.RPush, Key: "queue", Value: []byte("job1")})
+	}()
+
+	req, err := http.NewRequest(http.MethodPost, "http://test?key=queue&timeout=1s", http.NoBody)
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h.TypedHandle(typed.LPop)(rec, req)
+	require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	require.JSONEq(t, `{"value":"am9iMQ==","count":0,"exists":false}`, rec.Body.String())
+}
+
+func TestReturnElementKeepsExpiry(t *testing.T) {
+	k := NewService(10 * time.Second)
+	pop := typed.Command{Op: typed.LPop, Key: "queue"}
+	expiresAt := time.Now().Add(time.Hour)
+
+	// list was removed after its last element was handed over
+	k.mu.Lock()
+	k.returnElementLocked(pop, []byte("job"), expiresAt)
+	k.mu.Unlock()
+	entry, ok, _ := k.Get("queue")
+	require.True(t, ok)
+	require.WithinDuration(t, expiresAt, entry.ExpiresAt, 10*time.Millisecond)
+
+	// list which doesnt expire is created again without expiry
+	k.Delete("queue")
+	k.mu.Lock()
+	k.returnElementLocked(pop, []byte("job"), time.Time{})
+	k.mu.Unlock()
+	entry, ok, _ = k.Get("queue")
+	require.True(t, ok)
+	require.True(t, entry.ExpiresAt.IsZero())
+
+	// element goes back to existing list, ttl of list isnt changed
+	k.mu.Lock()
+	k.returnElementLocked(pop, []byte("first"), expiresAt)
+	k.mu.Unlock()
+	reply, err := k.Do(typed.Command{Op: typed.LRange, Key: "queue", Stop: -1})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("first"), []byte("job")}, reply.Values)
+	entry, _, _ = k.Get("queue")
+	require.True(t, entry.ExpiresAt.IsZero())
+
+	// list would have expired already, so element is dropped
+	k.Delete("queue")
+	k.mu.Lock()
+	k.returnElementLocked(pop, []byte("job"), time.Now().Add(-time.Second))
+	k.mu.Unlock()
+	_, ok, _ = k.Get("queue")
+	require.False(t, ok)
+}
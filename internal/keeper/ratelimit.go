@@ -0,0 +1,32 @@
+package keeper
+
+import (
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/ratelimit"
+)
+
+// RateLimit atomically evaluates request to rate limit by key.
+func (k *Keeper) RateLimit(key string, p ratelimit.Params) ratelimit.Decision {
+	k.limitersMu.Lock()
+	defer k.limitersMu.Unlock()
+
+	state, ok := k.limiters[key]
+	if !ok {
+		state = &ratelimit.State{}
+		k.limiters[key] = state
+	}
+	return state.Take(p, time.Now())
+}
+
+// cleanupLimiters removes states which are the same as new ones.
+func (k *Keeper) cleanupLimiters(now time.Time) {
+	k.limitersMu.Lock()
+	defer k.limitersMu.Unlock()
+
+	for key, state := range k.limiters {
+		if !now.Before(state.ExpiresAt()) {
+			delete(k.limiters, key)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package keeper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/ratelimit"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimit(t *testing.T) {
+	k := NewService(10 * time.Second)
+	p := ratelimit.Params{Algorithm: ratelimit.SlidingWindow, Limit: 2, Window: time.Minute, Cost: 1}
+
+	require.True(t, k.RateLimit("user42", p).Allowed)
+	require.True(t, k.RateLimit("user42", p).Allowed)
+	require.False(t, k.RateLimit("user42", p).Allowed)
+	require.True(t, k.RateLimit("user43", p).Allowed)
+
+	_, found, _ := k.Get("user42")
+	require.False(t, found, "rate limit is visible as value")
+}
+
+func TestRateLimitHandle(t *testing.T) {
+	h := NewHandler(NewService(10*time.Second), HandlerOptions{})
+
+	do := func() *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodPost, "http://test?key=user42&limit=1&window=1m&algorithm=token_bucket", http.NoBody)
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		h.RateLimitHandle(rec, req)
+		return rec
+	}
+
+	rec := do()
+	require.Equal(t, http.StatusOK, rec.Result().StatusCode)
+	require.Equal(t, "0", rec.Header().Get(handler.RateLimitRemainingHeader))
+
+	rec = do()
+	require.Equal(t, http.StatusTooManyRequests, rec.Result().StatusCode)
+	require.Equal(t, "60", rec.Header().Get("Retry-After"))
+
+	d, err := handler.ExtractRateLimitDecision(rec.Result())
+	require.NoError(t, err)
+	require.False(t, d.Allowed)
+	require.InDelta(t, time.Minute, d.RetryAfter, float64(time.Second))
+}
+
+func TestRateLimitHandleInvalidParams(t *testing.T) {
+	h := NewHandler(NewService(10*time.Second), HandlerOptions{})
+
+	req, err := http.NewRequest(http.MethodPost, "http://test?key=user42&limit=1&window=1m&cost=2", http.NoBody)
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h.RateLimitHandle(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Result().StatusCode)
+}
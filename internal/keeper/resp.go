@@ -0,0 +1,138 @@
+package keeper
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/resp"
+	"github.com/aosderzhikov/sticky/internal/typed"
+)
+
+func NewRESPBackend(k *Keeper) *RESPBackend {
+	return &RESPBackend{k}
+}
+
+// RESPBackend executes commands of RESP clients by keeper.
+type RESPBackend struct {
+	k *Keeper
+}
+
+func (b *RESPBackend) Get(_ context.Context, key string) ([]byte, bool, error) {
+	entry, found, err := b.k.Get(key)
+	if err != nil {
+		return nil, false, err
+	}
+	if found && entry.Type != "" {
+		return nil, false, typed.ErrWrongType
+	}
+	return entry.Data, found, nil
+}
+
+func (b *RESPBackend) Set(_ context.Context, key string, value []byte, ttl time.Duration, cond resp.Condition) (bool, error) {
+	k := b.k
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	val, ok := k.values[key]
+	exists := ok && !val.expired(time.Now())
+	if (cond == resp.IfAbsent && exists) || (cond == resp.IfPresent && !exists) {
+		return false, nil
+	}
+
+	k.setLocked(key, value, ttl)
+	return true, nil
+}
+
+// Delete returns number of keys which existed before delete.
+func (b *RESPBackend) Delete(_ context.Context, keys []string) (int, error) {
+	k := b.k
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	deleted := 0
+	for _, key := range keys {
+		if val, ok := k.values[key]; ok && !val.expired(now) {
+			deleted++
+		}
+		k.deleteLocked(key)
+	}
+	return deleted, nil
+}
+
+func (b *RESPBackend) Expire(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	return b.k.Expire(key, ttl), nil
+}
+
+func (b *RESPBackend) Persist(_ context.Context, key string) (bool, error) {
+	return b.k.Persist(key), nil
+}
+
+func (b *RESPBackend) TTL(_ context.Context, key string) (time.Duration, bool, error) {
+	entry, found, err := b.k.Get(key)
+	if err != nil {
+		return 0, false, err
+	}
+	if !found {
+		return 0, false, nil
+	}
+	if entry.ExpiresAt.IsZero() {
+		return -1, true, nil
+	}
+	return max(time.Until(entry.ExpiresAt), 0), true, nil
+}
+
+func (b *RESPBackend) Incr(_ context.Context, key string) (int64, error) {
+	value, err := b.k.Incr(key, 1, 0)
+	if errors.Is(err, ErrNotNumber) || errors.Is(err, ErrOverflow) {
+		return 0, resp.ErrNotInteger
+	}
+	return value, err
+}
+
+// MGet returns nil for typed values the same as for absent keys.
+func (b *RESPBackend) MGet(_ context.Context, keys []string) ([][]byte, error) {
+	entries, err := b.k.MGet(keys)
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		entry, found := entries[key]
+		switch {
+		case !found || entry.Type != "":
+			values = append(values, nil)
+		case entry.Data == nil:
+			values = append(values, []byte{})
+		default:
+			values = append(values, entry.Data)
+		}
+	}
+	return values, nil
+}
+
+func (b *RESPBackend) MSet(_ context.Context, items []batch.Item) error {
+	b.k.MSet(items)
+	return nil
+}
+
+func (b *RESPBackend) Scan(_ context.Context, cursor uint64, pattern string, count int) (uint64, []string, error) {
+	next, keys := b.k.Scan(cursor, pattern, count)
+	return next, keys, nil
+}
+
+func (b *RESPBackend) Info(_ context.Context) map[string]string {
+	compression := b.k.CompressionStats()
+	return map[string]string{
+		"role":        "keeper",
+		"keys":        strconv.Itoa(b.k.Len()),
+		"used_memory": strconv.FormatInt(b.k.Memory(), 10),
+		// used_memory counts compressed values by compressed size
+		"compressed_values":    strconv.Itoa(compression.Values),
+		"compressed_raw_bytes": strconv.FormatInt(compression.RawSize, 10),
+		"compression_ratio":    strconv.FormatFloat(compression.Ratio(), 'f', 2, 64),
+	}
+}
@@ -0,0 +1,75 @@
+package keeper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/resp"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRESPBackendSetConditions(t *testing.T) {
+	ctx := context.Background()
+	b := NewRESPBackend(NewService(time.Minute))
+
+	stored, err := b.Set(ctx, "key", []byte("1"), 0, resp.IfPresent)
+	require.NoError(t, err)
+	require.False(t, stored)
+
+	stored, err = b.Set(ctx, "key", []byte("2"), 0, resp.IfAbsent)
+	require.NoError(t, err)
+	require.True(t, stored)
+
+	stored, err = b.Set(ctx, "key", []byte("3"), 0, resp.IfAbsent)
+	require.NoError(t, err)
+	require.False(t, stored)
+
+	stored, err = b.Set(ctx, "key", []byte("4"), time.Second, resp.IfPresent)
+	require.NoError(t, err)
+	require.True(t, stored)
+
+	value, found, err := b.Get(ctx, "key")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, []byte("4"), value)
+}
+
+func TestRESPBackendKeyspace(t *testing.T) {
+	ctx := context.Background()
+	k := NewService(time.Minute)
+	b := NewRESPBackend(k)
+
+	k.Set("a", []byte("1"), 0)
+	k.Set("b", nil, 0)
+	_, err := k.Do(typed.Command{Op: typed.HSet, Key: "hash", Field: "f", Value: []byte("v")})
+	require.NoError(t, err)
+
+	_, _, err = b.Get(ctx, "hash")
+	require.ErrorIs(t, err, typed.ErrWrongType)
+
+	values, err := b.MGet(ctx, []string{"a", "b", "hash", "absent"})
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("1"), {}, nil, nil}, values)
+
+	ttl, found, err := b.TTL(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	persisted, err := b.Persist(ctx, "a")
+	require.NoError(t, err)
+	require.True(t, persisted)
+
+	ttl, _, err = b.TTL(ctx, "a")
+	require.NoError(t, err)
+	require.Negative(t, ttl)
+
+	_, err = b.Incr(ctx, "hash")
+	require.ErrorIs(t, err, resp.ErrNotInteger)
+
+	deleted, err := b.Delete(ctx, []string{"a", "b", "absent"})
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+}
@@ -0,0 +1,100 @@
+package keeper
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/rpc"
+	"github.com/aosderzhikov/sticky/internal/typed"
+)
+
+func NewRPCBackend(k *Keeper) *RPCBackend {
+	return &RPCBackend{k}
+}
+
+// RPCBackend executes grpc requests by keeper.
+type RPCBackend struct {
+	k *Keeper
+}
+
+func (b *RPCBackend) Get(_ context.Context, key string) (rpc.Entry, error) {
+	entry, found, err := b.k.Get(key)
+	if err != nil {
+		return rpc.Entry{}, err
+	}
+	if !found {
+		return rpc.Entry{}, rpc.ErrNotFound
+	}
+	if entry.Type != "" {
+		return rpc.Entry{}, rpc.Error(http.StatusConflict, typed.ErrWrongType)
+	}
+	return rpcEntry(entry), nil
+}
+
+func (b *RPCBackend) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	b.k.Set(key, value, ttl)
+	return nil
+}
+
+func (b *RPCBackend) Delete(_ context.Context, key string) error {
+	b.k.Delete(key)
+	return nil
+}
+
+func (b *RPCBackend) TTL(_ context.Context, key string) (time.Duration, error) {
+	entry, found, err := b.k.Get(key)
+	if err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, rpc.ErrNotFound
+	}
+	return rpcEntry(entry).TTL, nil
+}
+
+func (b *RPCBackend) MGet(_ context.Context, keys []string) ([]batch.Result, error) {
+	entries, err := b.k.MGet(keys)
+	if err != nil {
+		return nil, err
+	}
+	return mgetResults(batch.KeysRequest(keys), entries), nil
+}
+
+func (b *RPCBackend) MSet(_ context.Context, items []batch.Item) ([]batch.Result, error) {
+	b.k.MSet(items)
+	return okResults(batch.Request{Items: items}), nil
+}
+
+func (b *RPCBackend) MDelete(_ context.Context, keys []string) ([]batch.Result, error) {
+	b.k.MDelete(keys)
+	return okResults(batch.KeysRequest(keys)), nil
+}
+
+func (b *RPCBackend) Scan(_ context.Context, cursor uint64, pattern string, count int) (uint64, []string, error) {
+	next, keys := b.k.Scan(cursor, pattern, count)
+	return next, keys, nil
+}
+
+func (b *RPCBackend) Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (rpc.Entry, error) {
+	entry, found, err := b.k.Watch(ctx, key, sinceVersion, timeout)
+	switch {
+	case errors.Is(err, ErrNotModified):
+		return rpc.Entry{}, rpc.ErrNotModified
+	case err != nil:
+		return rpc.Entry{}, err
+	case !found:
+		return rpc.Entry{}, rpc.ErrNotFound
+	}
+	return rpcEntry(entry), nil
+}
+
+func rpcEntry(entry Entry) rpc.Entry {
+	ttl := time.Duration(-1)
+	if !entry.ExpiresAt.IsZero() {
+		ttl = max(time.Until(entry.ExpiresAt), 0)
+	}
+	return rpc.Entry{Value: entry.Data, Version: entry.Version, TTL: ttl}
+}
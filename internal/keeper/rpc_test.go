@@ -0,0 +1,94 @@
+package keeper
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/api/stickypb"
+	"github.com/aosderzhikov/sticky/internal/rpc"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func newRPCClient(t *testing.T, k *Keeper) stickypb.StickyClient {
+	l := bufconn.Listen(1 << 20)
+	srv := rpc.NewServer(NewRPCBackend(k))
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return stickypb.NewStickyClient(conn)
+}
+
+func TestRPC(t *testing.T) {
+	ctx := context.Background()
+	k := NewService(time.Minute)
+	c := newRPCClient(t, k)
+
+	_, err := c.Set(ctx, &stickypb.SetRequest{Key: "key", Value: []byte("value"), Ttl: durationpb.New(time.Second)})
+	require.NoError(t, err)
+
+	resp, err := c.Get(ctx, &stickypb.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), resp.GetValue())
+	stored, _, _ := k.Get("key")
+	require.Equal(t, stored.Version, resp.GetVersion())
+	require.InDelta(t, time.Second, resp.GetTtl().AsDuration(), float64(100*time.Millisecond))
+
+	k.Persist("key")
+	ttl, err := c.TTL(ctx, &stickypb.TTLRequest{Key: "key"})
+	require.NoError(t, err)
+	require.Nil(t, ttl.GetTtl())
+
+	_, err = c.Delete(ctx, &stickypb.DeleteRequest{Key: "key"})
+	require.NoError(t, err)
+
+	_, err = c.Get(ctx, &stickypb.GetRequest{Key: "key"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = k.Do(typed.Command{Op: typed.HSet, Key: "hash", Field: "f", Value: []byte("v")})
+	require.NoError(t, err)
+	_, err = c.Get(ctx, &stickypb.GetRequest{Key: "hash"})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestRPCWatch(t *testing.T) {
+	k := NewService(time.Minute)
+	c := newRPCClient(t, k)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := c.Watch(ctx, &stickypb.WatchRequest{Key: "key"})
+	require.NoError(t, err)
+
+	k.Set("key", []byte("1"), 0)
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), event.GetValue())
+
+	k.Delete("key")
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	require.True(t, event.GetDeleted())
+
+	k.Set("key", []byte("2"), 0)
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, []byte("2"), event.GetValue())
+}
@@ -5,13 +5,33 @@ import (
 	"log/slog"
 	"maps"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/aosderzhikov/sticky/internal/keyring"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/aosderzhikov/sticky/internal/ratelimit"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/aosderzhikov/sticky/internal/webhook"
 )
 
 func NewService(ttl time.Duration) *Keeper {
+	broker := events.NewBroker(events.Options{})
 	return &Keeper{
+		events:     broker,
+		webhooks:   webhook.NewDispatcher(broker, webhook.Options{}),
 		values:     make(map[string]value),
+		popWaiters: make(map[string][]*popWaiter),
 		defaultTTL: ttl,
+		limiters:   make(map[string]*ratelimit.State),
+		locks:      make(map[string]*lock),
+		keyIDs:     make(map[string]int),
+		// versions and fencing tokens start from current time, so they keep growing after restart
+		// and versions of different keepers dont repeat each other
+		version:      uint64(time.Now().UnixNano()),
+		fencingToken: uint64(time.Now().UnixNano()),
 	}
 }
 
@@ -19,50 +39,315 @@ type Keeper struct {
 	mu         sync.RWMutex
 	values     map[string]value
 	defaultTTL time.Duration
+	// version is incremented on each set, so every stored value has unique version.
+	version uint64
+	// memory is approximate size of keys and values in bytes, compressed values are counted by compressed size.
+	memory     int64
+	compressed CompressionStats
+	// compression is nil if values arent compressed.
+	compression atomic.Pointer[CompressionOptions]
+	// keyring is nil if values arent encrypted, keyIDs are numbers of values by ids of their keys.
+	keyring *keyring.Keyring
+	keyIDs  map[string]int
+	// popWaiters are clients waiting for elements of lists by key.
+	popWaiters map[string][]*popWaiter
+	// events are published under mu, so subscribers get changes of key in order.
+	events   *events.Broker
+	webhooks *webhook.Dispatcher
+
+	// limiters are rate limits states, keys of them dont intersect with values.
+	limitersMu sync.Mutex
+	limiters   map[string]*ratelimit.State
+
+	// locks keys dont intersect with values.
+	locksMu      sync.Mutex
+	locks        map[string]*lock
+	fencingToken uint64
 }
 
 type value struct {
 	key  string
 	data []byte
 	ttl  *time.Timer
+	// expiresAt is zero if value doesnt expire, its ttl timer is stopped then.
+	expiresAt time.Time
+	version   uint64
+	// createdAt is kept when existing key is set again, updatedAt is changed with version.
+	createdAt time.Time
+	updatedAt time.Time
+	// meta is replaced on each set, so value set without metadata has none.
+	meta meta.Meta
+	// codec is empty if data isnt compressed, rawSize is size of data before compression.
+	codec   Codec
+	rawSize int
+	// keyID is empty if data isnt encrypted, data is compressed before encryption.
+	keyID string
+	// obj is nil for string values.
+	obj container
+}
+
+// entry returns value with decrypted and decompressed data, kr is keyring of keeper when value was read.
+func (v value) entry(kr *keyring.Keyring) (Entry, error) {
+	entry, err := v.storedEntry(kr)
+	if err != nil {
+		return Entry{}, err
+	}
+	if entry, err = entry.decompress(); err != nil {
+		return Entry{}, fmt.Errorf("decompress value of key %q: %w", v.key, err)
+	}
+	return entry, nil
+}
+
+// storedEntry returns decrypted value, its data is compressed if Codec isnt empty.
+// Value which cant be decrypted isnt returned, so client doesnt get it empty.
+func (v value) storedEntry(kr *keyring.Keyring) (Entry, error) {
+	data, err := v.open(kr)
+	if err != nil {
+		return Entry{}, fmt.Errorf("decrypt value of key %q: %w", v.key, err)
+	}
+
+	entry := Entry{
+		Data:      data,
+		ExpiresAt: v.expiresAt,
+		Version:   v.version,
+		CreatedAt: v.createdAt,
+		UpdatedAt: v.updatedAt,
+		Meta:      v.meta,
+		Codec:     v.codec,
+		rawSize:   v.rawSize,
+	}
+	if v.obj != nil {
+		entry.Type = v.obj.typ()
+	}
+	return entry, nil
+}
+
+func (v value) typ() typed.Type {
+	if v.obj == nil {
+		return typed.String
+	}
+	return v.obj.typ()
+}
+
+// size returns approximate memory used by value.
+func (v value) size() int {
+	n := len(v.key) + len(v.data) + v.meta.Size()
+	if v.obj != nil {
+		n += v.obj.bytes()
+	}
+	return n
+}
+
+func (v value) expired(now time.Time) bool {
+	return !v.expiresAt.IsZero() && !now.Before(v.expiresAt)
+}
+
+type Entry struct {
+	Data []byte
+	// ExpiresAt is zero if entry doesnt expire.
+	ExpiresAt time.Time
+	Version   uint64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Meta      meta.Meta
+	// Type is empty for plain values, Data of structured value is empty.
+	Type typed.Type
+	// Codec is empty unless entry is got with GetStored and its Data is compressed.
+	Codec   Codec
+	rawSize int
 }
 
-func (k *Keeper) Get(key string) []byte {
+// Memory returns approximate memory used by stored keys and values in bytes.
+func (k *Keeper) Memory() int64 {
 	k.mu.RLock()
-	data := k.values[key].data
+	defer k.mu.RUnlock()
+	return k.memory
+}
+
+type Stats struct {
+	Memory           int64            `json:"memory"`
+	Compression      CompressionStats `json:"compression"`
+	CompressionRatio float64          `json:"compressionRatio"`
+}
+
+func (k *Keeper) Stats() Stats {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return Stats{
+		Memory:           k.memory,
+		Compression:      k.compressed,
+		CompressionRatio: k.compressed.Ratio(),
+	}
+}
+
+// Get returns error if value cant be read, e.g. it is encrypted with key missing in keyring.
+func (k *Keeper) Get(key string) (Entry, bool, error) {
+	k.mu.RLock()
+	val, ok := k.values[key]
+	kr := k.keyring
 	k.mu.RUnlock()
-	return data
+	if !ok || val.expired(time.Now()) {
+		return Entry{}, false, nil
+	}
+	entry, err := val.entry(kr)
+	return entry, err == nil, err
+}
+
+// GetStored returns value as it is stored, so compressed value can be sent without decompression.
+// Encrypted value is decrypted anyway.
+func (k *Keeper) GetStored(key string) (Entry, bool, error) {
+	k.mu.RLock()
+	val, ok := k.values[key]
+	kr := k.keyring
+	k.mu.RUnlock()
+	if !ok || val.expired(time.Now()) {
+		return Entry{}, false, nil
+	}
+	entry, err := val.storedEntry(kr)
+	return entry, err == nil, err
 }
 
 func (k *Keeper) Set(key string, data []byte, ttl time.Duration) {
+	k.SetWithMeta(key, data, ttl, meta.Meta{})
+}
+
+// SetWithMeta stores value with its metadata, previous metadata of key is dropped.
+func (k *Keeper) SetWithMeta(key string, data []byte, ttl time.Duration, m meta.Meta) {
+	// value is compressed before lock, so other keys arent blocked meanwhile
+	val := k.compressValue(value{key: key, data: data, meta: m})
+	k.mu.Lock()
+	k.setValueLocked(val, ttl)
+	k.mu.Unlock()
+}
+
+func (k *Keeper) Delete(key string) {
+	k.mu.Lock()
+	k.deleteLocked(key)
+	k.mu.Unlock()
+}
+
+// MGet returns found entries by keys, absent keys are missing in result.
+// It fails if any value cant be read.
+func (k *Keeper) MGet(keys []string) (map[string]Entry, error) {
+	entries := make(map[string]Entry, len(keys))
+	now := time.Now()
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, key := range keys {
+		val, ok := k.values[key]
+		if !ok || val.expired(now) {
+			continue
+		}
+		entry, err := val.entry(k.keyring)
+		if err != nil {
+			return nil, err
+		}
+		entries[key] = entry
+	}
+	return entries, nil
+}
+
+func (k *Keeper) MSet(items []batch.Item) {
 	k.mu.Lock()
+	for _, item := range items {
+		k.setLocked(item.Key, item.Value, time.Duration(item.TTL))
+	}
+	k.mu.Unlock()
+}
+
+func (k *Keeper) MDelete(keys []string) {
+	k.mu.Lock()
+	for _, key := range keys {
+		k.deleteLocked(key)
+	}
+	k.mu.Unlock()
+}
+
+func (k *Keeper) setLocked(key string, data []byte, ttl time.Duration) {
+	k.setValueLocked(k.compressValue(value{key: key, data: data}), ttl)
+}
+
+func (k *Keeper) setValueLocked(val value, ttl time.Duration) {
+	val = k.storeLocked(k.sealLocked(val), ttl)
+	k.notifyLocked(events.OpSet, val.key, val.version)
+	slog.Debug(fmt.Sprintf("set key %q with ttl %s", val.key, ttl))
+}
+
+// createLocked stores empty typed value, caller fills it.
+func (k *Keeper) createLocked(key string, obj container, ttl time.Duration) value {
+	val := k.storeLocked(value{key: key, obj: obj}, ttl)
+	slog.Debug(fmt.Sprintf("create %s key %q with ttl %s", obj.typ(), key, ttl))
+	return val
+}
+
+func (k *Keeper) storeLocked(val value, ttl time.Duration) value {
 	if ttl == 0 {
 		ttl = k.defaultTTL
 	}
-	k.values[key] = value{key, data, time.NewTimer(ttl)}
-	k.mu.Unlock()
+	now := time.Now()
+	val.createdAt = now
+	if old, ok := k.values[val.key]; ok {
+		old.ttl.Stop()
+		k.accountLocked(old, -1)
+		if !old.expired(now) {
+			val.createdAt = old.createdAt
+		}
+	}
+	k.version++
+	val.ttl = time.NewTimer(ttl)
+	val.expiresAt = now.Add(ttl)
+	val.version = k.version
+	val.updatedAt = now
+	k.values[val.key] = val
+	k.accountLocked(val, 1)
+	return val
+}
 
-	slog.Debug(fmt.Sprintf("set key %q with ttl %s", key, ttl))
+func (k *Keeper) deleteLocked(key string) {
+	k.removeLocked(key, events.OpDelete)
 }
 
-func (k *Keeper) Delete(key string) {
-	k.mu.RLock()
+// expire removes key if it is still expired, key could be set again after its timer fired.
+func (k *Keeper) expire(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if val, ok := k.values[key]; ok && val.expired(time.Now()) {
+		k.removeLocked(key, events.OpExpired)
+	}
+}
+
+func (k *Keeper) removeLocked(key string, op events.Op) {
 	val, ok := k.values[key]
-	k.mu.RUnlock()
 	if !ok {
 		return
 	}
 
 	val.ttl.Stop()
-	k.mu.Lock()
 	delete(k.values, key)
-	k.mu.Unlock()
+	k.accountLocked(val, -1)
+	k.notifyLocked(op, key, val.version)
 
-	slog.Debug(fmt.Sprintf("delete key %q", key))
+	slog.Debug(fmt.Sprintf("%s key %q", op, key))
 }
 
+const cleanupInterval = time.Second
+
 func (k *Keeper) Run() {
 	go k.observeTTL()
+	go k.cleanup()
+}
+
+// cleanup removes expired rate limits and locks.
+func (k *Keeper) cleanup() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		k.cleanupLimiters(now)
+		k.cleanupLocks(now)
+	}
 }
 
 // TODO: think about optimization
@@ -81,8 +366,7 @@ func (k *Keeper) observeTTL() {
 
 			select {
 			case <-val.ttl.C:
-				slog.Debug(fmt.Sprintf("key %q expired", key))
-				k.Delete(key)
+				k.expire(key)
 			default:
 			}
 		}
@@ -3,37 +3,87 @@ package keeper
 import (
 	"testing"
 	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/stretchr/testify/require"
 )
 
 func TestStoring(t *testing.T) {
 	k := NewService(10 * time.Second)
 	k.Set("key1", []byte("data"), 0)
-	value := k.Get("key1")
-	if len(value) == 0 {
+	entry, _, _ := k.Get("key1")
+	if len(entry.Data) == 0 {
 		t.Error("value of key1 empty but shoudnt")
 	}
 }
 
+func TestStoringEmptyValue(t *testing.T) {
+	k := NewService(10 * time.Second)
+	k.Set("key1", []byte{}, 0)
+	_, found, _ := k.Get("key1")
+	if !found {
+		t.Error("key1 with empty value not found but shoud")
+	}
+
+	_, found, _ = k.Get("key2")
+	if found {
+		t.Error("key2 found but shoudnt")
+	}
+}
+
 func TestKeyOverwrite(t *testing.T) {
 	k := NewService(10 * time.Second)
 	k.Set("key1", []byte("data1"), 0)
 
 	wantData := []byte("data2")
 	k.Set("key1", wantData, 0)
-	gotData := k.Get("key1")
-	if string(gotData) != string(wantData) {
-		t.Errorf("want data %s, but got %s", string(wantData), string(gotData))
+	gotEntry, _, _ := k.Get("key1")
+	if string(gotEntry.Data) != string(wantData) {
+		t.Errorf("want data %s, but got %s", string(wantData), string(gotEntry.Data))
 	}
 }
 
+func TestVersionAfterRestart(t *testing.T) {
+	k := NewService(10 * time.Second)
+	k.Set("key", []byte("data1"), 0)
+	before, _, _ := k.Get("key")
+
+	// value of restarted or another keeper doesnt get the same version
+	restarted := NewService(10 * time.Second)
+	restarted.Set("key", []byte("data2"), 0)
+	after, _, _ := restarted.Get("key")
+	if after.Version <= before.Version {
+		t.Errorf("want version greater than %d, but got %d", before.Version, after.Version)
+	}
+}
+
+func TestCreatedAndUpdatedAt(t *testing.T) {
+	k := NewService(10 * time.Second)
+	k.Set("key1", []byte("data1"), 0)
+	first, _, _ := k.Get("key1")
+	require.False(t, first.CreatedAt.IsZero())
+	require.Equal(t, first.CreatedAt, first.UpdatedAt)
+
+	time.Sleep(time.Millisecond)
+	k.Set("key1", []byte("data2"), 0)
+	second, _, _ := k.Get("key1")
+	require.Equal(t, first.CreatedAt, second.CreatedAt)
+	require.True(t, second.UpdatedAt.After(first.UpdatedAt))
+
+	k.Delete("key1")
+	k.Set("key1", []byte("data3"), 0)
+	third, _, _ := k.Get("key1")
+	require.True(t, third.CreatedAt.After(first.CreatedAt))
+}
+
 func TestDelete(t *testing.T) {
 	k := NewService(10 * time.Second)
 	k.Set("key1", []byte("data1"), 0)
 
 	k.Delete("key1")
-	value := k.Get("key1")
-	if len(value) != 0 {
-		t.Errorf("value of key1 not empty but shoud")
+	_, found, _ := k.Get("key1")
+	if found {
+		t.Errorf("key1 found but shoudnt")
 	}
 }
 
@@ -47,9 +97,9 @@ func TestExpirationOneEntry(t *testing.T) {
 
 	time.Sleep(51 * time.Millisecond)
 
-	value := k.Get("key1")
-	if len(value) != 0 {
-		t.Error("value of key1 not empty but shoud")
+	_, found, _ := k.Get("key1")
+	if found {
+		t.Error("key1 found but shoudnt")
 	}
 }
 
@@ -66,23 +116,48 @@ func TestExpirationMultipleEntries(t *testing.T) {
 	k.Run()
 
 	time.Sleep(500 * time.Millisecond)
-	value := k.Get("key1")
-	if len(value) != 0 {
-		t.Error("value of key1 not empty but shoud")
+	_, found, _ := k.Get("key1")
+	if found {
+		t.Error("key1 found but shoudnt")
 	}
 
-	value = k.Get("key2")
-	if len(value) == 0 {
-		t.Error("value of key2 empty but shoudnt")
+	_, found, _ = k.Get("key2")
+	if !found {
+		t.Error("key2 not found but shoud")
 	}
 
-	value = k.Get("key3")
-	if len(value) == 0 {
-		t.Error("value of key3 empty but shoudnt")
+	_, found, _ = k.Get("key3")
+	if !found {
+		t.Error("key3 not found but shoud")
 	}
 
-	value = k.Get("key4")
-	if len(value) != 0 {
-		t.Error("value of key4 not empty but shoud")
+	_, found, _ = k.Get("key4")
+	if found {
+		t.Error("key4 found but shoudnt")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	k := NewService(10 * time.Second)
+	k.MSet([]batch.Item{
+		{Key: "key1", Value: []byte("data1")},
+		{Key: "key2", Value: []byte("data2"), TTL: batch.Duration(time.Minute)},
+	})
+
+	entries, _ := k.MGet([]string{"key1", "key2", "key3"})
+	if len(entries) != 2 {
+		t.Fatalf("want 2 entries, but got %d", len(entries))
+	}
+	if string(entries["key2"].Data) != "data2" {
+		t.Errorf("want data data2, but got %s", string(entries["key2"].Data))
+	}
+
+	k.MDelete([]string{"key1", "key3"})
+	entries, _ = k.MGet([]string{"key1", "key2"})
+	if _, found := entries["key1"]; found {
+		t.Error("key1 found but shoudnt")
+	}
+	if _, found := entries["key2"]; !found {
+		t.Error("key2 not found but shoud")
 	}
 }
@@ -0,0 +1,71 @@
+package keeper
+
+import (
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/tx"
+)
+
+// Exec applies operations of transaction in order under the store lock.
+// If any check fails nothing is applied.
+func (k *Keeper) Exec(t tx.Tx) tx.Response {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := time.Now()
+	// staged versions of keys changed by transaction, zero means deleted.
+	staged := make(map[string]uint64)
+	version := k.version
+	currentVersion := func(key string) uint64 {
+		if v, ok := staged[key]; ok {
+			return v
+		}
+		if val, ok := k.values[key]; ok && !val.expired(now) {
+			return val.version
+		}
+		return 0
+	}
+
+	results := make([]tx.Result, 0, len(t.Ops))
+	for i, op := range t.Ops {
+		res := tx.Result{Op: op.Op, Key: op.Key, Status: tx.StatusOK}
+		switch op.Op {
+		case tx.OpSet:
+			version++
+			staged[op.Key] = version
+			res.Version = version
+		case tx.OpDelete:
+			staged[op.Key] = 0
+		case tx.OpCheck:
+			res.Version = currentVersion(op.Key)
+			if res.Version != op.Version {
+				res.Status = tx.StatusConflict
+				return abort(t, i, res)
+			}
+		}
+		results = append(results, res)
+	}
+
+	for _, op := range t.Ops {
+		switch op.Op {
+		case tx.OpSet:
+			k.setValueLocked(k.compressValue(value{key: op.Key, data: op.Value, meta: op.Meta}), time.Duration(op.TTL))
+		case tx.OpDelete:
+			k.deleteLocked(op.Key)
+		}
+	}
+	return tx.Response{Committed: true, Results: results}
+}
+
+// abort returns response where failed operation has its result and all the others are aborted.
+func abort(t tx.Tx, failed int, failedRes tx.Result) tx.Response {
+	results := make([]tx.Result, 0, len(t.Ops))
+	for i, op := range t.Ops {
+		if i == failed {
+			results = append(results, failedRes)
+			continue
+		}
+		results = append(results, tx.Result{Op: op.Op, Key: op.Key, Status: tx.StatusAborted})
+	}
+	return tx.Response{Committed: false, Results: results}
+}
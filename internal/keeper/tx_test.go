@@ -0,0 +1,76 @@
+package keeper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/tx"
+)
+
+func TestExecCommit(t *testing.T) {
+	k := NewService(10 * time.Second)
+	k.Set("{user42}:email", []byte("old@mail"), 0)
+	entry, _, _ := k.Get("{user42}:email")
+
+	resp := k.Exec(tx.Tx{Ops: []tx.Op{
+		{Op: tx.OpCheck, Key: "{user42}:profile", Version: 0},
+		{Op: tx.OpCheck, Key: "{user42}:email", Version: entry.Version},
+		{Op: tx.OpSet, Key: "{user42}:profile", Value: []byte("profile")},
+		{Op: tx.OpDelete, Key: "{user42}:email"},
+	}})
+	if !resp.Committed {
+		t.Fatalf("transaction isnt committed: %+v", resp.Results)
+	}
+
+	profile, found, _ := k.Get("{user42}:profile")
+	if !found || string(profile.Data) != "profile" {
+		t.Errorf("profile isnt set")
+	}
+	if profile.Version != resp.Results[2].Version {
+		t.Errorf("want version %d, but got %d", resp.Results[2].Version, profile.Version)
+	}
+	if _, found, _ = k.Get("{user42}:email"); found {
+		t.Errorf("email found but shoudnt")
+	}
+}
+
+func TestExecConflict(t *testing.T) {
+	k := NewService(10 * time.Second)
+	k.Set("key1", []byte("data"), 0)
+
+	resp := k.Exec(tx.Tx{Ops: []tx.Op{
+		{Op: tx.OpSet, Key: "key2", Value: []byte("data")},
+		{Op: tx.OpCheck, Key: "key1", Version: 0},
+		{Op: tx.OpDelete, Key: "key1"},
+	}})
+	if resp.Committed {
+		t.Fatal("transaction committed but shoudnt")
+	}
+
+	want := []tx.Status{tx.StatusAborted, tx.StatusConflict, tx.StatusAborted}
+	for i, res := range resp.Results {
+		if res.Status != want[i] {
+			t.Errorf("want status %s of op %d, but got %s", want[i], i, res.Status)
+		}
+	}
+
+	if _, found, _ := k.Get("key2"); found {
+		t.Error("key2 found but shoudnt")
+	}
+	if _, found, _ := k.Get("key1"); !found {
+		t.Error("key1 not found but shoud")
+	}
+}
+
+func TestExecCheckSeesStagedOps(t *testing.T) {
+	k := NewService(10 * time.Second)
+
+	resp := k.Exec(tx.Tx{Ops: []tx.Op{
+		{Op: tx.OpSet, Key: "key1", Value: []byte("data")},
+		{Op: tx.OpDelete, Key: "key1"},
+		{Op: tx.OpCheck, Key: "key1", Version: 0},
+	}})
+	if !resp.Committed {
+		t.Fatalf("transaction isnt committed: %+v", resp.Results)
+	}
+}
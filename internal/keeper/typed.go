@@ -0,0 +1,247 @@
+package keeper
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/aosderzhikov/sticky/internal/typed"
+)
+
+// container is value of structured type, it is changed in place under lock of keeper.
+type container interface {
+	typ() typed.Type
+	// bytes returns memory used by elements of container.
+	bytes() int
+	len() int
+}
+
+// memberOverhead is approximate memory used by element besides its data.
+const memberOverhead = 16
+
+type hash struct {
+	fields map[string][]byte
+	size   int
+}
+
+func (h *hash) typ() typed.Type { return typed.Hash }
+func (h *hash) len() int        { return len(h.fields) }
+func (h *hash) bytes() int      { return h.size }
+
+type list struct {
+	items [][]byte
+	size  int
+}
+
+func (l *list) typ() typed.Type { return typed.List }
+func (l *list) len() int        { return len(l.items) }
+func (l *list) bytes() int      { return l.size }
+
+type set struct {
+	members map[string]struct{}
+	size    int
+}
+
+func (s *set) typ() typed.Type { return typed.Set }
+func (s *set) len() int        { return len(s.members) }
+func (s *set) bytes() int      { return s.size }
+
+type zset struct {
+	scores map[string]float64
+	size   int
+}
+
+func (z *zset) typ() typed.Type { return typed.ZSet }
+func (z *zset) len() int        { return len(z.scores) }
+func (z *zset) bytes() int      { return z.size }
+
+func newContainer(t typed.Type) container {
+	switch t {
+	case typed.Hash:
+		return &hash{fields: make(map[string][]byte)}
+	case typed.List:
+		return &list{}
+	case typed.Set:
+		return &set{members: make(map[string]struct{})}
+	default:
+		return &zset{scores: make(map[string]float64)}
+	}
+}
+
+// Do executes command on typed value. Value is created by the first write with
+// ttl of command and is removed when its last element is removed.
+func (k *Keeper) Do(cmd typed.Command) (typed.Reply, error) {
+	if cmd.Op.ReadOnly() {
+		k.mu.RLock()
+		defer k.mu.RUnlock()
+	} else {
+		k.mu.Lock()
+		defer k.mu.Unlock()
+	}
+	return k.doLocked(cmd)
+}
+
+func (k *Keeper) doLocked(cmd typed.Command) (typed.Reply, error) {
+	val, ok := k.values[cmd.Key]
+	if ok && val.expired(time.Now()) {
+		ok = false
+	}
+	if ok && val.typ() != cmd.Op.Type() {
+		return typed.Reply{}, typed.ErrWrongType
+	}
+
+	if cmd.Op.ReadOnly() {
+		if !ok {
+			return typed.Reply{}, typed.ErrNotFound
+		}
+		return read(val.obj, cmd)
+	}
+
+	if !ok {
+		switch cmd.Op {
+		case typed.HDel, typed.SRem, typed.LPop, typed.RPop:
+			return typed.Reply{}, typed.ErrNotFound
+		}
+		val = k.createLocked(cmd.Key, newContainer(cmd.Op.Type()), cmd.TTL)
+	}
+
+	before := val.size()
+	reply, err := write(val.obj, cmd)
+	if err != nil {
+		return typed.Reply{}, err
+	}
+	if cmd.Op == typed.LPush || cmd.Op == typed.RPush {
+		k.servePopWaitersLocked(cmd.Key, val.obj.(*list))
+	}
+
+	k.memory += int64(val.size() - before)
+	if val.obj.len() == 0 {
+		k.deleteLocked(cmd.Key)
+		return reply, nil
+	}
+
+	k.version++
+	val.version = k.version
+	val.updatedAt = time.Now()
+	k.values[cmd.Key] = val
+	k.notifyLocked(events.OpSet, cmd.Key, val.version)
+	reply.Exists = true
+	return reply, nil
+}
+
+func read(obj container, cmd typed.Command) (typed.Reply, error) {
+	var reply typed.Reply
+	switch cmd.Op {
+	case typed.HGet:
+		value, ok := obj.(*hash).fields[cmd.Field]
+		if !ok {
+			return typed.Reply{}, typed.ErrNotFound
+		}
+		reply.Value = value
+	case typed.LRange:
+		items := obj.(*list).items
+		start, stop := listRange(cmd.Start, cmd.Stop, len(items))
+		if start <= stop {
+			reply.Values = slices.Clone(items[start : stop+1])
+		}
+	case typed.SMembers:
+		for member := range obj.(*set).members {
+			reply.Members = append(reply.Members, typed.Member{Member: member})
+		}
+		slices.SortFunc(reply.Members, func(a, b typed.Member) int { return cmp.Compare(a.Member, b.Member) })
+	case typed.ZRange:
+		for member, score := range obj.(*zset).scores {
+			if score >= cmd.Min && score <= cmd.Max {
+				reply.Members = append(reply.Members, typed.Member{Member: member, Score: score})
+			}
+		}
+		slices.SortFunc(reply.Members, func(a, b typed.Member) int {
+			return cmp.Or(cmp.Compare(a.Score, b.Score), cmp.Compare(a.Member, b.Member))
+		})
+	}
+	reply.Count = len(reply.Values) + len(reply.Members)
+	reply.Exists = true
+	return reply, nil
+}
+
+func write(obj container, cmd typed.Command) (typed.Reply, error) {
+	var reply typed.Reply
+	switch cmd.Op {
+	case typed.HSet:
+		h := obj.(*hash)
+		old, ok := h.fields[cmd.Field]
+		if ok {
+			h.size -= len(old)
+		} else {
+			h.size += len(cmd.Field) + memberOverhead
+			reply.Count = 1
+		}
+		h.fields[cmd.Field] = cmd.Value
+		h.size += len(cmd.Value)
+	case typed.HDel:
+		h := obj.(*hash)
+		old, ok := h.fields[cmd.Field]
+		if !ok {
+			return typed.Reply{}, typed.ErrNotFound
+		}
+		delete(h.fields, cmd.Field)
+		h.size -= len(cmd.Field) + len(old) + memberOverhead
+		reply.Count = 1
+	case typed.LPush, typed.RPush:
+		l := obj.(*list)
+		if cmd.Op == typed.LPush {
+			l.items = slices.Insert(l.items, 0, cmd.Value)
+		} else {
+			l.items = append(l.items, cmd.Value)
+		}
+		l.size += len(cmd.Value) + memberOverhead
+		reply.Count = len(l.items)
+	case typed.LPop, typed.RPop:
+		l := obj.(*list)
+		i := 0
+		if cmd.Op == typed.RPop {
+			i = len(l.items) - 1
+		}
+		reply.Value = l.items[i]
+		l.items = slices.Delete(l.items, i, i+1)
+		l.size -= len(reply.Value) + memberOverhead
+		reply.Count = len(l.items)
+	case typed.SAdd:
+		s := obj.(*set)
+		if _, ok := s.members[cmd.Member]; !ok {
+			s.members[cmd.Member] = struct{}{}
+			s.size += len(cmd.Member) + memberOverhead
+			reply.Count = 1
+		}
+	case typed.SRem:
+		s := obj.(*set)
+		if _, ok := s.members[cmd.Member]; !ok {
+			return typed.Reply{}, typed.ErrNotFound
+		}
+		delete(s.members, cmd.Member)
+		s.size -= len(cmd.Member) + memberOverhead
+		reply.Count = 1
+	case typed.ZAdd:
+		z := obj.(*zset)
+		if _, ok := z.scores[cmd.Member]; !ok {
+			z.size += len(cmd.Member) + memberOverhead
+			reply.Count = 1
+		}
+		z.scores[cmd.Member] = cmd.Score
+	}
+	return reply, nil
+}
+
+// listRange converts inclusive range with negative indexes to bounds of slice with length n.
+func listRange(start, stop int64, n int) (int, int) {
+	if start < 0 {
+		start += int64(n)
+	}
+	if stop < 0 {
+		stop += int64(n)
+	}
+	start = max(start, 0)
+	stop = min(stop, int64(n)-1)
+	return int(start), int(stop)
+}
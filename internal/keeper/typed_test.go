@@ -0,0 +1,177 @@
+package keeper
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	k := NewService(10 * time.Second)
+
+	reply, err := k.Do(typed.Command{Op: typed.HSet, Key: "user42", Field: "name", Value: []byte("bob"), TTL: time.Minute})
+	require.NoError(t, err)
+	require.Equal(t, typed.Reply{Count: 1, Exists: true}, reply)
+
+	reply, err = k.Do(typed.Command{Op: typed.HSet, Key: "user42", Field: "name", Value: []byte("alice")})
+	require.NoError(t, err)
+	require.Equal(t, 0, reply.Count)
+
+	reply, err = k.Do(typed.Command{Op: typed.HGet, Key: "user42", Field: "name"})
+	require.NoError(t, err)
+	require.Equal(t, "alice", string(reply.Value))
+
+	_, err = k.Do(typed.Command{Op: typed.HGet, Key: "user42", Field: "age"})
+	require.ErrorIs(t, err, typed.ErrNotFound)
+
+	entry, found, _ := k.Get("user42")
+	require.True(t, found)
+	require.Equal(t, typed.Hash, entry.Type)
+	require.WithinDuration(t, time.Now().Add(time.Minute), entry.ExpiresAt, time.Second)
+
+	reply, err = k.Do(typed.Command{Op: typed.HDel, Key: "user42", Field: "name"})
+	require.NoError(t, err)
+	require.False(t, reply.Exists)
+
+	_, found, _ = k.Get("user42")
+	require.False(t, found, "empty hash isnt removed")
+}
+
+func TestList(t *testing.T) {
+	k := NewService(10 * time.Second)
+
+	for _, cmd := range []typed.Command{
+		{Op: typed.RPush, Key: "queue", Value: []byte("b")},
+		{Op: typed.RPush, Key: "queue", Value: []byte("c")},
+		{Op: typed.LPush, Key: "queue", Value: []byte("a")},
+	} {
+		_, err := k.Do(cmd)
+		require.NoError(t, err)
+	}
+
+	tests := []struct {
+		name        string
+		start, stop int64
+		want        [][]byte
+	}{
+		{"all", 0, -1, [][]byte{[]byte("a"), []byte("b"), []byte("c")}},
+		{"tail", -2, -1, [][]byte{[]byte("b"), []byte("c")}},
+		{"out of range", 5, 10, nil},
+		{"stop beyond end", 1, 100, [][]byte{[]byte("b"), []byte("c")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := k.Do(typed.Command{Op: typed.LRange, Key: "queue", Start: tt.start, Stop: tt.stop})
+			require.NoError(t, err)
+			require.Equal(t, tt.want, reply.Values)
+		})
+	}
+
+	reply, err := k.Do(typed.Command{Op: typed.LPop, Key: "queue"})
+	require.NoError(t, err)
+	require.Equal(t, "a", string(reply.Value))
+
+	reply, err = k.Do(typed.Command{Op: typed.RPop, Key: "queue"})
+	require.NoError(t, err)
+	require.Equal(t, "c", string(reply.Value))
+	require.Equal(t, 1, reply.Count)
+}
+
+func TestSetAndZSet(t *testing.T) {
+	k := NewService(10 * time.Second)
+
+	for _, member := range []string{"b", "a", "b"} {
+		_, err := k.Do(typed.Command{Op: typed.SAdd, Key: "tags", Member: member})
+		require.NoError(t, err)
+	}
+	reply, err := k.Do(typed.Command{Op: typed.SMembers, Key: "tags"})
+	require.NoError(t, err)
+	require.Equal(t, []typed.Member{{Member: "a"}, {Member: "b"}}, reply.Members)
+
+	for member, score := range map[string]float64{"a": 3, "b": 1, "c": 2} {
+		_, err = k.Do(typed.Command{Op: typed.ZAdd, Key: "scores", Member: member, Score: score})
+		require.NoError(t, err)
+	}
+	reply, err = k.Do(typed.Command{Op: typed.ZRange, Key: "scores", Min: 1.5, Max: 3})
+	require.NoError(t, err)
+	require.Equal(t, []typed.Member{{Member: "c", Score: 2}, {Member: "a", Score: 3}}, reply.Members)
+}
+
+func TestTypedWrongType(t *testing.T) {
+	k := NewService(10 * time.Second)
+	k.Set("plain", []byte("data"), 0)
+
+	_, err := k.Do(typed.Command{Op: typed.HSet, Key: "plain", Field: "f"})
+	require.ErrorIs(t, err, typed.ErrWrongType)
+
+	_, err = k.Do(typed.Command{Op: typed.SAdd, Key: "list", Member: "m"})
+	require.NoError(t, err)
+	_, err = k.Do(typed.Command{Op: typed.LPush, Key: "list"})
+	require.ErrorIs(t, err, typed.ErrWrongType)
+
+	_, err = k.Incr("list", 1, 0)
+	require.ErrorIs(t, err, ErrNotNumber)
+}
+
+func TestTypedMemory(t *testing.T) {
+	k := NewService(10 * time.Second)
+
+	_, err := k.Do(typed.Command{Op: typed.HSet, Key: "h", Field: "field", Value: []byte("value")})
+	require.NoError(t, err)
+	_, err = k.Do(typed.Command{Op: typed.RPush, Key: "l", Value: []byte("item")})
+	require.NoError(t, err)
+	require.Greater(t, k.Memory(), int64(0))
+
+	_, err = k.Do(typed.Command{Op: typed.HDel, Key: "h", Field: "field"})
+	require.NoError(t, err)
+	_, err = k.Do(typed.Command{Op: typed.LPop, Key: "l"})
+	require.NoError(t, err)
+	require.Equal(t, int64(0), k.Memory())
+
+	k.Set("key", []byte("data"), 0)
+	k.Delete("key")
+	require.Equal(t, int64(0), k.Memory())
+}
+
+func TestTypedHandle(t *testing.T) {
+	h := NewHandler(NewService(10*time.Second), HandlerOptions{})
+
+	tests := []struct {
+		name   string
+		op     typed.Op
+		url    string
+		body   string
+		code   int
+		values int
+	}{
+		{"push", typed.RPush, "http://test?key=queue", "job1", http.StatusOK, 0},
+		{"range", typed.LRange, "http://test?key=queue&start=0&stop=-1", "", http.StatusOK, 1},
+		{"invalid range", typed.LRange, "http://test?key=queue&start=a", "", http.StatusBadRequest, 0},
+		{"wrong type", typed.HGet, "http://test?key=queue&field=f", "", http.StatusConflict, 0},
+		{"empty field", typed.HGet, "http://test?key=queue", "", http.StatusBadRequest, 0},
+		{"not found", typed.SMembers, "http://test?key=tags", "", http.StatusNotFound, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.op.Method(), tt.url, bytes.NewReader([]byte(tt.body)))
+			require.NoError(t, err)
+			rec := httptest.NewRecorder()
+			h.TypedHandle(tt.op)(rec, req)
+			require.Equal(t, tt.code, rec.Result().StatusCode)
+
+			if tt.code == http.StatusOK {
+				var reply typed.Reply
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&reply))
+				require.Len(t, reply.Values, tt.values)
+			}
+		})
+	}
+}
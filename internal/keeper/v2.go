@@ -0,0 +1,82 @@
+package keeper
+
+import (
+	"net/http"
+
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/typed"
+)
+
+// GetV2Handle responds with json envelope of entry, HEAD request gets only its headers.
+func (h *Handler) GetV2Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, err := handler.ExtractKeyV2(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	entry, found, err := h.s.Get(key)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		handler.ErrorHandle(ctx, w, handler.ErrKeyNotFound, http.StatusNotFound)
+		return
+	}
+	if entry.Type != "" {
+		handler.ErrorHandle(ctx, w, typed.ErrWrongType, http.StatusConflict)
+		return
+	}
+	if handler.NotModified(r, entry.Version, entry.UpdatedAt) {
+		handler.WriteNotModified(w, entry.Version, entry.UpdatedAt)
+		return
+	}
+
+	handler.WriteEntryV2(w, r, handler.EntryV2{
+		Key:       key,
+		Value:     entry.Data,
+		Version:   entry.Version,
+		TTL:       handler.RemainingTTL(entry.ExpiresAt),
+		CreatedAt: entry.CreatedAt,
+		UpdatedAt: entry.UpdatedAt,
+		Meta:      entry.Meta,
+	})
+}
+
+func (h *Handler) PutV2Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	req, err := handler.ExtractPutV2(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	value, err := h.readValue(w, r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, handler.ValueErrorCode(err))
+		return
+	}
+
+	if h.setIfMatch(w, r, req.Key, value, req.TTL, req.Meta) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// DeleteV2Handle responds with 204 whether key existed or not, unless request has If-Match.
+func (h *Handler) DeleteV2Handle(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, err := handler.ExtractKeyV2(r)
+	if err != nil {
+		handler.ErrorHandle(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	if h.deleteIfMatch(w, r, key) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
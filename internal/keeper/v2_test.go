@@ -0,0 +1,116 @@
+package keeper
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/stretchr/testify/require"
+)
+
+func newV2Server(t *testing.T, k *Keeper) *httptest.Server {
+	h := NewHandler(k, HandlerOptions{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v2/keys/{key...}", h.GetV2Handle)
+	mux.HandleFunc("PUT /v2/keys/{key...}", h.PutV2Handle)
+	mux.HandleFunc("DELETE /v2/keys/{key...}", h.DeleteV2Handle)
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func doV2(t *testing.T, method, url, body string) *http.Response {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestV2Handles(t *testing.T) {
+	k := NewService(time.Minute)
+	srv := newV2Server(t, k)
+	url := srv.URL + "/v2/keys/user/1"
+
+	resp := doV2(t, http.MethodPut, url+"?ttl=10s", "\x00\xff")
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = doV2(t, http.MethodGet, url, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var entry handler.EntryV2
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&entry))
+	require.Equal(t, "user/1", entry.Key)
+	require.Equal(t, []byte("\x00\xff"), entry.Value)
+	stored, _, _ := k.Get("user/1")
+	require.Equal(t, stored.Version, entry.Version)
+	require.Equal(t, 2, entry.Size)
+	require.Equal(t, handler.DefaultContentType, entry.ContentType)
+	require.NotNil(t, entry.TTL)
+	require.InDelta(t, 10*time.Second, time.Duration(*entry.TTL), float64(time.Second))
+	require.False(t, entry.CreatedAt.IsZero())
+	require.Equal(t, entry.CreatedAt, entry.UpdatedAt)
+
+	resp = doV2(t, http.MethodHead, url, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, strconv.FormatUint(stored.Version, 10), resp.Header.Get(handler.VersionHeader))
+	require.Equal(t, "2", resp.Header.Get(handler.SizeHeader))
+	require.Equal(t, entry.CreatedAt, handler.ExtractTimeHeader(resp.Header, handler.CreatedAtHeader))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Empty(t, body)
+
+	resp = doV2(t, http.MethodDelete, url, "")
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = doV2(t, http.MethodDelete, url, "")
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = doV2(t, http.MethodGet, url, "")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp = doV2(t, http.MethodHead, url, "")
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, err = k.Do(typed.Command{Op: typed.HSet, Key: "hash", Field: "f", Value: []byte("v")})
+	require.NoError(t, err)
+	resp = doV2(t, http.MethodGet, srv.URL+"/v2/keys/hash", "")
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	resp = doV2(t, http.MethodPut, url+"?ttl=abc", "v")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestV2Meta(t *testing.T) {
+	k := NewService(time.Minute)
+	srv := newV2Server(t, k)
+	url := srv.URL + "/v2/keys/image"
+
+	req, err := http.NewRequest(http.MethodPut, url, strings.NewReader("png"))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "image/png")
+	req.Header.Set("X-Sticky-Meta-Owner", "team-a")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = doV2(t, http.MethodGet, url, "")
+	var entry handler.EntryV2
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&entry))
+	require.Equal(t, "image/png", entry.ContentType)
+	require.Equal(t, map[string]string{"Owner": "team-a"}, entry.User)
+	require.Equal(t, "image/png", resp.Header.Get(handler.ContentTypeHeader))
+	require.Equal(t, "team-a", resp.Header.Get("X-Sticky-Meta-Owner"))
+
+	// value set without metadata loses previous one
+	k.Set("image", []byte("raw"), 0)
+	got, _, _ := k.Get("image")
+	require.True(t, got.Meta.IsZero())
+}
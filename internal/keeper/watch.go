@@ -0,0 +1,51 @@
+package keeper
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+)
+
+var ErrNotModified error = errors.New("key isnt changed")
+
+// Watch returns entry of key if its version is newer than sinceVersion, otherwise it waits
+// for the next set, delete or expire of key until timeout. Found is false if key doesnt exist,
+// e.g. it was deleted. Zero sinceVersion waits for key to be created if it doesnt exist.
+func (k *Keeper) Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (Entry, bool, error) {
+	// events are published under write lock, so no change is missed between check and subscribe
+	k.mu.RLock()
+	entry, found, changed, err := k.changedLocked(key, sinceVersion)
+	if changed || err != nil {
+		k.mu.RUnlock()
+		return entry, found, err
+	}
+	sub := k.events.Subscribe(events.Filter{Pattern: events.ExactPattern(key)})
+	k.mu.RUnlock()
+	defer k.events.Unsubscribe(sub)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-sub.Events():
+		return k.Get(key)
+	case <-timer.C:
+		return Entry{}, false, ErrNotModified
+	case <-ctx.Done():
+		return Entry{}, false, ctx.Err()
+	}
+}
+
+func (k *Keeper) changedLocked(key string, sinceVersion uint64) (Entry, bool, bool, error) {
+	val, ok := k.values[key]
+	if !ok || val.expired(time.Now()) {
+		return Entry{}, false, sinceVersion != 0, nil
+	}
+	if val.version <= sinceVersion {
+		return Entry{}, true, false, nil
+	}
+	entry, err := val.entry(k.keyring)
+	return entry, err == nil, err == nil, err
+}
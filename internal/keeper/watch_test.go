@@ -0,0 +1,102 @@
+package keeper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchReturnsNewerVersion(t *testing.T) {
+	k := NewService(10 * time.Second)
+	k.Set("flag", []byte("on"), 0)
+	current, _, _ := k.Get("flag")
+
+	entry, found, err := k.Watch(context.Background(), "flag", current.Version-1, time.Minute)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "on", string(entry.Data))
+
+	_, found, err = k.Watch(context.Background(), "deleted", current.Version, time.Minute)
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestWatchWaitsForChange(t *testing.T) {
+	k := NewService(10 * time.Second)
+	k.Set("flag", []byte("on"), 0)
+	current, _, _ := k.Get("flag")
+
+	tests := []struct {
+		name   string
+		since  uint64
+		change func()
+		found  bool
+		data   string
+	}{
+		{"set", current.Version, func() { k.Set("flag", []byte("off"), 0) }, true, "off"},
+		{"delete", current.Version + 1, func() { k.Delete("flag") }, false, ""},
+		{"created", 0, func() { k.Set("flag", []byte("on"), 0) }, true, "on"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			time.AfterFunc(20*time.Millisecond, tt.change)
+
+			entry, found, err := k.Watch(context.Background(), "flag", tt.since, time.Second)
+			require.NoError(t, err)
+			require.Equal(t, tt.found, found)
+			require.Equal(t, tt.data, string(entry.Data))
+			if found {
+				require.Greater(t, entry.Version, tt.since)
+			}
+		})
+	}
+}
+
+func TestWatchTimeout(t *testing.T) {
+	k := NewService(10 * time.Second)
+	k.Set("flag", []byte("on"), 0)
+	current, _, _ := k.Get("flag")
+
+	_, _, err := k.Watch(context.Background(), "flag", current.Version, 20*time.Millisecond)
+	require.ErrorIs(t, err, ErrNotModified)
+	require.Zero(t, k.events.Publish(events.Event{Op: events.OpSet, Key: "flag"}), "watcher isnt unsubscribed")
+}
+
+func TestWatchHandle(t *testing.T) {
+	k := NewService(10 * time.Second)
+	k.Set("flag", []byte("on"), 0)
+	current, _, _ := k.Get("flag")
+	h := NewHandler(k, HandlerOptions{})
+
+	tests := []struct {
+		name string
+		url  string
+		code int
+	}{
+		{"changed", "http://test?key=flag&sinceVersion=0", http.StatusOK},
+		{"not modified", "http://test?key=flag&timeout=10ms&sinceVersion=" + strconv.FormatUint(current.Version, 10), http.StatusNotModified},
+		{"deleted", "http://test?key=other&sinceVersion=1", http.StatusNotFound},
+		{"invalid timeout", "http://test?key=flag&timeout=-1s", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, tt.url, http.NoBody)
+			require.NoError(t, err)
+			rec := httptest.NewRecorder()
+			h.WatchHandle(rec, req)
+			require.Equal(t, tt.code, rec.Result().StatusCode)
+			if tt.code == http.StatusOK {
+				require.Equal(t, strconv.FormatUint(current.Version, 10), rec.Header().Get(handler.VersionHeader))
+			}
+		})
+	}
+}
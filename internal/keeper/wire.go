@@ -0,0 +1,79 @@
+package keeper
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/handler"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/aosderzhikov/sticky/internal/wire"
+)
+
+// ServeWire serves binary requests of bouncer the same way as http handlers do.
+func (h *Handler) ServeWire(_ context.Context, req wire.Request) wire.Response {
+	switch req.Op {
+	case wire.OpGet, wire.OpSet, wire.OpDelete:
+		if req.Key == "" {
+			return wire.ErrorResponse(http.StatusBadRequest, handler.ErrEmptyParam)
+		}
+	case wire.OpMGet, wire.OpMSet, wire.OpMDelete:
+		if err := req.Batch.Validate(); err != nil {
+			return wire.ErrorResponse(http.StatusBadRequest, err)
+		}
+	}
+
+	switch req.Op {
+	case wire.OpGet:
+		entry, found, err := h.s.Get(req.Key)
+		if err != nil {
+			return wire.ErrorResponse(http.StatusInternalServerError, err)
+		}
+		if !found {
+			return wire.ErrorResponse(http.StatusNotFound, handler.ErrKeyNotFound)
+		}
+		if entry.Type != "" {
+			return wire.ErrorResponse(http.StatusConflict, typed.ErrWrongType)
+		}
+
+		resp := wire.OKResponse()
+		resp.Value = entry.Data
+		resp.Version = entry.Version
+		resp.CreatedAt = entry.CreatedAt
+		resp.UpdatedAt = entry.UpdatedAt
+		resp.Meta = entry.Meta
+		resp.TTL = -1
+		if !entry.ExpiresAt.IsZero() {
+			resp.TTL = max(time.Until(entry.ExpiresAt), 0)
+		}
+		return resp
+	case wire.OpSet:
+		if int64(len(req.Value)) > h.opts.MaxValueSize {
+			return wire.ErrorResponse(http.StatusRequestEntityTooLarge, handler.ErrValueTooLarge)
+		}
+		h.s.SetWithMeta(req.Key, req.Value, req.TTL, req.Meta)
+	case wire.OpDelete:
+		h.s.Delete(req.Key)
+	case wire.OpMGet:
+		results, err := h.mget(req.Batch)
+		if err != nil {
+			return wire.ErrorResponse(http.StatusInternalServerError, err)
+		}
+		resp := wire.OKResponse()
+		resp.Results = results
+		return resp
+	case wire.OpMSet:
+		h.s.MSet(req.Batch.Items)
+		resp := wire.OKResponse()
+		resp.Results = okResults(req.Batch)
+		return resp
+	case wire.OpMDelete:
+		h.s.MDelete(req.Batch.Keys())
+		resp := wire.OKResponse()
+		resp.Results = okResults(req.Batch)
+		return resp
+	default:
+		return wire.ErrorResponse(http.StatusBadRequest, wire.ErrUnknownOp)
+	}
+	return wire.OKResponse()
+}
@@ -0,0 +1,76 @@
+package keeper
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/aosderzhikov/sticky/internal/wire"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeWire(t *testing.T) {
+	ctx := context.Background()
+	k := NewService(time.Minute)
+	h := NewHandler(k, HandlerOptions{})
+
+	m := meta.Meta{ContentType: "text/plain", User: map[string]string{"Owner": "team-a"}}
+	resp := h.ServeWire(ctx, wire.Request{Op: wire.OpSet, Key: "key", Value: []byte("value"), TTL: time.Second, Meta: m})
+	require.Equal(t, http.StatusOK, resp.Status)
+
+	resp = h.ServeWire(ctx, wire.Request{Op: wire.OpGet, Key: "key"})
+	require.Equal(t, http.StatusOK, resp.Status)
+	require.Equal(t, []byte("value"), resp.Value)
+	stored, _, _ := k.Get("key")
+	require.Equal(t, stored.Version, resp.Version)
+	require.InDelta(t, time.Second, resp.TTL, float64(100*time.Millisecond))
+	require.Equal(t, m, resp.Meta)
+
+	k.Persist("key")
+	resp = h.ServeWire(ctx, wire.Request{Op: wire.OpGet, Key: "key"})
+	require.Negative(t, resp.TTL)
+
+	resp = h.ServeWire(ctx, wire.Request{Op: wire.OpMGet, Batch: batch.KeysRequest([]string{"key", "absent"})})
+	require.Equal(t, http.StatusOK, resp.Status)
+	require.Equal(t, []batch.Result{
+		{Key: "key", Status: batch.StatusOK, Value: []byte("value")},
+		{Key: "absent", Status: batch.StatusNotFound},
+	}, resp.Results)
+
+	resp = h.ServeWire(ctx, wire.Request{Op: wire.OpDelete, Key: "key"})
+	require.Equal(t, http.StatusOK, resp.Status)
+
+	resp = h.ServeWire(ctx, wire.Request{Op: wire.OpGet, Key: "key"})
+	require.Equal(t, http.StatusNotFound, resp.Status)
+}
+
+func TestServeWireErrors(t *testing.T) {
+	ctx := context.Background()
+	k := NewService(time.Minute)
+	h := NewHandler(k, HandlerOptions{})
+
+	_, err := k.Do(typed.Command{Op: typed.HSet, Key: "hash", Field: "f", Value: []byte("v")})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		req  wire.Request
+		code int
+	}{
+		{"empty key", wire.Request{Op: wire.OpGet}, http.StatusBadRequest},
+		{"empty batch", wire.Request{Op: wire.OpMSet}, http.StatusBadRequest},
+		{"wrong type", wire.Request{Op: wire.OpGet, Key: "hash"}, http.StatusConflict},
+		{"unknown op", wire.Request{Op: wire.Op(100), Key: "key"}, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := h.ServeWire(ctx, tt.req)
+			require.Equal(t, tt.code, resp.Status)
+			require.NotEmpty(t, resp.Msg)
+		})
+	}
+}
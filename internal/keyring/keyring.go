@@ -0,0 +1,148 @@
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxIDLen limits id of key, as its length is stored in one byte of sealed data.
+const maxIDLen = 255
+
+var (
+	ErrUnknownKey   error = errors.New("data is encrypted with unknown key")
+	ErrMalformed    error = errors.New("encrypted data is malformed")
+	ErrNoPrimary    error = errors.New("primary key is not in keyring")
+	ErrInvalidKey   error = errors.New("key must be 16, 24 or 32 bytes")
+	ErrInvalidKeyID error = errors.New("key id must be 1 to 255 bytes")
+	ErrEmptyKeyring error = errors.New("keyring has no keys")
+)
+
+// File is keyfile, keys are base64 encoded. Data is encrypted with primary key,
+// the other keys are kept to decrypt data encrypted before rotation.
+type File struct {
+	Primary string            `yaml:"primary"`
+	Keys    map[string]string `yaml:"keys"`
+}
+
+// Keyring encrypts data with AES-GCM. Sealed data starts with id of key,
+// so it is decrypted with the same key after primary key is rotated.
+type Keyring struct {
+	primary string
+	aeads   map[string]cipher.AEAD
+}
+
+// Load reads keyring from yaml keyfile.
+func Load(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f File
+	if err = yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("decode keyfile: %w", err)
+	}
+
+	keys := make(map[string][]byte, len(f.Keys))
+	for id, encoded := range f.Keys {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("decode key %q: %w", id, err)
+		}
+		keys[id] = key
+	}
+	return New(f.Primary, keys)
+}
+
+func New(primary string, keys map[string][]byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, ErrEmptyKeyring
+	}
+	if _, ok := keys[primary]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNoPrimary, primary)
+	}
+
+	kr := &Keyring{primary: primary, aeads: make(map[string]cipher.AEAD, len(keys))}
+	for id, key := range keys {
+		if id == "" || len(id) > maxIDLen {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKeyID, id)
+		}
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKey, id)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+		kr.aeads[id] = aead
+	}
+	return kr, nil
+}
+
+// Primary returns id of key which encrypts data.
+func (kr *Keyring) Primary() string {
+	return kr.primary
+}
+
+// Has reports whether keyring can decrypt data encrypted with key id.
+func (kr *Keyring) Has(id string) bool {
+	_, ok := kr.aeads[id]
+	return ok
+}
+
+// Seal encrypts data with primary key, ad is authenticated with data but isnt stored,
+// so the same ad is needed to open it. Sealed data is id length, id, nonce and ciphertext.
+func (kr *Keyring) Seal(data, ad []byte) []byte {
+	aead := kr.aeads[kr.primary]
+
+	n := 1 + len(kr.primary)
+	sealed := make([]byte, n+aead.NonceSize(), n+aead.NonceSize()+len(data)+aead.Overhead())
+	sealed[0] = byte(len(kr.primary))
+	copy(sealed[1:], kr.primary)
+
+	nonce := sealed[n:]
+	if _, err := rand.Read(nonce); err != nil {
+		// rand doesnt fail on supported platforms, reused nonce would break encryption
+		panic(fmt.Sprintf("read random nonce: %v", err))
+	}
+	return aead.Seal(sealed, nonce, data, ad)
+}
+
+// Open decrypts data sealed with any key of keyring, ErrUnknownKey is returned
+// if its key isnt in keyring.
+func (kr *Keyring) Open(sealed, ad []byte) ([]byte, error) {
+	id, err := KeyID(sealed)
+	if err != nil {
+		return nil, err
+	}
+	aead, ok := kr.aeads[id]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, id)
+	}
+
+	rest := sealed[1+len(id):]
+	if len(rest) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrMalformed
+	}
+	data, err := aead.Open(nil, rest[:aead.NonceSize()], rest[aead.NonceSize():], ad)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+	return data, nil
+}
+
+// KeyID returns id of key which sealed data.
+func KeyID(sealed []byte) (string, error) {
+	if len(sealed) == 0 || len(sealed) < 1+int(sealed[0]) || sealed[0] == 0 {
+		return "", ErrMalformed
+	}
+	return string(sealed[1 : 1+sealed[0]]), nil
+}
@@ -0,0 +1,92 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	key1 = bytes.Repeat([]byte{1}, 32)
+	key2 = bytes.Repeat([]byte{2}, 16)
+)
+
+func TestSealAndOpen(t *testing.T) {
+	old, err := New("k1", map[string][]byte{"k1": key1})
+	require.NoError(t, err)
+	sealed := old.Seal([]byte("secret"), []byte("key"))
+	require.NotContains(t, string(sealed), "secret")
+
+	id, err := KeyID(sealed)
+	require.NoError(t, err)
+	require.Equal(t, "k1", id)
+
+	// after rotation data of previous key is still opened
+	rotated, err := New("k2", map[string][]byte{"k1": key1, "k2": key2})
+	require.NoError(t, err)
+	data, err := rotated.Open(sealed, []byte("key"))
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), data)
+
+	resealed := rotated.Seal(data, []byte("key"))
+	id, err = KeyID(resealed)
+	require.NoError(t, err)
+	require.Equal(t, "k2", id)
+
+	// the same data is sealed with different nonces
+	require.NotEqual(t, resealed, rotated.Seal(data, []byte("key")))
+
+	_, err = old.Open(resealed, []byte("key"))
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	_, err = rotated.Open(sealed, []byte("another key"))
+	require.ErrorIs(t, err, ErrMalformed)
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+	_, err = rotated.Open(tampered, []byte("key"))
+	require.ErrorIs(t, err, ErrMalformed)
+
+	_, err = rotated.Open(sealed[:5], []byte("key"))
+	require.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary string
+		keys    map[string][]byte
+		err     error
+	}{
+		{"no keys", "k1", nil, ErrEmptyKeyring},
+		{"no primary", "k3", map[string][]byte{"k1": key1}, ErrNoPrimary},
+		{"short key", "k1", map[string][]byte{"k1": []byte("short")}, ErrInvalidKey},
+		{"empty id", "", map[string][]byte{"": key1}, ErrInvalidKeyID},
+	}
+	for _, tt := range tests {
+		_, err := New(tt.primary, tt.keys)
+		require.ErrorIs(t, err, tt.err, tt.name)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keyfile.yaml")
+	keyfile := "primary: k2\nkeys:\n" +
+		"  k1: " + base64.StdEncoding.EncodeToString(key1) + "\n" +
+		"  k2: " + base64.StdEncoding.EncodeToString(key2) + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(keyfile), 0o600))
+
+	kr, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "k2", kr.Primary())
+	require.True(t, kr.Has("k1"))
+	require.False(t, kr.Has("k3"))
+
+	require.NoError(t, os.WriteFile(path, []byte("primary: k1\nkeys:\n  k1: not base64!\n"), 0o600))
+	_, err = Load(path)
+	require.Error(t, err)
+}
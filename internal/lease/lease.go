@@ -0,0 +1,20 @@
+package lease
+
+import (
+	"errors"
+	"time"
+)
+
+// Lease is a granted lock. Token is fencing token, it is greater than tokens of all
+// leases granted before, so storage protected by lock can reject writes with stale token.
+type Lease struct {
+	Key       string    `json:"key"`
+	Owner     string    `json:"owner"`
+	Token     uint64    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+var (
+	ErrLocked   error = errors.New("lock is held by another owner")
+	ErrNotOwner error = errors.New("lock is held by another owner or isnt held")
+)
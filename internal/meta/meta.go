@@ -0,0 +1,106 @@
+// Package meta describes metadata stored with value: its content type, content encoding
+// and user headers X-Sticky-Meta-*.
+package meta
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// HeaderPrefix is prefix of user metadata headers, name of metadata is the rest of header.
+	HeaderPrefix = "X-Sticky-Meta-"
+
+	// MaxUserHeaders limits number of user metadata headers of one value.
+	MaxUserHeaders = 16
+	// MaxSize limits total length of names and values of metadata.
+	MaxSize = 2048
+)
+
+var (
+	ErrTooManyHeaders error = fmt.Errorf("more than %d metadata headers", MaxUserHeaders)
+	ErrTooLarge       error = fmt.Errorf("metadata is larger than %d bytes", MaxSize)
+	ErrEmptyName      error = errors.New("metadata header name cannot be empty")
+)
+
+// Meta is metadata of value, zero Meta means client didnt pass any.
+type Meta struct {
+	ContentType     string `json:"contentType,omitempty"`
+	ContentEncoding string `json:"contentEncoding,omitempty"`
+	// User is keyed by canonical header names without HeaderPrefix.
+	User map[string]string `json:"meta,omitempty"`
+}
+
+// FromHeader extracts metadata of request or response, only first value of each header is taken.
+func FromHeader(h http.Header) (Meta, error) {
+	m := Meta{
+		ContentType:     h.Get("Content-Type"),
+		ContentEncoding: h.Get("Content-Encoding"),
+	}
+
+	for name, values := range h {
+		name = http.CanonicalHeaderKey(name)
+		if !strings.HasPrefix(name, HeaderPrefix) || len(values) == 0 {
+			continue
+		}
+		if m.User == nil {
+			m.User = make(map[string]string)
+		}
+		m.User[strings.TrimPrefix(name, HeaderPrefix)] = values[0]
+	}
+
+	if err := m.Validate(); err != nil {
+		return Meta{}, err
+	}
+	return m, nil
+}
+
+// Validate checks limits of metadata, e.g. passed in json.
+func (m Meta) Validate() error {
+	if len(m.User) > MaxUserHeaders {
+		return ErrTooManyHeaders
+	}
+	if _, ok := m.User[""]; ok {
+		return ErrEmptyName
+	}
+	if m.Size() > MaxSize {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+// Put sets headers of metadata, empty fields arent set. Content-Type of value without it
+// is set to nil, so http server doesnt sniff type of untyped value.
+func (m Meta) Put(h http.Header) {
+	if m.ContentType != "" {
+		h.Set("Content-Type", m.ContentType)
+	} else {
+		h["Content-Type"] = nil
+	}
+	if m.ContentEncoding != "" {
+		h.Set("Content-Encoding", m.ContentEncoding)
+	}
+	m.PutUser(h)
+}
+
+// PutUser sets only user headers, e.g. when body isnt the value itself.
+func (m Meta) PutUser(h http.Header) {
+	for name, value := range m.User {
+		h.Set(HeaderPrefix+name, value)
+	}
+}
+
+// Size returns length of names and values of metadata.
+func (m Meta) Size() int {
+	n := len(m.ContentType) + len(m.ContentEncoding)
+	for name, value := range m.User {
+		n += len(name) + len(value)
+	}
+	return n
+}
+
+func (m Meta) IsZero() bool {
+	return m.ContentType == "" && m.ContentEncoding == "" && len(m.User) == 0
+}
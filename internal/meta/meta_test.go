@@ -0,0 +1,83 @@
+package meta
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromHeader(t *testing.T) {
+	tooMany := http.Header{}
+	for i := range MaxUserHeaders + 1 {
+		tooMany.Set(HeaderPrefix+strings.Repeat("a", i+1), "v")
+	}
+
+	cases := []struct {
+		name    string
+		header  http.Header
+		want    Meta
+		wantErr error
+	}{
+		{
+			name:   "empty",
+			header: http.Header{},
+		},
+		{
+			name: "all fields",
+			header: http.Header{
+				"Content-Type":         {"application/json"},
+				"Content-Encoding":     {"gzip"},
+				"X-Sticky-Meta-Owner":  {"team-a", "ignored"},
+				"x-sticky-meta-source": {"import"},
+				"X-Sticky-Version":     {"3"},
+			},
+			want: Meta{
+				ContentType:     "application/json",
+				ContentEncoding: "gzip",
+				User:            map[string]string{"Owner": "team-a", "Source": "import"},
+			},
+		},
+		{
+			name:    "too many headers",
+			header:  tooMany,
+			wantErr: ErrTooManyHeaders,
+		},
+		{
+			name:    "too large",
+			header:  http.Header{"X-Sticky-Meta-Big": {strings.Repeat("a", MaxSize)}},
+			wantErr: ErrTooLarge,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := FromHeader(tt.header)
+			require.ErrorIs(t, err, tt.wantErr)
+			require.Equal(t, tt.want, m)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	require.NoError(t, Meta{ContentType: "text/plain"}.Validate())
+	require.ErrorIs(t, Meta{User: map[string]string{"": "v"}}.Validate(), ErrEmptyName)
+	require.ErrorIs(t, Meta{ContentType: strings.Repeat("a", MaxSize+1)}.Validate(), ErrTooLarge)
+}
+
+func TestPut(t *testing.T) {
+	m := Meta{ContentType: "image/png", User: map[string]string{"Owner": "team-a"}}
+
+	h := http.Header{}
+	m.Put(h)
+	require.Equal(t, "image/png", h.Get("Content-Type"))
+	require.Empty(t, h.Values("Content-Encoding"))
+	require.Equal(t, "team-a", h.Get("X-Sticky-Meta-Owner"))
+
+	got, err := FromHeader(h)
+	require.NoError(t, err)
+	require.Equal(t, m, got)
+	require.Equal(t, len("image/png")+len("Owner")+len("team-a"), m.Size())
+	require.True(t, Meta{}.IsZero())
+	require.False(t, m.IsZero())
+}
@@ -0,0 +1,149 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+type Algorithm string
+
+const (
+	// SlidingWindow keeps log of taken costs and allows request if sum of costs
+	// taken within the last window plus request cost doesnt exceed limit.
+	SlidingWindow Algorithm = "sliding_window"
+	// TokenBucket refills bucket of limit tokens evenly during window and allows request
+	// if bucket has enough tokens.
+	TokenBucket Algorithm = "token_bucket"
+)
+
+type Params struct {
+	Algorithm Algorithm
+	Limit     int64
+	Window    time.Duration
+	Cost      int64
+}
+
+var (
+	ErrUnknownAlgorithm error = errors.New("unknown rate limit algorithm")
+	ErrInvalidParams    error = errors.New("limit, window and cost must be positive, cost cannot exceed limit")
+)
+
+func (p Params) Validate() error {
+	switch p.Algorithm {
+	case SlidingWindow, TokenBucket:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownAlgorithm, p.Algorithm)
+	}
+
+	if p.Limit <= 0 || p.Window <= 0 || p.Cost <= 0 || p.Cost > p.Limit {
+		return ErrInvalidParams
+	}
+	return nil
+}
+
+type Decision struct {
+	Allowed   bool
+	Remaining int64
+	// RetryAfter is zero if request is allowed.
+	RetryAfter time.Duration
+}
+
+// State of rate limit of single key, it isnt safe for concurrent use.
+type State struct {
+	params Params
+	log    []logEntry
+	tokens float64
+	last   time.Time
+}
+
+type logEntry struct {
+	at   time.Time
+	cost int64
+}
+
+// Take evaluates request with cost of params. State is reset if algorithm, limit or window
+// were changed, cost differs between requests, so its change doesnt reset state.
+func (s *State) Take(p Params, now time.Time) Decision {
+	if s.params.Algorithm != p.Algorithm || s.params.Limit != p.Limit || s.params.Window != p.Window {
+		*s = State{tokens: float64(p.Limit), last: now}
+	}
+	s.params = p
+
+	if p.Algorithm == TokenBucket {
+		return s.takeToken(now)
+	}
+	return s.takeSliding(now)
+}
+
+// ExpiresAt returns time after which state is the same as new one.
+func (s *State) ExpiresAt() time.Time {
+	if s.params.Algorithm == TokenBucket {
+		missing := float64(s.params.Limit) - s.tokens
+		return s.last.Add(time.Duration(math.Ceil(missing / s.rate())))
+	}
+
+	if len(s.log) == 0 {
+		return s.last
+	}
+	return s.log[len(s.log)-1].at.Add(s.params.Window)
+}
+
+func (s *State) takeSliding(now time.Time) Decision {
+	p := s.params
+	s.last = now
+
+	windowStart := now.Add(-p.Window)
+	i := 0
+	for i < len(s.log) && !s.log[i].at.After(windowStart) {
+		i++
+	}
+	s.log = s.log[i:]
+
+	var used int64
+	for _, e := range s.log {
+		used += e.cost
+	}
+
+	if used+p.Cost <= p.Limit {
+		s.log = append(s.log, logEntry{now, p.Cost})
+		return Decision{Allowed: true, Remaining: p.Limit - used - p.Cost}
+	}
+
+	remaining := p.Limit - used
+
+	// wait until enough of the oldest costs leave the window
+	var retryAfter time.Duration
+	for _, e := range s.log {
+		used -= e.cost
+		if used+p.Cost <= p.Limit {
+			retryAfter = e.at.Add(p.Window).Sub(now)
+			break
+		}
+	}
+	return Decision{Allowed: false, Remaining: remaining, RetryAfter: retryAfter}
+}
+
+func (s *State) takeToken(now time.Time) Decision {
+	p := s.params
+	elapsed := now.Sub(s.last)
+	if elapsed > 0 {
+		s.tokens = math.Min(float64(p.Limit), s.tokens+float64(elapsed)*s.rate())
+		s.last = now
+	}
+
+	cost := float64(p.Cost)
+	if s.tokens >= cost {
+		s.tokens -= cost
+		return Decision{Allowed: true, Remaining: int64(s.tokens)}
+	}
+
+	retryAfter := time.Duration(math.Ceil((cost - s.tokens) / s.rate()))
+	return Decision{Allowed: false, Remaining: int64(s.tokens), RetryAfter: retryAfter}
+}
+
+// rate returns tokens refilled per nanosecond.
+func (s *State) rate() float64 {
+	return float64(s.params.Limit) / float64(s.params.Window)
+}
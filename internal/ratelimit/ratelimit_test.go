@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	p := Params{Algorithm: SlidingWindow, Limit: 3, Window: time.Second, Cost: 1}
+	now := time.Now()
+	var s State
+
+	for i := range 3 {
+		d := s.Take(p, now.Add(time.Duration(i)*100*time.Millisecond))
+		require.True(t, d.Allowed)
+		require.Equal(t, int64(2-i), d.Remaining)
+	}
+
+	d := s.Take(p, now.Add(500*time.Millisecond))
+	require.False(t, d.Allowed)
+	require.Equal(t, int64(0), d.Remaining)
+	require.Equal(t, 500*time.Millisecond, d.RetryAfter)
+
+	d = s.Take(p, now.Add(time.Second+time.Millisecond))
+	require.True(t, d.Allowed)
+	require.Equal(t, now.Add(2*time.Second+time.Millisecond), s.ExpiresAt())
+}
+
+func TestTokenBucket(t *testing.T) {
+	p := Params{Algorithm: TokenBucket, Limit: 10, Window: time.Second, Cost: 5}
+	now := time.Now()
+	var s State
+
+	require.True(t, s.Take(p, now).Allowed)
+	require.True(t, s.Take(p, now).Allowed)
+
+	d := s.Take(p, now)
+	require.False(t, d.Allowed)
+	require.Equal(t, 500*time.Millisecond, d.RetryAfter)
+	require.Equal(t, now.Add(time.Second), s.ExpiresAt())
+
+	d = s.Take(p, now.Add(500*time.Millisecond))
+	require.True(t, d.Allowed)
+	require.Equal(t, int64(0), d.Remaining)
+}
+
+func TestParamsChangeResetsState(t *testing.T) {
+	p := Params{Algorithm: SlidingWindow, Limit: 1, Window: time.Minute, Cost: 1}
+	now := time.Now()
+	var s State
+
+	require.True(t, s.Take(p, now).Allowed)
+	require.False(t, s.Take(p, now).Allowed)
+
+	p.Algorithm = TokenBucket
+	require.True(t, s.Take(p, now).Allowed)
+}
+
+func TestCostChangeKeepsState(t *testing.T) {
+	for _, algorithm := range []Algorithm{SlidingWindow, TokenBucket} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			p := Params{Algorithm: algorithm, Limit: 3, Window: time.Minute}
+			now := time.Now()
+			var s State
+
+			allowed := 0
+			for i := range 100 {
+				p.Cost = int64(i%2 + 1)
+				if s.Take(p, now.Add(time.Duration(i)*time.Millisecond)).Allowed {
+					allowed++
+				}
+			}
+			// cost 1 and 2 fit limit once, then requests are denied until window passes
+			require.Equal(t, 2, allowed)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	require.ErrorIs(t, Params{Algorithm: "leaky", Limit: 1, Window: time.Second, Cost: 1}.Validate(), ErrUnknownAlgorithm)
+	require.ErrorIs(t, Params{Algorithm: TokenBucket, Limit: 1, Window: time.Second, Cost: 2}.Validate(), ErrInvalidParams)
+	require.NoError(t, Params{Algorithm: TokenBucket, Limit: 1, Window: time.Second, Cost: 1}.Validate())
+}
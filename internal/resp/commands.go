@@ -0,0 +1,426 @@
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/typed"
+)
+
+type command struct {
+	// arity is number of arguments including name of command, negative arity is minimal number.
+	arity  int
+	handle func(s *Server, ctx context.Context, c *client, args [][]byte)
+}
+
+var commands = map[string]command{
+	"ping":    {-1, (*Server).ping},
+	"echo":    {2, (*Server).echo},
+	"hello":   {-1, (*Server).hello},
+	"quit":    {-1, (*Server).quit},
+	"command": {-1, (*Server).command},
+	"select":  {2, (*Server).selectDB},
+	"info":    {-1, (*Server).info},
+	"get":     {2, (*Server).get},
+	"set":     {-3, (*Server).set},
+	"del":     {-2, (*Server).del},
+	"expire":  {-3, (*Server).expire},
+	"ttl":     {2, (*Server).ttl},
+	"persist": {2, (*Server).persist},
+	"incr":    {2, (*Server).incr},
+	"mget":    {-2, (*Server).mget},
+	"mset":    {-3, (*Server).mset},
+	"scan":    {-2, (*Server).scan},
+}
+
+const (
+	errSyntax      = "ERR syntax error"
+	errNotInteger  = "ERR value is not an integer or out of range"
+	errWrongType   = "WRONGTYPE Operation against a key holding the wrong kind of value"
+	errInvalidTime = "ERR invalid expire time in '%s' command"
+)
+
+func (s *Server) exec(ctx context.Context, c *client, args [][]byte) {
+	name := strings.ToLower(string(args[0]))
+	cmd, ok := commands[name]
+	if !ok {
+		c.w.WriteError(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+		return
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || len(args) < -cmd.arity {
+		c.w.WriteError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", name))
+		return
+	}
+	cmd.handle(s, ctx, c, args)
+}
+
+func (c *client) writeErr(err error) {
+	switch {
+	case errors.Is(err, typed.ErrWrongType):
+		c.w.WriteError(errWrongType)
+	case errors.Is(err, ErrNotInteger):
+		c.w.WriteError(errNotInteger)
+	default:
+		c.w.WriteError("ERR " + err.Error())
+	}
+}
+
+func (c *client) writeBool(b bool) {
+	if b {
+		c.w.WriteInt(1)
+		return
+	}
+	c.w.WriteInt(0)
+}
+
+func (s *Server) ping(_ context.Context, c *client, args [][]byte) {
+	switch len(args) {
+	case 1:
+		c.w.WriteSimple("PONG")
+	case 2:
+		c.w.WriteBulk(args[1])
+	default:
+		c.w.WriteError("ERR wrong number of arguments for 'ping' command")
+	}
+}
+
+func (s *Server) echo(_ context.Context, c *client, args [][]byte) {
+	c.w.WriteBulk(args[1])
+}
+
+// hello switches protocol of replies, options of authentication and client name are ignored.
+func (s *Server) hello(_ context.Context, c *client, args [][]byte) {
+	if len(args) > 1 {
+		proto, err := strconv.Atoi(string(args[1]))
+		if err != nil {
+			c.w.WriteError("ERR Protocol version is not an integer or out of range")
+			return
+		}
+		if proto != 2 && proto != 3 {
+			c.w.WriteError("NOPROTO unsupported protocol version")
+			return
+		}
+		c.w.SetProtocol(proto)
+	}
+
+	c.w.WriteMap(6)
+	c.w.WriteBulkString("server")
+	c.w.WriteBulkString("sticky")
+	c.w.WriteBulkString("proto")
+	c.w.WriteInt(int64(c.w.Protocol()))
+	c.w.WriteBulkString("id")
+	c.w.WriteInt(c.id)
+	c.w.WriteBulkString("mode")
+	c.w.WriteBulkString("standalone")
+	c.w.WriteBulkString("role")
+	c.w.WriteBulkString("master")
+	c.w.WriteBulkString("modules")
+	c.w.WriteArray(0)
+}
+
+func (s *Server) quit(_ context.Context, c *client, _ [][]byte) {
+	c.quit = true
+	c.w.WriteSimple("OK")
+}
+
+// command replies with empty list, so clients which ask for docs of commands on start, e.g. redis-cli, dont fail.
+func (s *Server) command(_ context.Context, c *client, _ [][]byte) {
+	c.w.WriteArray(0)
+}
+
+// selectDB accepts only database 0, keys arent split by databases.
+func (s *Server) selectDB(_ context.Context, c *client, args [][]byte) {
+	if string(args[1]) != "0" {
+		c.w.WriteError("ERR DB index is out of range")
+		return
+	}
+	c.w.WriteSimple("OK")
+}
+
+func (s *Server) info(ctx context.Context, c *client, args [][]byte) {
+	sections := map[string][]string{
+		"server": {
+			"server:sticky",
+			fmt.Sprintf("uptime_in_seconds:%d", int64(time.Since(s.started).Seconds())),
+		},
+		"clients": {
+			fmt.Sprintf("connected_clients:%d", s.clients()),
+		},
+	}
+
+	fields := s.backend.Info(ctx)
+	sticky := make([]string, 0, len(fields))
+	for name, value := range fields {
+		sticky = append(sticky, name+":"+value)
+	}
+	slices.Sort(sticky)
+	sections["sticky"] = sticky
+
+	requested := []string{"server", "clients", "sticky"}
+	if len(args) > 1 {
+		requested = requested[:0]
+		for _, arg := range args[1:] {
+			name := strings.ToLower(string(arg))
+			if name == "all" || name == "everything" || name == "default" {
+				requested = []string{"server", "clients", "sticky"}
+				break
+			}
+			requested = append(requested, name)
+		}
+	}
+
+	var b strings.Builder
+	for _, name := range requested {
+		lines, ok := sections[name]
+		if !ok {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteString("\r\n")
+		}
+		b.WriteString("# " + strings.ToUpper(name[:1]) + name[1:] + "\r\n")
+		for _, line := range lines {
+			b.WriteString(line + "\r\n")
+		}
+	}
+	c.w.WriteBulkString(b.String())
+}
+
+func (s *Server) get(ctx context.Context, c *client, args [][]byte) {
+	value, found, err := s.backend.Get(ctx, string(args[1]))
+	switch {
+	case err != nil:
+		c.writeErr(err)
+	case !found:
+		c.w.WriteNull()
+	default:
+		if value == nil {
+			// empty value isnt null
+			value = []byte{}
+		}
+		c.w.WriteBulk(value)
+	}
+}
+
+// set supports NX, XX, EX and PX options. Value without EX and PX gets default ttl of keeper.
+func (s *Server) set(ctx context.Context, c *client, args [][]byte) {
+	var (
+		cond Condition
+		ttl  time.Duration
+	)
+	for i := 3; i < len(args); i++ {
+		switch opt := strings.ToUpper(string(args[i])); {
+		case (opt == "NX" || opt == "XX") && cond == Always:
+			cond = IfAbsent
+			if opt == "XX" {
+				cond = IfPresent
+			}
+		case (opt == "EX" || opt == "PX") && ttl == 0 && i+1 < len(args):
+			i++
+			unit := time.Second
+			if opt == "PX" {
+				unit = time.Millisecond
+			}
+
+			var ok bool
+			ttl, ok = duration(args[i], unit)
+			if !ok {
+				c.w.WriteError(fmt.Sprintf(errInvalidTime, "set"))
+				return
+			}
+		default:
+			c.w.WriteError(errSyntax)
+			return
+		}
+	}
+
+	stored, err := s.backend.Set(ctx, string(args[1]), args[2], ttl, cond)
+	switch {
+	case err != nil:
+		c.writeErr(err)
+	case !stored:
+		c.w.WriteNull()
+	default:
+		c.w.WriteSimple("OK")
+	}
+}
+
+func (s *Server) del(ctx context.Context, c *client, args [][]byte) {
+	deleted, err := s.backend.Delete(ctx, keys(args[1:]))
+	if err != nil {
+		c.writeErr(err)
+		return
+	}
+	c.w.WriteInt(int64(deleted))
+}
+
+// expire deletes key if seconds arent positive, the same as Redis does.
+func (s *Server) expire(ctx context.Context, c *client, args [][]byte) {
+	if len(args) > 3 {
+		c.w.WriteError(errSyntax)
+		return
+	}
+
+	key := string(args[1])
+	seconds, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		c.w.WriteError(errNotInteger)
+		return
+	}
+	if seconds > math.MaxInt64/int64(time.Second) {
+		c.w.WriteError(fmt.Sprintf(errInvalidTime, "expire"))
+		return
+	}
+
+	if seconds <= 0 {
+		deleted, err := s.backend.Delete(ctx, []string{key})
+		if err != nil {
+			c.writeErr(err)
+			return
+		}
+		c.w.WriteInt(int64(deleted))
+		return
+	}
+
+	found, err := s.backend.Expire(ctx, key, time.Duration(seconds)*time.Second)
+	if err != nil {
+		c.writeErr(err)
+		return
+	}
+	c.writeBool(found)
+}
+
+// ttl replies with -2 if key doesnt exist and with -1 if key doesnt expire.
+func (s *Server) ttl(ctx context.Context, c *client, args [][]byte) {
+	ttl, found, err := s.backend.TTL(ctx, string(args[1]))
+	switch {
+	case err != nil:
+		c.writeErr(err)
+	case !found:
+		c.w.WriteInt(-2)
+	case ttl < 0:
+		c.w.WriteInt(-1)
+	default:
+		c.w.WriteInt(int64((ttl + time.Second/2) / time.Second))
+	}
+}
+
+func (s *Server) persist(ctx context.Context, c *client, args [][]byte) {
+	persisted, err := s.backend.Persist(ctx, string(args[1]))
+	if err != nil {
+		c.writeErr(err)
+		return
+	}
+	c.writeBool(persisted)
+}
+
+func (s *Server) incr(ctx context.Context, c *client, args [][]byte) {
+	value, err := s.backend.Incr(ctx, string(args[1]))
+	if err != nil {
+		c.writeErr(err)
+		return
+	}
+	c.w.WriteInt(value)
+}
+
+func (s *Server) mget(ctx context.Context, c *client, args [][]byte) {
+	values, err := s.backend.MGet(ctx, keys(args[1:]))
+	if err != nil {
+		c.writeErr(err)
+		return
+	}
+
+	c.w.WriteArray(len(values))
+	for _, value := range values {
+		c.w.WriteBulk(value)
+	}
+}
+
+func (s *Server) mset(ctx context.Context, c *client, args [][]byte) {
+	if len(args)%2 == 0 {
+		c.w.WriteError("ERR wrong number of arguments for 'mset' command")
+		return
+	}
+
+	items := make([]batch.Item, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		items = append(items, batch.Item{Key: string(args[i]), Value: args[i+1]})
+	}
+
+	if err := s.backend.MSet(ctx, items); err != nil {
+		c.writeErr(err)
+		return
+	}
+	c.w.WriteSimple("OK")
+}
+
+// scan supports MATCH and COUNT options, pattern supports only * and ?.
+func (s *Server) scan(ctx context.Context, c *client, args [][]byte) {
+	cursor, err := strconv.ParseUint(string(args[1]), 10, 64)
+	if err != nil {
+		c.w.WriteError("ERR invalid cursor")
+		return
+	}
+
+	var (
+		pattern string
+		count   int
+	)
+	for i := 2; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			c.w.WriteError(errSyntax)
+			return
+		}
+
+		switch strings.ToUpper(string(args[i])) {
+		case "MATCH":
+			pattern = string(args[i+1])
+		case "COUNT":
+			count, err = strconv.Atoi(string(args[i+1]))
+			if err != nil || count < 1 {
+				c.w.WriteError(errNotInteger)
+				return
+			}
+		default:
+			c.w.WriteError(errSyntax)
+			return
+		}
+	}
+
+	next, found, err := s.backend.Scan(ctx, cursor, pattern, count)
+	if err != nil {
+		c.writeErr(err)
+		return
+	}
+
+	c.w.WriteArray(2)
+	c.w.WriteBulkString(strconv.FormatUint(next, 10))
+	c.w.WriteArray(len(found))
+	for _, key := range found {
+		c.w.WriteBulkString(key)
+	}
+}
+
+func keys(args [][]byte) []string {
+	keys := make([]string, 0, len(args))
+	for _, arg := range args {
+		keys = append(keys, string(arg))
+	}
+	return keys
+}
+
+// duration parses positive number of units.
+func duration(arg []byte, unit time.Duration) (time.Duration, bool) {
+	n, err := strconv.ParseInt(string(arg), 10, 64)
+	if err != nil || n <= 0 || n > math.MaxInt64/int64(unit) {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
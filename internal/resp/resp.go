@@ -0,0 +1,213 @@
+package resp
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// RESP is the protocol of Redis, clients send commands as arrays of bulk strings
+// or as inline commands, e.g. from telnet. Replies are written in RESP2 by default
+// or in RESP3 after HELLO 3.
+
+const (
+	// MaxBulkLen limits size of single argument of command.
+	MaxBulkLen = 64 << 20
+	// MaxArgs limits number of arguments of command.
+	MaxArgs = 1 << 20
+	// maxInlineLen limits length of inline command and of lines with lengths.
+	maxInlineLen = 64 << 10
+)
+
+var ErrProtocol error = errors.New("protocol error")
+
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{bufio.NewReaderSize(r, maxInlineLen)}
+}
+
+// Buffered returns number of bytes which can be read without blocking,
+// it is non zero if client pipelined the next command.
+func (r *Reader) Buffered() int {
+	return r.r.Buffered()
+}
+
+// ReadCommand returns arguments of the next command, the first one is name of command.
+// Empty commands are skipped.
+func (r *Reader) ReadCommand() ([][]byte, error) {
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+
+		var args [][]byte
+		if len(line) > 0 && line[0] == '*' {
+			args, err = r.readArray(line)
+		} else {
+			args = inline(line)
+		}
+		if err != nil || len(args) > 0 {
+			return args, err
+		}
+	}
+}
+
+func (r *Reader) readArray(line []byte) ([][]byte, error) {
+	n, err := length(line[1:], MaxArgs)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid multibulk length", ErrProtocol)
+	}
+
+	args := make([][]byte, 0, min(n, 1024))
+	for range n {
+		line, err = r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("%w: expected '$', got '%s'", ErrProtocol, firstByte(line))
+		}
+
+		size, err := length(line[1:], MaxBulkLen)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid bulk length", ErrProtocol)
+		}
+
+		arg := make([]byte, size+2)
+		if _, err = io.ReadFull(r.r, arg); err != nil {
+			return nil, err
+		}
+		if !bytes.HasSuffix(arg, []byte("\r\n")) {
+			return nil, fmt.Errorf("%w: bulk isnt terminated by CRLF", ErrProtocol)
+		}
+		args = append(args, arg[:size])
+	}
+	return args, nil
+}
+
+// readLine returns line without CRLF, the returned slice is valid until the next read.
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.r.ReadSlice('\n')
+	if errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("%w: too big inline request", ErrProtocol)
+	}
+	if err != nil {
+		return nil, err
+	}
+	line = bytes.TrimSuffix(line[:len(line)-1], []byte("\r"))
+	return line, nil
+}
+
+func inline(line []byte) [][]byte {
+	fields := bytes.Fields(line)
+	args := make([][]byte, 0, len(fields))
+	for _, f := range fields {
+		args = append(args, bytes.Clone(f))
+	}
+	return args
+}
+
+func length(b []byte, limit int) (int, error) {
+	n, err := strconv.Atoi(string(b))
+	if err != nil || n < 0 || n > limit {
+		return 0, ErrProtocol
+	}
+	return n, nil
+}
+
+func firstByte(line []byte) string {
+	if len(line) == 0 {
+		return ""
+	}
+	return string(line[:1])
+}
+
+// Writer buffers replies, they are sent to client by Flush.
+type Writer struct {
+	w *bufio.Writer
+	// proto is version of protocol of replies, 2 or 3.
+	proto int
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w), proto: 2}
+}
+
+func (w *Writer) SetProtocol(proto int) {
+	w.proto = proto
+}
+
+func (w *Writer) Protocol() int {
+	return w.proto
+}
+
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+func (w *Writer) WriteSimple(s string) {
+	w.line('+', s)
+}
+
+// WriteError writes error reply, msg starts with error code, e.g. "ERR syntax error".
+func (w *Writer) WriteError(msg string) {
+	w.line('-', strings.NewReplacer("\r", " ", "\n", " ").Replace(msg))
+}
+
+func (w *Writer) WriteInt(n int64) {
+	w.line(':', strconv.FormatInt(n, 10))
+}
+
+// WriteBulk writes bulk string, nil b is written as null.
+func (w *Writer) WriteBulk(b []byte) {
+	if b == nil {
+		w.WriteNull()
+		return
+	}
+	w.line('$', strconv.Itoa(len(b)))
+	_, _ = w.w.Write(b)
+	_, _ = w.w.WriteString("\r\n")
+}
+
+func (w *Writer) WriteBulkString(s string) {
+	w.line('$', strconv.Itoa(len(s)))
+	_, _ = w.w.WriteString(s)
+	_, _ = w.w.WriteString("\r\n")
+}
+
+func (w *Writer) WriteNull() {
+	if w.proto == 3 {
+		w.line('_', "")
+		return
+	}
+	w.line('$', "-1")
+}
+
+// WriteArray writes header of array, n elements have to follow it.
+func (w *Writer) WriteArray(n int) {
+	w.line('*', strconv.Itoa(n))
+}
+
+// WriteMap writes header of map, n pairs of key and value have to follow it.
+// Map is written as flat array in RESP2.
+func (w *Writer) WriteMap(n int) {
+	if w.proto == 3 {
+		w.line('%', strconv.Itoa(n))
+		return
+	}
+	w.WriteArray(2 * n)
+}
+
+func (w *Writer) line(prefix byte, s string) {
+	_ = w.w.WriteByte(prefix)
+	_, _ = w.w.WriteString(s)
+	_, _ = w.w.WriteString("\r\n")
+}
@@ -0,0 +1,116 @@
+package resp
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCommand(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "array of bulk strings",
+			input: "*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$5\r\nva\r\nl\r\n",
+			want:  []string{"SET", "key", "va\r\nl"},
+		},
+		{
+			name:  "inline command",
+			input: "GET  key\r\n",
+			want:  []string{"GET", "key"},
+		},
+		{
+			name:  "empty commands are skipped",
+			input: "\r\n*0\r\nPING\n",
+			want:  []string{"PING"},
+		},
+		{
+			name:  "empty bulk string",
+			input: "*2\r\n$4\r\nECHO\r\n$0\r\n\r\n",
+			want:  []string{"ECHO", ""},
+		},
+		{
+			name:    "invalid multibulk length",
+			input:   "*x\r\n",
+			wantErr: ErrProtocol,
+		},
+		{
+			name:    "missing bulk",
+			input:   "*1\r\n:1\r\n",
+			wantErr: ErrProtocol,
+		},
+		{
+			name:    "too big bulk",
+			input:   "*1\r\n$999999999999\r\n",
+			wantErr: ErrProtocol,
+		},
+		{
+			name:    "bulk without CRLF",
+			input:   "*1\r\n$3\r\nGETxx",
+			wantErr: ErrProtocol,
+		},
+		{
+			name:    "unexpected end",
+			input:   "*2\r\n$3\r\nGET\r\n",
+			wantErr: io.EOF,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := NewReader(strings.NewReader(tt.input)).ReadCommand()
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			got := make([]string, 0, len(args))
+			for _, arg := range args {
+				got = append(got, string(arg))
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestReadPipelinedCommands(t *testing.T) {
+	r := NewReader(strings.NewReader("*1\r\n$4\r\nPING\r\n*2\r\n$3\r\nGET\r\n$1\r\nk\r\n"))
+
+	args, err := r.ReadCommand()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("PING")}, args)
+	require.NotZero(t, r.Buffered())
+
+	args, err = r.ReadCommand()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("GET"), []byte("k")}, args)
+	require.Zero(t, r.Buffered())
+
+	_, err = r.ReadCommand()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestWriterProtocols(t *testing.T) {
+	write := func(proto int) string {
+		var buf bytes.Buffer
+		w := NewWriter(&buf)
+		w.SetProtocol(proto)
+		w.WriteMap(1)
+		w.WriteBulkString("k")
+		w.WriteBulk(nil)
+		w.WriteError("ERR bad\r\nline")
+		require.NoError(t, w.Flush())
+		return buf.String()
+	}
+
+	require.Equal(t, "*2\r\n$1\r\nk\r\n$-1\r\n-ERR bad  line\r\n", write(2))
+	require.Equal(t, "%1\r\n$1\r\nk\r\n_\r\n-ERR bad  line\r\n", write(3))
+}
@@ -0,0 +1,204 @@
+//go:generate mockgen -source=$GOFILE -destination=mock_test.go -package=$GOPACKAGE
+package resp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+)
+
+// Condition of SET, it is NX or XX option.
+type Condition int
+
+const (
+	Always Condition = iota
+	// IfAbsent sets value only if key doesnt exist.
+	IfAbsent
+	// IfPresent sets value only if key exists.
+	IfPresent
+)
+
+var (
+	// ErrNotInteger is returned by backend if INCR is applied to value which isnt integer.
+	ErrNotInteger   error = errors.New("value is not an integer or out of range")
+	ErrServerClosed error = errors.New("resp server is closed")
+)
+
+// Backend executes commands, it is keeper or bouncer. Errors are sent to client as ERR replies.
+type Backend interface {
+	// Get returns typed.ErrWrongType if value of key isnt plain value.
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	// Set stores value with ttl, zero ttl is default ttl of keeper. Stored is false if condition isnt met.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration, cond Condition) (stored bool, err error)
+	Delete(ctx context.Context, keys []string) (deleted int, err error)
+
+	Expire(ctx context.Context, key string, ttl time.Duration) (found bool, err error)
+	Persist(ctx context.Context, key string) (persisted bool, err error)
+	// TTL returns remaining ttl of key, it is negative if key doesnt expire.
+	TTL(ctx context.Context, key string) (ttl time.Duration, found bool, err error)
+
+	Incr(ctx context.Context, key string) (value int64, err error)
+
+	// MGet returns values in order of keys, value is nil if key isnt found.
+	MGet(ctx context.Context, keys []string) (values [][]byte, err error)
+	MSet(ctx context.Context, items []batch.Item) (err error)
+
+	Scan(ctx context.Context, cursor uint64, pattern string, count int) (next uint64, keys []string, err error)
+
+	// Info returns fields of sticky section of INFO.
+	Info(ctx context.Context) (fields map[string]string)
+}
+
+func NewServer(b Backend) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		ctx:     ctx,
+		cancel:  cancel,
+		backend: b,
+		started: time.Now(),
+		conns:   make(map[net.Conn]struct{}),
+	}
+}
+
+// Server serves RESP clients, e.g. redis-cli or client libraries of Redis.
+// Commands of connection are executed one by one, replies of pipelined commands
+// are sent together when client has no more buffered commands.
+type Server struct {
+	// ctx of commands is canceled by Close.
+	ctx     context.Context
+	cancel  context.CancelFunc
+	backend Backend
+	started time.Time
+	lastID  atomic.Int64
+
+	mu        sync.Mutex
+	listeners []net.Listener
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners = append(s.listeners, l)
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		if !s.track(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+		go s.serve(conn)
+	}
+}
+
+// Close stops listeners and closes connections of clients.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	s.cancel()
+	var errs []error
+	for _, l := range s.listeners {
+		errs = append(errs, l.Close())
+	}
+	for conn := range s.conns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+}
+
+func (s *Server) clients() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+type client struct {
+	id int64
+	r  *Reader
+	w  *Writer
+	// quit is set by QUIT, connection is closed after reply.
+	quit bool
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer s.untrack(conn)
+	defer conn.Close()
+
+	c := &client{id: s.lastID.Add(1), r: NewReader(conn), w: NewWriter(conn)}
+	slog.Debug(fmt.Sprintf("resp client %d connected from %q", c.id, conn.RemoteAddr()))
+
+	for {
+		args, err := c.r.ReadCommand()
+		if err != nil {
+			if errors.Is(err, ErrProtocol) {
+				c.w.WriteError("ERR " + err.Error())
+				_ = c.w.Flush()
+			}
+			if !errors.Is(err, io.EOF) && !s.isClosed() {
+				slog.Debug(fmt.Sprintf("resp client %d: %v", c.id, err))
+			}
+			return
+		}
+
+		s.exec(s.ctx, c, args)
+
+		if c.r.Buffered() == 0 || c.quit {
+			if err = c.w.Flush(); err != nil || c.quit {
+				return
+			}
+		}
+	}
+}
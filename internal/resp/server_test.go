@@ -0,0 +1,120 @@
+package resp
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/typed"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// startServer returns connection to server with backend, server is closed by cleanup.
+func startServer(t *testing.T, b Backend) net.Conn {
+	t.Helper()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := NewServer(b)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// roundTrip sends raw request and reads reply of len(want) bytes.
+func roundTrip(t *testing.T, conn net.Conn, r *bufio.Reader, req, want string) {
+	t.Helper()
+
+	_, err := conn.Write([]byte(req))
+	require.NoError(t, err)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	got := make([]byte, len(want))
+	_, err = io.ReadFull(r, got)
+	require.NoError(t, err)
+	require.Equal(t, want, string(got))
+}
+
+func TestServerPipelining(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := NewMockBackend(ctrl)
+	gomock.InOrder(
+		backend.EXPECT().Set(gomock.Any(), "k", []byte("v"), 10*time.Second, IfAbsent).Return(true, nil),
+		backend.EXPECT().Get(gomock.Any(), "k").Return([]byte("v"), true, nil),
+		backend.EXPECT().Get(gomock.Any(), "absent").Return(nil, false, nil),
+	)
+
+	conn := startServer(t, backend)
+	roundTrip(t, conn, bufio.NewReader(conn),
+		"*6\r\n$3\r\nSET\r\n$1\r\nk\r\n$1\r\nv\r\n$2\r\nnx\r\n$2\r\nEX\r\n$2\r\n10\r\n"+
+			"*2\r\n$3\r\nGET\r\n$1\r\nk\r\n"+
+			"GET absent\r\n"+
+			"PING\r\n",
+		"+OK\r\n$1\r\nv\r\n$-1\r\n+PONG\r\n")
+}
+
+func TestServerErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := NewMockBackend(ctrl)
+	backend.EXPECT().Get(gomock.Any(), "hash").Return(nil, false, typed.ErrWrongType)
+	backend.EXPECT().Incr(gomock.Any(), "text").Return(int64(0), ErrNotInteger)
+
+	conn := startServer(t, backend)
+	r := bufio.NewReader(conn)
+
+	roundTrip(t, conn, r, "GET hash\r\n", "-"+errWrongType+"\r\n")
+	roundTrip(t, conn, r, "INCR text\r\n", "-"+errNotInteger+"\r\n")
+	roundTrip(t, conn, r, "GET\r\n", "-ERR wrong number of arguments for 'get' command\r\n")
+	roundTrip(t, conn, r, "FLUSHALL\r\n", "-ERR unknown command 'FLUSHALL'\r\n")
+	roundTrip(t, conn, r, "SET k v NX XX\r\n", "-"+errSyntax+"\r\n")
+	roundTrip(t, conn, r, "SET k v EX 0\r\n", "-ERR invalid expire time in 'set' command\r\n")
+	roundTrip(t, conn, r, "SCAN x\r\n", "-ERR invalid cursor\r\n")
+}
+
+func TestServerKeyspaceCommands(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := NewMockBackend(ctrl)
+	backend.EXPECT().TTL(gomock.Any(), "absent").Return(time.Duration(0), false, nil)
+	backend.EXPECT().TTL(gomock.Any(), "persisted").Return(-time.Nanosecond, true, nil)
+	backend.EXPECT().TTL(gomock.Any(), "k").Return(1400*time.Millisecond, true, nil)
+	backend.EXPECT().Delete(gomock.Any(), []string{"k"}).Return(1, nil)
+	backend.EXPECT().Scan(gomock.Any(), uint64(0), "user:*", 5).Return(uint64(42), []string{"user:1"}, nil)
+	backend.EXPECT().MGet(gomock.Any(), []string{"a", "b"}).Return([][]byte{[]byte("1"), nil}, nil)
+
+	conn := startServer(t, backend)
+	r := bufio.NewReader(conn)
+
+	roundTrip(t, conn, r, "TTL absent\r\n", ":-2\r\n")
+	roundTrip(t, conn, r, "TTL persisted\r\n", ":-1\r\n")
+	roundTrip(t, conn, r, "TTL k\r\n", ":1\r\n")
+	// not positive ttl deletes key
+	roundTrip(t, conn, r, "EXPIRE k 0\r\n", ":1\r\n")
+	roundTrip(t, conn, r, "SCAN 0 MATCH user:* COUNT 5\r\n", "*2\r\n$2\r\n42\r\n*1\r\n$6\r\nuser:1\r\n")
+	roundTrip(t, conn, r, "MGET a b\r\n", "*2\r\n$1\r\n1\r\n$-1\r\n")
+}
+
+func TestServerHello(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	backend := NewMockBackend(ctrl)
+	backend.EXPECT().Get(gomock.Any(), "absent").Return(nil, false, nil)
+
+	conn := startServer(t, backend)
+	r := bufio.NewReader(conn)
+
+	roundTrip(t, conn, r, "HELLO 4\r\n", "-NOPROTO unsupported protocol version\r\n")
+	roundTrip(t, conn, r, "HELLO 3\r\n", "%6\r\n$6\r\nserver\r\n$6\r\nsticky\r\n$5\r\nproto\r\n:3\r\n"+
+		"$2\r\nid\r\n:1\r\n$4\r\nmode\r\n$10\r\nstandalone\r\n$4\r\nrole\r\n$6\r\nmaster\r\n$7\r\nmodules\r\n*0\r\n")
+	roundTrip(t, conn, r, "GET absent\r\n", "_\r\n")
+	roundTrip(t, conn, r, "QUIT\r\n", "+OK\r\n")
+
+	_, err := r.ReadByte()
+	require.ErrorIs(t, err, io.EOF)
+}
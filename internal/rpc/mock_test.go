@@ -0,0 +1,176 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: server.go
+//
+// Generated by this command:
+//
+//	mockgen -source=server.go -destination=mock_test.go -package=rpc
+//
+
+// Package rpc is a generated GoMock package.
+package rpc
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	batch "github.com/aosderzhikov/sticky/internal/batch"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockBackend is a mock of Backend interface.
+type MockBackend struct {
+	ctrl     *gomock.Controller
+	recorder *MockBackendMockRecorder
+}
+
+// MockBackendMockRecorder is the mock recorder for MockBackend.
+type MockBackendMockRecorder struct {
+	mock *MockBackend
+}
+
+// NewMockBackend creates a new mock instance.
+func NewMockBackend(ctrl *gomock.Controller) *MockBackend {
+	mock := &MockBackend{ctrl: ctrl}
+	mock.recorder = &MockBackendMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBackend) EXPECT() *MockBackendMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockBackend) Delete(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockBackendMockRecorder) Delete(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockBackend)(nil).Delete), ctx, key)
+}
+
+// Get mocks base method.
+func (m *MockBackend) Get(ctx context.Context, key string) (Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockBackendMockRecorder) Get(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockBackend)(nil).Get), ctx, key)
+}
+
+// MDelete mocks base method.
+func (m *MockBackend) MDelete(ctx context.Context, keys []string) ([]batch.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MDelete", ctx, keys)
+	ret0, _ := ret[0].([]batch.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MDelete indicates an expected call of MDelete.
+func (mr *MockBackendMockRecorder) MDelete(ctx, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MDelete", reflect.TypeOf((*MockBackend)(nil).MDelete), ctx, keys)
+}
+
+// MGet mocks base method.
+func (m *MockBackend) MGet(ctx context.Context, keys []string) ([]batch.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MGet", ctx, keys)
+	ret0, _ := ret[0].([]batch.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MGet indicates an expected call of MGet.
+func (mr *MockBackendMockRecorder) MGet(ctx, keys any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MGet", reflect.TypeOf((*MockBackend)(nil).MGet), ctx, keys)
+}
+
+// MSet mocks base method.
+func (m *MockBackend) MSet(ctx context.Context, items []batch.Item) ([]batch.Result, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MSet", ctx, items)
+	ret0, _ := ret[0].([]batch.Result)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MSet indicates an expected call of MSet.
+func (mr *MockBackendMockRecorder) MSet(ctx, items any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MSet", reflect.TypeOf((*MockBackend)(nil).MSet), ctx, items)
+}
+
+// Scan mocks base method.
+func (m *MockBackend) Scan(ctx context.Context, cursor uint64, pattern string, count int) (uint64, []string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", ctx, cursor, pattern, count)
+	ret0, _ := ret[0].(uint64)
+	ret1, _ := ret[1].([]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Scan indicates an expected call of Scan.
+func (mr *MockBackendMockRecorder) Scan(ctx, cursor, pattern, count any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockBackend)(nil).Scan), ctx, cursor, pattern, count)
+}
+
+// Set mocks base method.
+func (m *MockBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", ctx, key, value, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Set indicates an expected call of Set.
+func (mr *MockBackendMockRecorder) Set(ctx, key, value, ttl any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockBackend)(nil).Set), ctx, key, value, ttl)
+}
+
+// TTL mocks base method.
+func (m *MockBackend) TTL(ctx context.Context, key string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TTL", ctx, key)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TTL indicates an expected call of TTL.
+func (mr *MockBackendMockRecorder) TTL(ctx, key any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TTL", reflect.TypeOf((*MockBackend)(nil).TTL), ctx, key)
+}
+
+// Watch mocks base method.
+func (m *MockBackend) Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (Entry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, key, sinceVersion, timeout)
+	ret0, _ := ret[0].(Entry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Watch indicates an expected call of Watch.
+func (mr *MockBackendMockRecorder) Watch(ctx, key, sinceVersion, timeout any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockBackend)(nil).Watch), ctx, key, sinceVersion, timeout)
+}
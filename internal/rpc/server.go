@@ -0,0 +1,303 @@
+//go:generate mockgen -source=$GOFILE -destination=mock_test.go -package=$GOPACKAGE
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/aosderzhikov/sticky/api/stickypb"
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/scan"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Entry is value of key, TTL is negative if key doesnt expire.
+type Entry struct {
+	Value   []byte
+	Version uint64
+	TTL     time.Duration
+}
+
+var (
+	ErrEmptyKey    error = errors.New("key cannot be empty")
+	ErrNotFound    error = errors.New("key not found")
+	ErrNotModified error = errors.New("key isnt changed")
+)
+
+// Backend executes requests, it is keeper or bouncer. Errors which arent ErrNotFound,
+// ErrNotModified or context errors should be wrapped by Error to get their grpc code.
+type Backend interface {
+	// Get returns ErrNotFound if key doesnt exist.
+	Get(ctx context.Context, key string) (entry Entry, err error)
+	// Set stores value with ttl, zero ttl is default ttl of keeper.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) (err error)
+	Delete(ctx context.Context, key string) (err error)
+	// TTL returns ErrNotFound if key doesnt exist.
+	TTL(ctx context.Context, key string) (ttl time.Duration, err error)
+
+	MGet(ctx context.Context, keys []string) (results []batch.Result, err error)
+	MSet(ctx context.Context, items []batch.Item) (results []batch.Result, err error)
+	MDelete(ctx context.Context, keys []string) (results []batch.Result, err error)
+
+	Scan(ctx context.Context, cursor uint64, pattern string, count int) (next uint64, keys []string, err error)
+	// Watch returns ErrNotFound if key was deleted and ErrNotModified if it isnt changed until timeout.
+	Watch(ctx context.Context, key string, sinceVersion uint64, timeout time.Duration) (entry Entry, err error)
+}
+
+// codeError is error of backend with http status code, the same as http handlers respond with.
+type codeError struct {
+	code int
+	err  error
+}
+
+func (e *codeError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codeError) Unwrap() error {
+	return e.err
+}
+
+// Error wraps err of backend with http status code, server responds with matching grpc code.
+func Error(code int, err error) error {
+	return &codeError{code: code, err: err}
+}
+
+// watchTimeout is how long one watch of backend waits, stream watches again after it.
+var watchTimeout = 30 * time.Second
+
+func NewServer(b Backend, opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	stickypb.RegisterStickyServer(srv, &service{b: b})
+	return srv
+}
+
+func ListenAndServe(addr string, b Backend) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return NewServer(b).Serve(l)
+}
+
+type service struct {
+	stickypb.UnimplementedStickyServer
+	b Backend
+}
+
+func (s *service) Get(ctx context.Context, req *stickypb.GetRequest) (*stickypb.GetResponse, error) {
+	if req.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, ErrEmptyKey.Error())
+	}
+
+	entry, err := s.b.Get(ctx, req.GetKey())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &stickypb.GetResponse{Value: entry.Value, Version: entry.Version, Ttl: ttlToProto(entry.TTL)}, nil
+}
+
+func (s *service) Set(ctx context.Context, req *stickypb.SetRequest) (*stickypb.SetResponse, error) {
+	if req.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, ErrEmptyKey.Error())
+	}
+
+	if err := s.b.Set(ctx, req.GetKey(), req.GetValue(), req.GetTtl().AsDuration()); err != nil {
+		return nil, statusError(err)
+	}
+	return &stickypb.SetResponse{}, nil
+}
+
+func (s *service) Delete(ctx context.Context, req *stickypb.DeleteRequest) (*stickypb.DeleteResponse, error) {
+	if req.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, ErrEmptyKey.Error())
+	}
+
+	if err := s.b.Delete(ctx, req.GetKey()); err != nil {
+		return nil, statusError(err)
+	}
+	return &stickypb.DeleteResponse{}, nil
+}
+
+func (s *service) TTL(ctx context.Context, req *stickypb.TTLRequest) (*stickypb.TTLResponse, error) {
+	if req.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, ErrEmptyKey.Error())
+	}
+
+	ttl, err := s.b.TTL(ctx, req.GetKey())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &stickypb.TTLResponse{Ttl: ttlToProto(ttl)}, nil
+}
+
+func (s *service) MGet(ctx context.Context, req *stickypb.MGetRequest) (*stickypb.BatchResponse, error) {
+	batchReq := batch.KeysRequest(req.GetKeys())
+	if err := batchReq.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return batchResponse(s.b.MGet(ctx, batchReq.Keys()))
+}
+
+func (s *service) MSet(ctx context.Context, req *stickypb.MSetRequest) (*stickypb.BatchResponse, error) {
+	var batchReq batch.Request
+	for _, item := range req.GetItems() {
+		batchReq.Items = append(batchReq.Items, batch.Item{
+			Key:   item.GetKey(),
+			Value: item.GetValue(),
+			TTL:   batch.Duration(item.GetTtl().AsDuration()),
+		})
+	}
+	if err := batchReq.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return batchResponse(s.b.MSet(ctx, batchReq.Items))
+}
+
+func (s *service) MDelete(ctx context.Context, req *stickypb.MDeleteRequest) (*stickypb.BatchResponse, error) {
+	batchReq := batch.KeysRequest(req.GetKeys())
+	if err := batchReq.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return batchResponse(s.b.MDelete(ctx, batchReq.Keys()))
+}
+
+func (s *service) Scan(req *stickypb.ScanRequest, stream stickypb.Sticky_ScanServer) error {
+	ctx := stream.Context()
+
+	count := int(req.GetCount())
+	if count == 0 {
+		count = scan.DefaultCount
+	}
+
+	var cursor uint64
+	for {
+		next, keys, err := s.b.Scan(ctx, cursor, req.GetPattern(), count)
+		if err != nil {
+			return statusError(err)
+		}
+		if len(keys) > 0 {
+			if err = stream.Send(&stickypb.ScanResponse{Keys: keys}); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// Watch sends event on each change of key, deleted key is watched until it is created again.
+func (s *service) Watch(req *stickypb.WatchRequest, stream stickypb.Sticky_WatchServer) error {
+	ctx := stream.Context()
+	if req.GetKey() == "" {
+		return status.Error(codes.InvalidArgument, ErrEmptyKey.Error())
+	}
+
+	sinceVersion := req.GetSinceVersion()
+	for {
+		entry, err := s.b.Watch(ctx, req.GetKey(), sinceVersion, watchTimeout)
+		event := &stickypb.WatchEvent{Key: req.GetKey()}
+		switch {
+		case err == nil:
+			event.Value = entry.Value
+			event.Version = entry.Version
+			event.Ttl = ttlToProto(entry.TTL)
+			sinceVersion = entry.Version
+		case errors.Is(err, ErrNotFound):
+			event.Deleted = true
+			sinceVersion = 0
+		case errors.Is(err, ErrNotModified):
+			continue
+		default:
+			return statusError(err)
+		}
+
+		if err = stream.Send(event); err != nil {
+			return err
+		}
+	}
+}
+
+func batchResponse(results []batch.Result, err error) (*stickypb.BatchResponse, error) {
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	resp := &stickypb.BatchResponse{Results: make([]*stickypb.Result, 0, len(results))}
+	for _, res := range results {
+		resp.Results = append(resp.Results, &stickypb.Result{
+			Key:    res.Key,
+			Status: resultStatus(res.Status),
+			Value:  res.Value,
+			Error:  res.Error,
+		})
+	}
+	return resp, nil
+}
+
+func resultStatus(s batch.Status) stickypb.Result_Status {
+	switch s {
+	case batch.StatusOK:
+		return stickypb.Result_STATUS_OK
+	case batch.StatusNotFound:
+		return stickypb.Result_STATUS_NOT_FOUND
+	default:
+		return stickypb.Result_STATUS_ERROR
+	}
+}
+
+func ttlToProto(ttl time.Duration) *durationpb.Duration {
+	if ttl < 0 {
+		return nil
+	}
+	return durationpb.New(ttl)
+}
+
+func statusError(err error) error {
+	var codeErr *codeError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.As(err, &codeErr):
+		return status.Error(Code(codeErr.code), err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// Code maps http status code to grpc code.
+func Code(httpCode int) codes.Code {
+	switch httpCode {
+	case http.StatusOK:
+		return codes.OK
+	case http.StatusBadRequest, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable, http.StatusBadGateway:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		if httpCode >= http.StatusInternalServerError {
+			return codes.Internal
+		}
+		return codes.Unknown
+	}
+}
@@ -0,0 +1,201 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/api/stickypb"
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// newTestClient serves backend on in-process listener and returns client connected to it.
+func newTestClient(t *testing.T, b Backend) stickypb.StickyClient {
+	l := bufconn.Listen(1 << 20)
+	srv := NewServer(b)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return l.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return stickypb.NewStickyClient(conn)
+}
+
+func TestGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	b := NewMockBackend(ctrl)
+	b.EXPECT().Get(gomock.Any(), "key").Return(Entry{Value: []byte("value"), Version: 2, TTL: time.Minute}, nil)
+	b.EXPECT().Get(gomock.Any(), "persisted").Return(Entry{Value: []byte("value"), Version: 1, TTL: -1}, nil)
+
+	c := newTestClient(t, b)
+	ctx := context.Background()
+
+	resp, err := c.Get(ctx, &stickypb.GetRequest{Key: "key"})
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), resp.GetValue())
+	require.Equal(t, uint64(2), resp.GetVersion())
+	require.Equal(t, time.Minute, resp.GetTtl().AsDuration())
+
+	resp, err = c.Get(ctx, &stickypb.GetRequest{Key: "persisted"})
+	require.NoError(t, err)
+	require.Nil(t, resp.GetTtl())
+}
+
+func TestSetAndBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	b := NewMockBackend(ctrl)
+	b.EXPECT().Set(gomock.Any(), "key", []byte("value"), time.Second).Return(nil)
+	b.EXPECT().MSet(gomock.Any(), []batch.Item{{Key: "a", Value: []byte("1"), TTL: batch.Duration(time.Second)}}).
+		Return([]batch.Result{{Key: "a", Status: batch.StatusOK}}, nil)
+	b.EXPECT().MGet(gomock.Any(), []string{"a", "b"}).Return([]batch.Result{
+		{Key: "a", Status: batch.StatusOK, Value: []byte("1")},
+		{Key: "b", Status: batch.StatusNotFound},
+	}, nil)
+
+	c := newTestClient(t, b)
+	ctx := context.Background()
+
+	_, err := c.Set(ctx, &stickypb.SetRequest{Key: "key", Value: []byte("value"), Ttl: durationpb.New(time.Second)})
+	require.NoError(t, err)
+
+	resp, err := c.MSet(ctx, &stickypb.MSetRequest{Items: []*stickypb.Item{
+		{Key: "a", Value: []byte("1"), Ttl: durationpb.New(time.Second)},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, stickypb.Result_STATUS_OK, resp.GetResults()[0].GetStatus())
+
+	resp, err = c.MGet(ctx, &stickypb.MGetRequest{Keys: []string{"a", "b"}})
+	require.NoError(t, err)
+	require.Len(t, resp.GetResults(), 2)
+	require.Equal(t, []byte("1"), resp.GetResults()[0].GetValue())
+	require.Equal(t, stickypb.Result_STATUS_NOT_FOUND, resp.GetResults()[1].GetStatus())
+}
+
+func TestErrorCodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	b := NewMockBackend(ctrl)
+	b.EXPECT().Get(gomock.Any(), "absent").Return(Entry{}, ErrNotFound)
+	b.EXPECT().Get(gomock.Any(), "hash").Return(Entry{}, Error(http.StatusConflict, errors.New("wrong type")))
+	b.EXPECT().Get(gomock.Any(), "down").Return(Entry{}, Error(http.StatusServiceUnavailable, errors.New("down")))
+	b.EXPECT().Get(gomock.Any(), "broken").Return(Entry{}, errors.New("broken"))
+
+	c := newTestClient(t, b)
+	ctx := context.Background()
+
+	tests := []struct {
+		key  string
+		code codes.Code
+	}{
+		{"", codes.InvalidArgument},
+		{"absent", codes.NotFound},
+		{"hash", codes.FailedPrecondition},
+		{"down", codes.Unavailable},
+		{"broken", codes.Internal},
+	}
+	for _, tt := range tests {
+		_, err := c.Get(ctx, &stickypb.GetRequest{Key: tt.key})
+		require.Equal(t, tt.code, status.Code(err), tt.key)
+	}
+
+	_, err := c.MDelete(ctx, &stickypb.MDeleteRequest{})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestScanStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	b := NewMockBackend(ctrl)
+	gomock.InOrder(
+		b.EXPECT().Scan(gomock.Any(), uint64(0), "user:*", 2).Return(uint64(5), []string{"user:1", "user:2"}, nil),
+		b.EXPECT().Scan(gomock.Any(), uint64(5), "user:*", 2).Return(uint64(9), nil, nil),
+		b.EXPECT().Scan(gomock.Any(), uint64(9), "user:*", 2).Return(uint64(0), []string{"user:3"}, nil),
+	)
+
+	c := newTestClient(t, b)
+	stream, err := c.Scan(context.Background(), &stickypb.ScanRequest{Pattern: "user:*", Count: 2})
+	require.NoError(t, err)
+
+	var keys []string
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		keys = append(keys, resp.GetKeys()...)
+	}
+	require.Equal(t, []string{"user:1", "user:2", "user:3"}, keys)
+}
+
+func TestWatchStream(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	b := NewMockBackend(ctrl)
+	gomock.InOrder(
+		b.EXPECT().Watch(gomock.Any(), "key", uint64(0), gomock.Any()).Return(Entry{Value: []byte("1"), Version: 1, TTL: -1}, nil),
+		b.EXPECT().Watch(gomock.Any(), "key", uint64(1), gomock.Any()).Return(Entry{}, ErrNotModified),
+		b.EXPECT().Watch(gomock.Any(), "key", uint64(1), gomock.Any()).Return(Entry{}, ErrNotFound),
+		b.EXPECT().Watch(gomock.Any(), "key", uint64(0), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _ string, _ uint64, _ time.Duration) (Entry, error) {
+				<-ctx.Done()
+				return Entry{}, ctx.Err()
+			}),
+	)
+
+	c := newTestClient(t, b)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := c.Watch(ctx, &stickypb.WatchRequest{Key: "key"})
+	require.NoError(t, err)
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, []byte("1"), event.GetValue())
+	require.Equal(t, uint64(1), event.GetVersion())
+	require.False(t, event.GetDeleted())
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	require.True(t, event.GetDeleted())
+
+	cancel()
+	_, err = stream.Recv()
+	require.Equal(t, codes.Canceled, status.Code(err))
+}
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		httpCode int
+		code     codes.Code
+	}{
+		{http.StatusOK, codes.OK},
+		{http.StatusBadRequest, codes.InvalidArgument},
+		{http.StatusNotFound, codes.NotFound},
+		{http.StatusConflict, codes.FailedPrecondition},
+		{http.StatusTooManyRequests, codes.ResourceExhausted},
+		{http.StatusServiceUnavailable, codes.Unavailable},
+		{http.StatusGatewayTimeout, codes.DeadlineExceeded},
+		{http.StatusInternalServerError, codes.Internal},
+		{http.StatusTeapot, codes.Unknown},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.code, Code(tt.httpCode), tt.httpCode)
+	}
+}
@@ -0,0 +1,77 @@
+package scan
+
+import (
+	"cmp"
+	"hash/fnv"
+	"slices"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+)
+
+// DefaultCount is number of keys returned by one call if count isnt set.
+const DefaultCount = 10
+
+// Page collects keys of one scan call. Keys are iterated in order of their hash and cursor
+// is the next hash to scan from, so cursor stays valid while keys are added and removed:
+// each key which exists during the whole iteration is returned, keys changed in the middle
+// of iteration may be returned or not. Iteration starts and ends with zero cursor.
+type Page struct {
+	cursor  uint64
+	pattern string
+	count   int
+	keys    []hashedKey
+}
+
+type hashedKey struct {
+	hash uint64
+	key  string
+}
+
+// New returns page of keys matched by glob pattern starting from cursor, empty pattern matches all keys.
+func New(cursor uint64, pattern string, count int) *Page {
+	if count <= 0 {
+		count = DefaultCount
+	}
+	return &Page{cursor: cursor, pattern: pattern, count: count}
+}
+
+// Add offers key to page, keys can be offered in any order.
+func (p *Page) Add(key string) {
+	h := hash(key)
+	if h < p.cursor {
+		return
+	}
+	if p.pattern != "" && !events.Match(p.pattern, key) {
+		return
+	}
+	p.keys = append(p.keys, hashedKey{h, key})
+}
+
+// Result returns keys of page and cursor of the next page, it is zero if iteration is over.
+// Keys with the same hash are never split between pages, so page can be a bit larger than count.
+func (p *Page) Result() (uint64, []string) {
+	slices.SortFunc(p.keys, func(a, b hashedKey) int {
+		return cmp.Or(cmp.Compare(a.hash, b.hash), cmp.Compare(a.key, b.key))
+	})
+
+	n := min(p.count, len(p.keys))
+	for n < len(p.keys) && p.keys[n].hash == p.keys[n-1].hash {
+		n++
+	}
+
+	keys := make([]string, 0, n)
+	for _, k := range p.keys[:n] {
+		keys = append(keys, k.key)
+	}
+
+	if n == len(p.keys) {
+		return 0, keys
+	}
+	return p.keys[n-1].hash + 1, keys
+}
+
+func hash(key string) uint64 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return uint64(h.Sum32())
+}
@@ -0,0 +1,78 @@
+package scan
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func scanAll(t *testing.T, keys map[string]struct{}, pattern string, count int, between func()) []string {
+	t.Helper()
+
+	var (
+		cursor uint64
+		all    []string
+	)
+	for calls := 0; ; calls++ {
+		require.Less(t, calls, 1000, "scan doesnt end")
+
+		p := New(cursor, pattern, count)
+		for key := range keys {
+			p.Add(key)
+		}
+
+		var page []string
+		cursor, page = p.Result()
+		all = append(all, page...)
+		if cursor == 0 {
+			return all
+		}
+		if between != nil {
+			between()
+		}
+	}
+}
+
+func TestScanReturnsAllKeys(t *testing.T) {
+	keys := make(map[string]struct{})
+	for i := range 100 {
+		keys[fmt.Sprintf("key:%d", i)] = struct{}{}
+	}
+
+	all := scanAll(t, keys, "", 7, nil)
+	require.Len(t, all, len(keys))
+	for _, key := range all {
+		require.Contains(t, keys, key)
+	}
+}
+
+func TestScanPattern(t *testing.T) {
+	keys := map[string]struct{}{"user:1": {}, "user:2": {}, "session:1": {}}
+
+	require.ElementsMatch(t, []string{"user:1", "user:2"}, scanAll(t, keys, "user:*", 1, nil))
+	require.Empty(t, scanAll(t, keys, "none:*", 10, nil))
+}
+
+func TestScanKeepsCursorWhileKeysChange(t *testing.T) {
+	keys := make(map[string]struct{})
+	for i := range 50 {
+		keys[fmt.Sprintf("stable:%d", i)] = struct{}{}
+		keys[fmt.Sprintf("removed:%d", i)] = struct{}{}
+	}
+
+	removed := 0
+	all := scanAll(t, keys, "", 5, func() {
+		delete(keys, fmt.Sprintf("removed:%d", removed))
+		keys[fmt.Sprintf("added:%d", removed)] = struct{}{}
+		removed++
+	})
+
+	seen := make(map[string]int)
+	for _, key := range all {
+		seen[key]++
+	}
+	for i := range 50 {
+		require.Equal(t, 1, seen[fmt.Sprintf("stable:%d", i)])
+	}
+}
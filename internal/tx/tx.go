@@ -0,0 +1,96 @@
+package tx
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/meta"
+)
+
+type OpType string
+
+const (
+	OpSet    OpType = "set"
+	OpDelete OpType = "delete"
+	// OpCheck fails the transaction if version of key isnt equal to Op.Version,
+	// zero version means key must not exist.
+	OpCheck OpType = "check"
+)
+
+// Op is operation of transaction, value of set is stored with inlined metadata.
+type Op struct {
+	Op      OpType         `json:"op"`
+	Key     string         `json:"key"`
+	Value   []byte         `json:"value,omitempty"`
+	TTL     batch.Duration `json:"ttl,omitempty"`
+	Version uint64         `json:"version,omitempty"`
+	meta.Meta
+}
+
+// Tx is ordered list of operations applied all or nothing.
+type Tx struct {
+	Ops []Op `json:"ops"`
+}
+
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusConflict Status = "conflict"
+	StatusAborted  Status = "aborted"
+)
+
+// Result of operation. Version is a version of key after operation, zero if key doesnt exist.
+type Result struct {
+	Op      OpType `json:"op"`
+	Key     string `json:"key"`
+	Status  Status `json:"status"`
+	Version uint64 `json:"version,omitempty"`
+}
+
+type Response struct {
+	Committed bool     `json:"committed"`
+	Results   []Result `json:"results"`
+}
+
+const MaxOps = 100
+
+var (
+	ErrEmptyTx      error = errors.New("transaction cannot be empty")
+	ErrTooManyOps   error = errors.New("transaction is too large")
+	ErrEmptyKey     error = errors.New("key in transaction cannot be empty")
+	ErrUnknownOp    error = errors.New("unknown operation in transaction")
+	ErrNotCommitted error = errors.New("transaction isnt committed")
+)
+
+func (t Tx) Validate() error {
+	if len(t.Ops) == 0 {
+		return ErrEmptyTx
+	}
+	if len(t.Ops) > MaxOps {
+		return ErrTooManyOps
+	}
+	for _, op := range t.Ops {
+		if op.Key == "" {
+			return ErrEmptyKey
+		}
+		switch op.Op {
+		case OpSet, OpDelete, OpCheck:
+		default:
+			return fmt.Errorf("%w: %q", ErrUnknownOp, op.Op)
+		}
+		if err := op.Meta.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t Tx) Keys() []string {
+	keys := make([]string, 0, len(t.Ops))
+	for _, op := range t.Ops {
+		keys = append(keys, op.Key)
+	}
+	return keys
+}
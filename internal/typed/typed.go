@@ -0,0 +1,252 @@
+package typed
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Type of value stored by key. Values set by /set have String type.
+type Type string
+
+const (
+	String Type = "string"
+	Hash   Type = "hash"
+	List   Type = "list"
+	Set    Type = "set"
+	ZSet   Type = "zset"
+)
+
+type Op string
+
+const (
+	HGet Op = "hget"
+	HSet Op = "hset"
+	HDel Op = "hdel"
+
+	LPush  Op = "lpush"
+	RPush  Op = "rpush"
+	LPop   Op = "lpop"
+	RPop   Op = "rpop"
+	LRange Op = "lrange"
+
+	SAdd     Op = "sadd"
+	SRem     Op = "srem"
+	SMembers Op = "smembers"
+
+	ZAdd   Op = "zadd"
+	ZRange Op = "zrange"
+)
+
+// Ops are all operations, each of them has its own endpoint.
+var Ops = []Op{HGet, HSet, HDel, LPush, RPush, LPop, RPop, LRange, SAdd, SRem, SMembers, ZAdd, ZRange}
+
+func (op Op) Type() Type {
+	switch op {
+	case HGet, HSet, HDel:
+		return Hash
+	case LPush, RPush, LPop, RPop, LRange:
+		return List
+	case SAdd, SRem, SMembers:
+		return Set
+	default:
+		return ZSet
+	}
+}
+
+// ReadOnly returns true if operation doesnt change value.
+func (op Op) ReadOnly() bool {
+	switch op {
+	case HGet, LRange, SMembers, ZRange:
+		return true
+	default:
+		return false
+	}
+}
+
+// Method returns http method of operation endpoint.
+func (op Op) Method() string {
+	switch {
+	case op.ReadOnly():
+		return http.MethodGet
+	case op == HDel || op == SRem:
+		return http.MethodDelete
+	default:
+		return http.MethodPost
+	}
+}
+
+// Command is operation on typed value. Field is a field of hash, Member is
+// a member of set or sorted set, Value is a value of hash field or list element.
+type Command struct {
+	Op     Op
+	Key    string
+	Field  string
+	Member string
+	Value  []byte
+	Score  float64
+	// Start and Stop are inclusive indexes of list range, negative index counts from the end.
+	Start int64
+	Stop  int64
+	// Min and Max are inclusive scores of sorted set range.
+	Min float64
+	Max float64
+	// TTL is used only when key is created, ttl of the whole key isnt changed by later operations.
+	TTL time.Duration
+	// Timeout of waiting for element of list to pop, zero means pop doesnt wait.
+	Timeout time.Duration
+}
+
+// Blocking returns true if command waits for element.
+func (c Command) Blocking() bool {
+	return (c.Op == LPop || c.Op == RPop) && c.Timeout > 0
+}
+
+type Member struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// Reply of command. Count is a number of created fields or members, or length of list after push.
+// Exists is false if key was removed because the last element was removed.
+type Reply struct {
+	Value   []byte   `json:"value,omitempty"`
+	Values  [][]byte `json:"values,omitempty"`
+	Members []Member `json:"members,omitempty"`
+	Count   int      `json:"count"`
+	Exists  bool     `json:"exists"`
+}
+
+var (
+	ErrWrongType   error = errors.New("operation against a key holding the wrong type of value")
+	ErrNotFound    error = errors.New("key or element not found")
+	ErrEmptyField  error = errors.New("field query param cannot be empty")
+	ErrEmptyMember error = errors.New("member query param cannot be empty")
+	ErrInvalidArg  error = errors.New("invalid range, score or timeout query param")
+)
+
+const (
+	fieldParam   = "field"
+	memberParam  = "member"
+	scoreParam   = "score"
+	startParam   = "start"
+	stopParam    = "stop"
+	minParam     = "min"
+	maxParam     = "max"
+	timeoutParam = "timeout"
+)
+
+// ParseCommand builds command from query params of request, key, ttl and value
+// are extracted by caller.
+func ParseCommand(op Op, query url.Values) (Command, error) {
+	cmd := Command{Op: op, Stop: -1, Min: math.Inf(-1), Max: math.Inf(1)}
+
+	var err error
+	switch op {
+	case HGet, HSet, HDel:
+		cmd.Field = query.Get(fieldParam)
+		if cmd.Field == "" {
+			return Command{}, ErrEmptyField
+		}
+	case SAdd, SRem, ZAdd:
+		cmd.Member = query.Get(memberParam)
+		if cmd.Member == "" {
+			return Command{}, ErrEmptyMember
+		}
+	}
+
+	switch op {
+	case ZAdd:
+		cmd.Score, err = parseFloat(query, scoreParam, 0)
+	case ZRange:
+		cmd.Min, err = parseFloat(query, minParam, cmd.Min)
+		if err == nil {
+			cmd.Max, err = parseFloat(query, maxParam, cmd.Max)
+		}
+	case LRange:
+		cmd.Start, err = parseInt(query, startParam, 0)
+		if err == nil {
+			cmd.Stop, err = parseInt(query, stopParam, cmd.Stop)
+		}
+	case LPop, RPop:
+		if str := query.Get(timeoutParam); str != "" {
+			cmd.Timeout, err = time.ParseDuration(str)
+			if err == nil && cmd.Timeout < 0 {
+				err = fmt.Errorf("%s: %q", timeoutParam, str)
+			}
+		}
+	}
+	if err != nil {
+		return Command{}, errors.Join(ErrInvalidArg, err)
+	}
+	return cmd, nil
+}
+
+// Query returns query params of command, it is reverse to ParseCommand.
+func (c Command) Query() url.Values {
+	query := url.Values{}
+	query.Set("key", c.Key)
+	if c.TTL != 0 {
+		query.Set("ttl", c.TTL.String())
+	}
+	if c.Field != "" {
+		query.Set(fieldParam, c.Field)
+	}
+	if c.Member != "" {
+		query.Set(memberParam, c.Member)
+	}
+
+	switch c.Op {
+	case ZAdd:
+		query.Set(scoreParam, strconv.FormatFloat(c.Score, 'g', -1, 64))
+	case ZRange:
+		query.Set(minParam, strconv.FormatFloat(c.Min, 'g', -1, 64))
+		query.Set(maxParam, strconv.FormatFloat(c.Max, 'g', -1, 64))
+	case LRange:
+		query.Set(startParam, strconv.FormatInt(c.Start, 10))
+		query.Set(stopParam, strconv.FormatInt(c.Stop, 10))
+	case LPop, RPop:
+		if c.Timeout != 0 {
+			query.Set(timeoutParam, c.Timeout.String())
+		}
+	}
+	return query
+}
+
+// HasBody returns true if command passes value in request body.
+func (c Command) HasBody() bool {
+	switch c.Op {
+	case HSet, LPush, RPush:
+		return true
+	default:
+		return false
+	}
+}
+
+func parseFloat(query url.Values, param string, def float64) (float64, error) {
+	str := query.Get(param)
+	if str == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0, fmt.Errorf("%s: %q", param, str)
+	}
+	return f, nil
+}
+
+func parseInt(query url.Values, param string, def int64) (int64, error) {
+	str := query.Get(param)
+	if str == "" {
+		return def, nil
+	}
+	i, err := strconv.ParseInt(str, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %q", param, str)
+	}
+	return i, nil
+}
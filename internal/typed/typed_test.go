@@ -0,0 +1,61 @@
+package typed
+
+import (
+	"math"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandQuery(t *testing.T) {
+	tests := []Command{
+		{Op: HSet, Key: "user42", Field: "name", TTL: time.Minute},
+		{Op: ZAdd, Key: "scores", Member: "bob", Score: 1.5},
+		{Op: ZRange, Key: "scores", Min: math.Inf(-1), Max: 10},
+		{Op: LRange, Key: "queue", Start: 1, Stop: -2},
+		{Op: SRem, Key: "tags", Member: "a"},
+	}
+
+	for _, want := range tests {
+		t.Run(string(want.Op), func(t *testing.T) {
+			query := want.Query()
+			cmd, err := ParseCommand(want.Op, query)
+			require.NoError(t, err)
+
+			cmd.Key = query.Get("key")
+			if ttl := query.Get("ttl"); ttl != "" {
+				cmd.TTL, err = time.ParseDuration(ttl)
+				require.NoError(t, err)
+			}
+			if want.Op != ZRange {
+				want.Min, want.Max = math.Inf(-1), math.Inf(1)
+			}
+			if want.Op != LRange {
+				want.Stop = -1
+			}
+			require.Equal(t, want, cmd)
+		})
+	}
+}
+
+func TestParseCommandInvalid(t *testing.T) {
+	tests := []struct {
+		op    Op
+		query string
+		err   error
+	}{
+		{HGet, "", ErrEmptyField},
+		{SAdd, "", ErrEmptyMember},
+		{ZAdd, "member=a&score=NaN", ErrInvalidArg},
+		{LRange, "stop=x", ErrInvalidArg},
+	}
+
+	for _, tt := range tests {
+		query, err := url.ParseQuery(tt.query)
+		require.NoError(t, err)
+		_, err = ParseCommand(tt.op, query)
+		require.ErrorIs(t, err, tt.err)
+	}
+}
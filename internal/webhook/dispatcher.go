@@ -0,0 +1,284 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	mathrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+)
+
+const (
+	SignatureHeader = "X-Sticky-Signature"
+	IDHeader        = "X-Sticky-Webhook"
+	AttemptHeader   = "X-Sticky-Delivery-Attempt"
+)
+
+// Sign returns signature of payload sent in SignatureHeader, it is hex of HMAC-SHA256 with prefix.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks signature of payload in constant time.
+func Verify(secret string, payload []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, payload)), []byte(signature))
+}
+
+type Options struct {
+	// MaxAttempts of delivery of event including the first one.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Timeout limits one attempt.
+	Timeout time.Duration
+	// QueueSize is a number of events waiting for delivery, new events are dead letters if it is full.
+	QueueSize int
+}
+
+var DefaultOptions = Options{
+	MaxAttempts: 5,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+	Timeout:     5 * time.Second,
+	QueueSize:   1024,
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts == 0 {
+		o.MaxAttempts = DefaultOptions.MaxAttempts
+	}
+	if o.BaseDelay == 0 {
+		o.BaseDelay = DefaultOptions.BaseDelay
+	}
+	if o.MaxDelay == 0 {
+		o.MaxDelay = DefaultOptions.MaxDelay
+	}
+	if o.Timeout == 0 {
+		o.Timeout = DefaultOptions.Timeout
+	}
+	if o.QueueSize == 0 {
+		o.QueueSize = DefaultOptions.QueueSize
+	}
+	return o
+}
+
+func NewDispatcher(broker *events.Broker, opts Options) *Dispatcher {
+	opts = opts.withDefaults()
+	return &Dispatcher{
+		broker: broker,
+		opts:   opts,
+		client: &http.Client{Timeout: opts.Timeout},
+		hooks:  make(map[string]*hook),
+	}
+}
+
+// Dispatcher delivers events of broker to webhooks asynchronously,
+// each webhook has its own queue, so slow receiver doesnt delay the others.
+type Dispatcher struct {
+	broker *events.Broker
+	opts   Options
+	client *http.Client
+
+	mu    sync.Mutex
+	hooks map[string]*hook
+}
+
+type hook struct {
+	Webhook
+	cancel context.CancelFunc
+	queue  chan events.Event
+
+	delivered   atomic.Int64
+	retries     atomic.Int64
+	deadLetters atomic.Int64
+}
+
+// Register starts delivery of events to webhook, id is generated if it is empty.
+func (d *Dispatcher) Register(w Webhook) (Webhook, error) {
+	if w.Pattern == "" {
+		w.Pattern = "*"
+	}
+	if err := w.Validate(); err != nil {
+		return Webhook{}, err
+	}
+	if w.ID == "" {
+		w.ID = newID()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.hooks[w.ID]; ok {
+		return Webhook{}, ErrExist
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &hook{Webhook: w, cancel: cancel, queue: make(chan events.Event, d.opts.QueueSize)}
+	d.hooks[w.ID] = h
+
+	// subscription is made before return, so events after registration arent missed
+	go d.receive(ctx, h, d.broker.Subscribe(events.Filter{Pattern: w.Pattern}))
+	go d.deliver(ctx, h)
+
+	slog.Info(fmt.Sprintf("webhook %q to %q for pattern %q is registered", w.ID, w.URL, w.Pattern))
+	return w, nil
+}
+
+func (d *Dispatcher) Unregister(id string) error {
+	d.mu.Lock()
+	h, ok := d.hooks[id]
+	delete(d.hooks, id)
+	d.mu.Unlock()
+
+	if !ok {
+		return ErrNotFound
+	}
+	h.cancel()
+	return nil
+}
+
+func (d *Dispatcher) List() []Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	statuses := make([]Status, 0, len(d.hooks))
+	for _, h := range d.hooks {
+		w := h.Webhook
+		w.Secret = ""
+		statuses = append(statuses, Status{Webhook: w, Stats: Stats{
+			Delivered:   h.delivered.Load(),
+			Retries:     h.retries.Load(),
+			DeadLetters: h.deadLetters.Load(),
+		}})
+	}
+	return statuses
+}
+
+// receive moves events from broker to queue of webhook, it never blocks on delivery,
+// so broker doesnt disconnect webhook as slow subscriber.
+func (d *Dispatcher) receive(ctx context.Context, h *hook, sub *events.Subscriber) {
+	for {
+		d.receiveFrom(ctx, h, sub)
+		d.broker.Unsubscribe(sub)
+		if ctx.Err() != nil {
+			return
+		}
+		sub = d.broker.Subscribe(events.Filter{Pattern: h.Pattern})
+	}
+}
+
+func (d *Dispatcher) receiveFrom(ctx context.Context, h *hook, sub *events.Subscriber) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-sub.Events():
+			if !ok {
+				// events are lost, but their number is unknown
+				h.deadLetters.Add(1)
+				return
+			}
+			if !h.match(e) {
+				continue
+			}
+
+			select {
+			case h.queue <- e:
+			default:
+				h.deadLetters.Add(1)
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, h *hook) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-h.queue:
+			if d.deliverWithRetries(ctx, h, e) {
+				h.delivered.Add(1)
+			} else if ctx.Err() == nil {
+				h.deadLetters.Add(1)
+				slog.Error(fmt.Sprintf("event %s of key %q isnt delivered to webhook %q", e.Op, e.Key, h.ID))
+			}
+		}
+	}
+}
+
+func (d *Dispatcher) deliverWithRetries(ctx context.Context, h *hook, e events.Event) bool {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return false
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = d.post(ctx, h, payload, attempt)
+		if err == nil {
+			return true
+		}
+		if attempt >= d.opts.MaxAttempts {
+			return false
+		}
+
+		h.retries.Add(1)
+		slog.Debug(fmt.Sprintf("delivery to webhook %q failed, attempt %d: %v", h.ID, attempt, err))
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(d.backoff(attempt)):
+		}
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, h *hook, payload []byte, attempt int) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, h.ID)
+	req.Header.Set(AttemptHeader, strconv.Itoa(attempt))
+	if h.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(h.Secret, payload))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// backoff returns exponential delay with jitter before the next attempt.
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := float64(d.opts.BaseDelay) * math.Pow(2, float64(attempt-1))
+	delay = min(delay, float64(d.opts.MaxDelay))
+	return time.Duration(delay/2 + mathrand.Float64()*delay/2)
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
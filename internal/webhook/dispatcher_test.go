@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+	"github.com/stretchr/testify/require"
+)
+
+var testOptions = Options{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+// delivery is request received by test webhook, it is checked in test goroutine.
+type delivery struct {
+	payload []byte
+	header  http.Header
+	err     error
+}
+
+func TestDeliverySignedAndFiltered(t *testing.T) {
+	received := make(chan delivery, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, err := io.ReadAll(r.Body)
+		received <- delivery{payload: payload, header: r.Header.Clone(), err: err}
+	}))
+	defer srv.Close()
+
+	broker := events.NewBroker(events.Options{})
+	d := NewDispatcher(broker, testOptions)
+	_, err := d.Register(Webhook{ID: "sessions", URL: srv.URL, Pattern: "session:*", Ops: []events.Op{events.OpExpired}, Secret: "secret"})
+	require.NoError(t, err)
+
+	broker.Publish(events.Event{Op: events.OpSet, Key: "session:1"})
+	broker.Publish(events.Event{Op: events.OpExpired, Key: "user:1"})
+	broker.Publish(events.Event{Op: events.OpExpired, Key: "session:1", Version: 3})
+
+	got := <-received
+	require.NoError(t, got.err)
+	require.True(t, Verify("secret", got.payload, got.header.Get(SignatureHeader)))
+	require.Equal(t, "sessions", got.header.Get(IDHeader))
+
+	var e events.Event
+	require.NoError(t, json.Unmarshal(got.payload, &e))
+	require.Equal(t, events.OpExpired, e.Op)
+	require.Equal(t, "session:1", e.Key)
+	require.Equal(t, uint64(3), e.Version)
+
+	require.Eventually(t, func() bool { return d.List()[0].Stats.Delivered == 1 }, time.Second, time.Millisecond)
+	require.Empty(t, d.List()[0].Secret)
+	require.Empty(t, received)
+}
+
+func TestDeliveryRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	broker := events.NewBroker(events.Options{})
+	d := NewDispatcher(broker, testOptions)
+	_, err := d.Register(Webhook{URL: srv.URL})
+	require.NoError(t, err)
+
+	broker.Publish(events.Event{Op: events.OpDelete, Key: "key1"})
+
+	require.Eventually(t, func() bool {
+		return d.List()[0].Stats == Stats{Delivered: 1, Retries: 2}
+	}, time.Second, time.Millisecond)
+}
+
+func TestDeliveryDeadLetter(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	broker := events.NewBroker(events.Options{})
+	d := NewDispatcher(broker, testOptions)
+	hook, err := d.Register(Webhook{URL: srv.URL})
+	require.NoError(t, err)
+
+	broker.Publish(events.Event{Op: events.OpDelete, Key: "key1"})
+
+	require.Eventually(t, func() bool {
+		return d.List()[0].Stats == Stats{Retries: 2, DeadLetters: 1}
+	}, time.Second, time.Millisecond)
+	require.Equal(t, int32(3), calls.Load())
+
+	require.NoError(t, d.Unregister(hook.ID))
+	require.ErrorIs(t, d.Unregister(hook.ID), ErrNotFound)
+	require.Empty(t, d.List())
+}
+
+func TestWebhookValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook Webhook
+		err     error
+	}{
+		{"valid", Webhook{URL: "https://example.com/hook", Ops: []events.Op{events.OpExpired}}, nil},
+		{"relative url", Webhook{URL: "/hook"}, ErrInvalidURL},
+		{"unsupported scheme", Webhook{URL: "ftp://example.com"}, ErrInvalidURL},
+		{"message op", Webhook{URL: "http://example.com", Ops: []events.Op{events.OpMessage}}, ErrInvalidOp},
+		{"invalid pattern", Webhook{URL: "http://example.com", Pattern: `key\`}, events.ErrInvalidPattern},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.webhook.Validate()
+			if tt.err == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+
+	"github.com/aosderzhikov/sticky/internal/events"
+)
+
+// Webhook receives keyspace events of keys matched by Pattern as POST requests with json of event.
+type Webhook struct {
+	ID      string `json:"id" yaml:"id"`
+	URL     string `json:"url" yaml:"url"`
+	Pattern string `json:"pattern" yaml:"pattern"`
+	// Ops are delivered operations, all keyspace operations are delivered if it is empty.
+	Ops []events.Op `json:"ops,omitempty" yaml:"ops"`
+	// Secret signs payload, signature isnt sent if it is empty.
+	Secret string `json:"secret,omitempty" yaml:"secret"`
+}
+
+type Stats struct {
+	Delivered int64 `json:"delivered"`
+	Retries   int64 `json:"retries"`
+	// DeadLetters are events which werent delivered after all attempts or were dropped
+	// because queue of webhook was full.
+	DeadLetters int64 `json:"deadLetters"`
+}
+
+// Status is registered webhook with its delivery stats, secret is hidden.
+type Status struct {
+	Webhook
+	Stats Stats `json:"stats"`
+}
+
+var (
+	ErrInvalidURL error = errors.New("webhook url must be absolute http or https url")
+	ErrInvalidOp  error = errors.New("webhook op must be keyspace operation")
+	ErrExist      error = errors.New("webhook with the same id is already registered")
+	ErrNotFound   error = errors.New("webhook not found")
+)
+
+var keyspaceOps = []events.Op{events.OpSet, events.OpDelete, events.OpExpired, events.OpEvicted}
+
+func (w Webhook) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	for _, op := range w.Ops {
+		if !slices.Contains(keyspaceOps, op) {
+			return fmt.Errorf("%w: %q", ErrInvalidOp, op)
+		}
+	}
+	return events.Filter{Pattern: w.Pattern}.Validate()
+}
+
+func (w Webhook) match(e events.Event) bool {
+	return len(w.Ops) == 0 || slices.Contains(w.Ops, e.Op)
+}
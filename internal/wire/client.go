@@ -0,0 +1,263 @@
+package wire
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type ClientOptions struct {
+	// Conns is size of pool of persistent connections, requests are spread over them.
+	Conns int
+	// MaxInFlight bounds requests waiting for response on one connection,
+	// new requests wait for free slot until their deadline.
+	MaxInFlight int
+	DialTimeout time.Duration
+	// RedialInterval is time after failed dial when requests fail fast without dial.
+	RedialInterval time.Duration
+}
+
+var DefaultClientOptions = ClientOptions{
+	Conns:          2,
+	MaxInFlight:    256,
+	DialTimeout:    time.Second,
+	RedialInterval: time.Second,
+}
+
+func (o ClientOptions) withDefaults() ClientOptions {
+	if o.Conns == 0 {
+		o.Conns = DefaultClientOptions.Conns
+	}
+	if o.MaxInFlight == 0 {
+		o.MaxInFlight = DefaultClientOptions.MaxInFlight
+	}
+	if o.DialTimeout == 0 {
+		o.DialTimeout = DefaultClientOptions.DialTimeout
+	}
+	if o.RedialInterval == 0 {
+		o.RedialInterval = DefaultClientOptions.RedialInterval
+	}
+	return o
+}
+
+var (
+	// ErrUnavailable means request wasnt sent, so it can be safely sent another way.
+	ErrUnavailable error = errors.New("wire connection is unavailable")
+	// ErrConnLost means connection was broken after request was sent, request could be applied.
+	ErrConnLost     error = errors.New("wire connection is lost")
+	ErrClientClosed error = errors.New("wire client is closed")
+)
+
+func NewClient(addr string, opts ClientOptions) *Client {
+	opts = opts.withDefaults()
+	return &Client{
+		addr:  addr,
+		opts:  opts,
+		conns: make([]*clientConn, opts.Conns),
+	}
+}
+
+// Client sends requests over pool of persistent connections, responses are matched
+// with requests by id, so many requests share connection without waiting for each other.
+type Client struct {
+	addr   string
+	opts   ClientOptions
+	lastID atomic.Uint64
+	next   atomic.Uint64
+
+	mu      sync.Mutex
+	conns   []*clientConn
+	retryAt time.Time
+	closed  bool
+}
+
+type clientConn struct {
+	conn  net.Conn
+	slots chan struct{}
+
+	wmu sync.Mutex
+	w   *bufio.Writer
+
+	mu      sync.Mutex
+	pending map[uint64]chan frame
+	// done is closed when connection is broken.
+	done chan struct{}
+}
+
+func (c *Client) Do(ctx context.Context, req Request) (Response, error) {
+	payload, err := encodeRequest(req)
+	if err != nil {
+		return Response{}, err
+	}
+
+	cc, err := c.conn(ctx)
+	if err != nil {
+		return Response{}, err
+	}
+
+	// backpressure: request waits while connection has too many requests in flight
+	select {
+	case cc.slots <- struct{}{}:
+		defer func() { <-cc.slots }()
+	case <-cc.done:
+		return Response{}, ErrUnavailable
+	case <-ctx.Done():
+		return Response{}, fmt.Errorf("%w: %w", ErrUnavailable, ctx.Err())
+	}
+
+	id := c.lastID.Add(1)
+	ch, ok := cc.register(id)
+	if !ok {
+		return Response{}, ErrUnavailable
+	}
+	defer cc.unregister(id)
+
+	if err = cc.write(ctx, frame{id: id, code: uint16(req.Op), payload: payload}); err != nil {
+		// frame isnt complete, so keeper doesnt apply it
+		cc.close()
+		return Response{}, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	select {
+	case f, ok := <-ch:
+		if !ok {
+			return Response{}, ErrConnLost
+		}
+		return decodeResponse(req.Op, f.code, f.payload)
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	}
+}
+
+// conn returns connection of pool, broken connections are dialed again.
+func (c *Client) conn(ctx context.Context) (*clientConn, error) {
+	i := int(c.next.Add(1) % uint64(len(c.conns)))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, ErrClientClosed)
+	}
+	if cc := c.conns[i]; cc != nil && !cc.broken() {
+		return cc, nil
+	}
+	if time.Now().Before(c.retryAt) {
+		return nil, ErrUnavailable
+	}
+
+	dialer := net.Dialer{Timeout: c.opts.DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		c.retryAt = time.Now().Add(c.opts.RedialInterval)
+		return nil, fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+
+	cc := &clientConn{
+		conn:    conn,
+		slots:   make(chan struct{}, c.opts.MaxInFlight),
+		w:       bufio.NewWriter(conn),
+		pending: make(map[uint64]chan frame),
+		done:    make(chan struct{}),
+	}
+	c.conns[i] = cc
+	go cc.read()
+
+	slog.Debug(fmt.Sprintf("wire connection to %q is established", c.addr))
+	return cc, nil
+}
+
+// Close closes connections, requests in flight get ErrConnLost.
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for _, cc := range c.conns {
+		if cc != nil {
+			cc.close()
+		}
+	}
+}
+
+func (cc *clientConn) register(id uint64) (chan frame, bool) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.broken() {
+		return nil, false
+	}
+	ch := make(chan frame, 1)
+	cc.pending[id] = ch
+	return ch, true
+}
+
+func (cc *clientConn) unregister(id uint64) {
+	cc.mu.Lock()
+	delete(cc.pending, id)
+	cc.mu.Unlock()
+}
+
+func (cc *clientConn) write(ctx context.Context, f frame) error {
+	cc.wmu.Lock()
+	defer cc.wmu.Unlock()
+
+	deadline, _ := ctx.Deadline()
+	if err := cc.conn.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+	return writeFrame(cc.w, f)
+}
+
+// read delivers responses to waiting requests until connection is broken.
+func (cc *clientConn) read() {
+	defer cc.close()
+
+	r := bufio.NewReader(cc.conn)
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			return
+		}
+
+		cc.mu.Lock()
+		ch, ok := cc.pending[f.id]
+		delete(cc.pending, f.id)
+		cc.mu.Unlock()
+
+		// response of canceled request is dropped
+		if ok {
+			ch <- f
+		}
+	}
+}
+
+func (cc *clientConn) broken() bool {
+	select {
+	case <-cc.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (cc *clientConn) close() {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.broken() {
+		return
+	}
+	close(cc.done)
+	cc.conn.Close()
+	for id, ch := range cc.pending {
+		close(ch)
+		delete(cc.pending, id)
+	}
+}
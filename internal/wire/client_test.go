@@ -0,0 +1,194 @@
+package wire
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func startServer(t *testing.T, h Handler, maxInFlight int) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := NewServer(h, maxInFlight)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(func() { _ = srv.Close() })
+	return l.Addr().String()
+}
+
+func TestClientOutOfOrderResponses(t *testing.T) {
+	release := make(chan struct{})
+	addr := startServer(t, HandlerFunc(func(_ context.Context, req Request) Response {
+		if req.Key == "slow" {
+			<-release
+		}
+		resp := OKResponse()
+		resp.Value = []byte(req.Key)
+		return resp
+	}), 0)
+
+	c := NewClient(addr, ClientOptions{Conns: 1})
+	t.Cleanup(c.Close)
+	ctx := context.Background()
+
+	slow := make(chan Response)
+	go func() {
+		resp, _ := c.Do(ctx, Request{Op: OpGet, Key: "slow"})
+		slow <- resp
+	}()
+
+	// fast request isnt blocked by slow one on the same connection
+	resp, err := c.Do(ctx, Request{Op: OpGet, Key: "fast"})
+	require.NoError(t, err)
+	require.Equal(t, []byte("fast"), resp.Value)
+
+	close(release)
+	require.Equal(t, []byte("slow"), (<-slow).Value)
+}
+
+func TestClientConcurrentRequests(t *testing.T) {
+	addr := startServer(t, HandlerFunc(func(_ context.Context, req Request) Response {
+		resp := OKResponse()
+		resp.Value = []byte(req.Key)
+		return resp
+	}), 0)
+
+	c := NewClient(addr, ClientOptions{Conns: 2, MaxInFlight: 4})
+	t.Cleanup(c.Close)
+
+	var wg sync.WaitGroup
+	for i := range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := string(rune('a' + i%26))
+			resp, err := c.Do(context.Background(), Request{Op: OpGet, Key: key})
+			require.NoError(t, err)
+			require.Equal(t, key, string(resp.Value))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestClientBackpressure(t *testing.T) {
+	var (
+		inFlight atomic.Int32
+		maxSeen  atomic.Int32
+	)
+	addr := startServer(t, HandlerFunc(func(_ context.Context, _ Request) Response {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxSeen.Load()
+			if n <= m || maxSeen.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return OKResponse()
+	}), 0)
+
+	c := NewClient(addr, ClientOptions{Conns: 1, MaxInFlight: 2})
+	t.Cleanup(c.Close)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.Do(context.Background(), Request{Op: OpSet, Key: "key"})
+			require.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.LessOrEqual(t, maxSeen.Load(), int32(2))
+}
+
+func TestClientBackpressureDeadline(t *testing.T) {
+	release := make(chan struct{})
+	addr := startServer(t, HandlerFunc(func(_ context.Context, _ Request) Response {
+		<-release
+		return OKResponse()
+	}), 0)
+	t.Cleanup(func() { close(release) })
+
+	c := NewClient(addr, ClientOptions{Conns: 1, MaxInFlight: 1})
+	t.Cleanup(c.Close)
+
+	go func() { _, _ = c.Do(context.Background(), Request{Op: OpGet, Key: "key"}) }()
+	require.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.conns[0] != nil && len(c.conns[0].slots) == 1
+	}, time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := c.Do(ctx, Request{Op: OpGet, Key: "key"})
+	require.ErrorIs(t, err, ErrUnavailable)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClientUnavailable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	c := NewClient(addr, ClientOptions{RedialInterval: time.Minute})
+	t.Cleanup(c.Close)
+
+	_, err = c.Do(context.Background(), Request{Op: OpGet, Key: "key"})
+	require.ErrorIs(t, err, ErrUnavailable)
+
+	// next request fails fast without dial
+	_, err = c.Do(context.Background(), Request{Op: OpGet, Key: "key"})
+	require.ErrorIs(t, err, ErrUnavailable)
+}
+
+func TestClientConnLost(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		// request is read, but connection is closed before response
+		buf := make([]byte, 64)
+		_, _ = conn.Read(buf)
+		_ = conn.Close()
+	}()
+
+	c := NewClient(l.Addr().String(), ClientOptions{Conns: 1})
+	t.Cleanup(c.Close)
+
+	_, err = c.Do(context.Background(), Request{Op: OpSet, Key: "key"})
+	require.ErrorIs(t, err, ErrConnLost)
+}
+
+func TestServerBadRequest(t *testing.T) {
+	addr := startServer(t, HandlerFunc(func(_ context.Context, _ Request) Response {
+		return OKResponse()
+	}), 0)
+
+	c := NewClient(addr, ClientOptions{})
+	t.Cleanup(c.Close)
+
+	cc, err := c.conn(context.Background())
+	require.NoError(t, err)
+	ch, ok := cc.register(1)
+	require.True(t, ok)
+	require.NoError(t, cc.write(context.Background(), frame{id: 1, code: 100}))
+
+	f := <-ch
+	require.Equal(t, uint16(http.StatusBadRequest), f.code)
+}
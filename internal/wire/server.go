@@ -0,0 +1,193 @@
+package wire
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// Handler serves requests of bouncer, statuses of responses are the same as http handlers have.
+type Handler interface {
+	ServeWire(ctx context.Context, req Request) Response
+}
+
+type HandlerFunc func(ctx context.Context, req Request) Response
+
+func (f HandlerFunc) ServeWire(ctx context.Context, req Request) Response {
+	return f(ctx, req)
+}
+
+// DefaultMaxInFlight bounds requests served at once on one connection of server.
+const DefaultMaxInFlight = 256
+
+var ErrServerClosed error = errors.New("wire server is closed")
+
+func NewServer(h Handler, maxInFlight int) *Server {
+	if maxInFlight <= 0 {
+		maxInFlight = DefaultMaxInFlight
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		h:           h,
+		maxInFlight: maxInFlight,
+		ctx:         ctx,
+		cancel:      cancel,
+		conns:       make(map[net.Conn]struct{}),
+	}
+}
+
+// Server serves requests of connection concurrently and sends responses as they are ready.
+// When connection has maxInFlight requests being served, server stops reading it,
+// so client is slowed down by tcp flow control.
+type Server struct {
+	h           Handler
+	maxInFlight int
+	// ctx of requests is canceled by Close.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	listeners []net.Listener
+	conns     map[net.Conn]struct{}
+	closed    bool
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners = append(s.listeners, l)
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		if !s.track(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+		go s.serve(conn)
+	}
+}
+
+// Close stops listeners and closes connections, requests being served are canceled.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	s.cancel()
+	var errs []error
+	for _, l := range s.listeners {
+		errs = append(errs, l.Close())
+	}
+	for conn := range s.conns {
+		errs = append(errs, conn.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+func (s *Server) untrack(conn net.Conn) {
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer s.untrack(conn)
+	defer conn.Close()
+
+	var (
+		wg    sync.WaitGroup
+		wmu   sync.Mutex
+		slots = make(chan struct{}, s.maxInFlight)
+	)
+	// responses of served requests are written before connection is closed
+	defer wg.Wait()
+
+	w := bufio.NewWriter(conn)
+	r := bufio.NewReader(conn)
+	for {
+		f, err := readFrame(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !s.isClosed() {
+				slog.Error(fmt.Sprintf("read wire frame from %q: %v", conn.RemoteAddr(), err))
+			}
+			return
+		}
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			out := s.handle(f)
+			wmu.Lock()
+			err := writeFrame(w, out)
+			wmu.Unlock()
+			if err != nil {
+				slog.Debug(fmt.Sprintf("write wire frame to %q: %v", conn.RemoteAddr(), err))
+				conn.Close()
+			}
+		}()
+	}
+}
+
+func (s *Server) handle(f frame) frame {
+	op := Op(f.code)
+
+	var resp Response
+	req, err := decodeRequest(op, f.payload)
+	if err != nil {
+		resp = ErrorResponse(http.StatusBadRequest, err)
+	} else {
+		resp = s.h.ServeWire(s.ctx, req)
+	}
+
+	payload, err := encodeResponse(op, resp)
+	if err != nil {
+		resp = ErrorResponse(http.StatusInternalServerError, err)
+		payload, _ = encodeResponse(op, resp)
+	}
+	return frame{id: f.id, code: uint16(resp.Status), payload: payload}
+}
@@ -0,0 +1,338 @@
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/meta"
+)
+
+// Wire is internal protocol between bouncer and keeper. Each message is a frame:
+//
+//	uint32 length of the rest of frame
+//	uint64 id of request, response has id of its request
+//	uint16 op of request or status of response, statuses are http codes
+//	payload
+//
+// Responses can be sent in any order, so one connection carries many requests at once.
+// Integers are big endian, fields are prefixed with uint32 length.
+
+// MaxFrameLen limits frame, so broken peer cant make reader allocate too much.
+const MaxFrameLen = 256 << 20
+
+const headerLen = 8 + 2
+
+type Op uint16
+
+const (
+	OpGet Op = iota + 1
+	OpSet
+	OpDelete
+	OpMGet
+	OpMSet
+	OpMDelete
+)
+
+func (op Op) String() string {
+	switch op {
+	case OpGet:
+		return "get"
+	case OpSet:
+		return "set"
+	case OpDelete:
+		return "delete"
+	case OpMGet:
+		return "mget"
+	case OpMSet:
+		return "mset"
+	case OpMDelete:
+		return "mdel"
+	default:
+		return fmt.Sprintf("op(%d)", uint16(op))
+	}
+}
+
+// Request of Op, only its fields are used: Key for get and delete, Key, Value, TTL and Meta
+// for set, Batch for batch operations.
+type Request struct {
+	Op    Op
+	Key   string
+	Value []byte
+	TTL   time.Duration
+	Meta  meta.Meta
+	Batch batch.Request
+}
+
+// Response has Status as http code, Msg is error for other statuses than 200.
+// Value, TTL, Version, times and Meta of value are filled by get, TTL is negative if value doesnt expire.
+// Results are filled by batch operations.
+type Response struct {
+	Status    int
+	Msg       string
+	Value     []byte
+	TTL       time.Duration
+	Version   uint64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Meta      meta.Meta
+	Results   []batch.Result
+}
+
+func OKResponse() Response {
+	return Response{Status: http.StatusOK}
+}
+
+func ErrorResponse(code int, err error) Response {
+	return Response{Status: code, Msg: err.Error()}
+}
+
+var (
+	ErrFrameTooLarge error = errors.New("frame is too large")
+	ErrInvalidFrame  error = errors.New("frame is shorter than its header")
+	ErrUnknownOp     error = errors.New("unknown op")
+)
+
+type frame struct {
+	id      uint64
+	code    uint16
+	payload []byte
+}
+
+func readFrame(r *bufio.Reader) (frame, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return frame{}, err
+	}
+	if n < headerLen {
+		return frame{}, ErrInvalidFrame
+	}
+	if n > MaxFrameLen {
+		return frame{}, ErrFrameTooLarge
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return frame{}, err
+	}
+	return frame{
+		id:      binary.BigEndian.Uint64(b),
+		code:    binary.BigEndian.Uint16(b[8:]),
+		payload: b[headerLen:],
+	}, nil
+}
+
+func writeFrame(w *bufio.Writer, f frame) error {
+	if len(f.payload) > MaxFrameLen-headerLen {
+		return ErrFrameTooLarge
+	}
+
+	var header [4 + headerLen]byte
+	binary.BigEndian.PutUint32(header[:], uint32(headerLen+len(f.payload)))
+	binary.BigEndian.PutUint64(header[4:], f.id)
+	binary.BigEndian.PutUint16(header[12:], f.code)
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(f.payload); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func encodeRequest(req Request) ([]byte, error) {
+	var b bytes.Buffer
+	switch req.Op {
+	case OpGet, OpDelete:
+		writeField(&b, []byte(req.Key))
+	case OpSet:
+		writeField(&b, []byte(req.Key))
+		_ = binary.Write(&b, binary.BigEndian, int64(req.TTL))
+		writeMeta(&b, req.Meta)
+		writeField(&b, req.Value)
+	case OpMGet, OpMSet, OpMDelete:
+		if err := batch.EncodeRequest(&b, batch.ContentTypeBinary, req.Batch); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownOp, req.Op)
+	}
+	return b.Bytes(), nil
+}
+
+func decodeRequest(op Op, payload []byte) (Request, error) {
+	req := Request{Op: op}
+	r := bytes.NewReader(payload)
+
+	var err error
+	switch op {
+	case OpGet, OpDelete:
+		req.Key, err = readString(r)
+	case OpSet:
+		if req.Key, err = readString(r); err != nil {
+			return req, err
+		}
+		var ttl int64
+		if err = binary.Read(r, binary.BigEndian, &ttl); err != nil {
+			return req, err
+		}
+		req.TTL = time.Duration(ttl)
+		if req.Meta, err = readMeta(r); err != nil {
+			return req, err
+		}
+		req.Value, err = readField(r)
+	case OpMGet, OpMSet, OpMDelete:
+		req.Batch, err = batch.DecodeRequest(r, batch.ContentTypeBinary)
+	default:
+		err = fmt.Errorf("%w: %s", ErrUnknownOp, op)
+	}
+	return req, err
+}
+
+func encodeResponse(op Op, resp Response) ([]byte, error) {
+	var b bytes.Buffer
+	if resp.Status != http.StatusOK {
+		writeField(&b, []byte(resp.Msg))
+		return b.Bytes(), nil
+	}
+
+	switch op {
+	case OpGet:
+		_ = binary.Write(&b, binary.BigEndian, int64(resp.TTL))
+		_ = binary.Write(&b, binary.BigEndian, resp.Version)
+		_ = binary.Write(&b, binary.BigEndian, unixNano(resp.CreatedAt))
+		_ = binary.Write(&b, binary.BigEndian, unixNano(resp.UpdatedAt))
+		writeMeta(&b, resp.Meta)
+		writeField(&b, resp.Value)
+	case OpMGet, OpMSet, OpMDelete:
+		if err := batch.EncodeResponse(&b, batch.ContentTypeBinary, batch.Response{Results: resp.Results}); err != nil {
+			return nil, err
+		}
+	}
+	return b.Bytes(), nil
+}
+
+func decodeResponse(op Op, status uint16, payload []byte) (Response, error) {
+	resp := Response{Status: int(status)}
+	r := bytes.NewReader(payload)
+
+	var err error
+	if resp.Status != http.StatusOK {
+		resp.Msg, err = readString(r)
+		return resp, err
+	}
+
+	switch op {
+	case OpGet:
+		var ttl int64
+		if err = binary.Read(r, binary.BigEndian, &ttl); err != nil {
+			return resp, err
+		}
+		resp.TTL = time.Duration(ttl)
+		if err = binary.Read(r, binary.BigEndian, &resp.Version); err != nil {
+			return resp, err
+		}
+		if resp.CreatedAt, err = readTime(r); err != nil {
+			return resp, err
+		}
+		if resp.UpdatedAt, err = readTime(r); err != nil {
+			return resp, err
+		}
+		if resp.Meta, err = readMeta(r); err != nil {
+			return resp, err
+		}
+		resp.Value, err = readField(r)
+	case OpMGet, OpMSet, OpMDelete:
+		var batchResp batch.Response
+		batchResp, err = batch.DecodeResponse(r, batch.ContentTypeBinary)
+		resp.Results = batchResp.Results
+	}
+	return resp, err
+}
+
+// unixNano returns 0 for zero time, so it is decoded back to zero time.
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func readTime(r *bytes.Reader) (time.Time, error) {
+	var n int64
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return time.Time{}, err
+	}
+	if n == 0 {
+		return time.Time{}, nil
+	}
+	return time.Unix(0, n), nil
+}
+
+// writeMeta writes content type, content encoding, uint16 number of user headers and their names and values.
+func writeMeta(w io.Writer, m meta.Meta) {
+	writeField(w, []byte(m.ContentType))
+	writeField(w, []byte(m.ContentEncoding))
+	_ = binary.Write(w, binary.BigEndian, uint16(len(m.User)))
+	for name, value := range m.User {
+		writeField(w, []byte(name))
+		writeField(w, []byte(value))
+	}
+}
+
+func readMeta(r *bytes.Reader) (m meta.Meta, err error) {
+	if m.ContentType, err = readString(r); err != nil {
+		return m, err
+	}
+	if m.ContentEncoding, err = readString(r); err != nil {
+		return m, err
+	}
+
+	var n uint16
+	if err = binary.Read(r, binary.BigEndian, &n); err != nil {
+		return m, err
+	}
+	if n > 0 {
+		m.User = make(map[string]string, n)
+	}
+	for range n {
+		name, err := readString(r)
+		if err != nil {
+			return m, err
+		}
+		if m.User[name], err = readString(r); err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+func readField(r *bytes.Reader) ([]byte, error) {
+	var n uint32
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	if int64(n) > int64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	b := make([]byte, n)
+	_, err := io.ReadFull(r, b)
+	return b, err
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	b, err := readField(r)
+	return string(b), err
+}
+
+func writeField(w io.Writer, b []byte) {
+	_ = binary.Write(w, binary.BigEndian, uint32(len(b)))
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,108 @@
+package wire
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aosderzhikov/sticky/internal/batch"
+	"github.com/aosderzhikov/sticky/internal/meta"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		req  Request
+	}{
+		{"get", Request{Op: OpGet, Key: "key"}},
+		{"set", Request{Op: OpSet, Key: "key", Value: []byte("value"), TTL: time.Minute}},
+		{"set empty value", Request{Op: OpSet, Key: "key", Value: []byte{}}},
+		{"set with meta", Request{Op: OpSet, Key: "key", Value: []byte("{}"), Meta: meta.Meta{
+			ContentType: "application/json", ContentEncoding: "gzip", User: map[string]string{"Owner": "team-a", "Source": "import"},
+		}}},
+		{"delete", Request{Op: OpDelete, Key: "key"}},
+		{"mdel", Request{Op: OpMDelete, Batch: batch.Request{Items: []batch.Item{{Key: "a", Value: []byte{}}}}}},
+		{"mset", Request{Op: OpMSet, Batch: batch.Request{Items: []batch.Item{{Key: "a", Value: []byte("1"), TTL: batch.Duration(time.Second)}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := encodeRequest(tt.req)
+			require.NoError(t, err)
+
+			req, err := decodeRequest(tt.req.Op, payload)
+			require.NoError(t, err)
+			require.Equal(t, tt.req, req)
+		})
+	}
+}
+
+func TestResponseRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		op   Op
+		resp Response
+	}{
+		{"get", OpGet, Response{Status: http.StatusOK, Value: []byte("value"), TTL: time.Minute, Version: 7,
+			CreatedAt: time.Unix(0, 1700000000000000000), UpdatedAt: time.Unix(0, 1700000001000000000),
+			Meta: meta.Meta{ContentType: "image/png", User: map[string]string{"Owner": "team-a"}}}},
+		{"get persisted", OpGet, Response{Status: http.StatusOK, Value: []byte{}, TTL: -1, Version: 1}},
+		{"set", OpSet, OKResponse()},
+		{"not found", OpGet, Response{Status: http.StatusNotFound, Msg: "key not found"}},
+		{"mget", OpMGet, Response{Status: http.StatusOK, Results: []batch.Result{
+			{Key: "a", Status: batch.StatusOK, Value: []byte("1")},
+			{Key: "b", Status: batch.StatusNotFound, Value: []byte{}},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := encodeResponse(tt.op, tt.resp)
+			require.NoError(t, err)
+
+			resp, err := decodeResponse(tt.op, uint16(tt.resp.Status), payload)
+			require.NoError(t, err)
+			require.Equal(t, tt.resp, resp)
+		})
+	}
+}
+
+func TestDecodeRequestErrors(t *testing.T) {
+	_, err := decodeRequest(Op(100), nil)
+	require.ErrorIs(t, err, ErrUnknownOp)
+
+	// field length is larger than payload
+	var b bytes.Buffer
+	_ = binary.Write(&b, binary.BigEndian, uint32(1<<30))
+	_, err = decodeRequest(OpGet, b.Bytes())
+	require.Error(t, err)
+}
+
+func TestFrame(t *testing.T) {
+	var b bytes.Buffer
+	w := bufio.NewWriter(&b)
+	require.NoError(t, writeFrame(w, frame{id: 1, code: uint16(OpGet), payload: []byte("abc")}))
+	require.NoError(t, writeFrame(w, frame{id: 2, code: http.StatusOK}))
+
+	r := bufio.NewReader(&b)
+	f, err := readFrame(r)
+	require.NoError(t, err)
+	require.Equal(t, frame{id: 1, code: uint16(OpGet), payload: []byte("abc")}, f)
+
+	f, err = readFrame(r)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), f.id)
+	require.Empty(t, f.payload)
+
+	b.Reset()
+	_ = binary.Write(&b, binary.BigEndian, uint32(MaxFrameLen+1))
+	_, err = readFrame(bufio.NewReader(&b))
+	require.ErrorIs(t, err, ErrFrameTooLarge)
+
+	b.Reset()
+	_ = binary.Write(&b, binary.BigEndian, uint32(1))
+	_, err = readFrame(bufio.NewReader(&b))
+	require.ErrorIs(t, err, ErrInvalidFrame)
+}